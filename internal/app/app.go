@@ -1,12 +1,16 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -15,17 +19,25 @@ import (
 	"github.com/vikvang/zero/internal/csync"
 	"github.com/vikvang/zero/internal/db"
 	"github.com/vikvang/zero/internal/format"
+	"github.com/vikvang/zero/internal/grants"
 	"github.com/vikvang/zero/internal/history"
+	"github.com/vikvang/zero/internal/home"
 	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/llm/tools"
 	"github.com/vikvang/zero/internal/log"
 	"github.com/vikvang/zero/internal/pubsub"
 
 	"github.com/vikvang/zero/internal/lsp"
 	"github.com/vikvang/zero/internal/message"
 	"github.com/vikvang/zero/internal/permission"
+	"github.com/vikvang/zero/internal/sandbox"
 	"github.com/vikvang/zero/internal/session"
+	"github.com/vikvang/zero/internal/shell"
 )
 
+// ErrNoSessions is returned when there are no sessions to continue.
+var ErrNoSessions = errors.New("no sessions found")
+
 type App struct {
 	Sessions    session.Service
 	Messages    message.Service
@@ -64,12 +76,30 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
 		allowedTools = cfg.Permissions.AllowedTools
 	}
+	policy, err := permissionPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := permissionSandbox(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Global permission grants live in the global data directory, so they
+	// apply to every project.
+	var globalQueries db.Querier
+	globalConn, err := ConnectGlobalDB(ctx)
+	if err != nil {
+		slog.Warn("Global permission grants are not available", "error", err)
+	} else {
+		globalQueries = db.New(globalConn)
+	}
 
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
-		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
+		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools, policy, grants.NewStore(q, globalQueries), sb),
 		LSPClients:  make(map[string]*lsp.Client),
 
 		globalCtx: ctx,
@@ -83,8 +113,22 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		tuiWG:           &sync.WaitGroup{},
 	}
 
+	if globalConn != nil {
+		app.cleanupFuncs = append(app.cleanupFuncs, func() {
+			if err := globalConn.Close(); err != nil {
+				slog.Error("Failed to close global database", "error", err)
+			}
+		})
+	}
+
 	app.setupEvents()
 
+	// The persistent shell is created by the first bash command.
+	shell.ConfigurePersistentShell(shell.PersistentShellOptions{
+		Env:        cfg.ShellEnv(),
+		InitScript: cfg.InitScript(),
+	})
+
 	// Initialize LSP clients in the background.
 	app.initLSPClients(ctx)
 
@@ -99,34 +143,150 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	return app, nil
 }
 
+// ConnectGlobalDB connects to the database in the global data directory,
+// which holds the data shared by all projects, e.g. global permission grants.
+func ConnectGlobalDB(ctx context.Context) (*sql.DB, error) {
+	dir := config.GlobalDataDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create global data directory: %w", err)
+	}
+	return db.Connect(ctx, dir)
+}
+
+// permissionPolicy compiles the permission rules from the config.
+func permissionPolicy(cfg *config.Config) (*permission.Policy, error) {
+	var rules []permission.Rule
+	if cfg.Permissions != nil {
+		for _, r := range cfg.Permissions.Rules {
+			rules = append(rules, permission.Rule{
+				Decision: permission.Decision(r.Decision),
+				Tool:     r.Tool,
+				Action:   r.Action,
+				Path:     r.Path,
+				Command:  r.Command,
+				Host:     r.Host,
+			})
+		}
+	}
+	policy, err := permission.NewPolicy(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permission rules: %w", err)
+	}
+	return policy, nil
+}
+
+// permissionSandbox creates the sandbox from the config, or returns nil if
+// it isn't enabled.
+func permissionSandbox(cfg *config.Config) (*sandbox.Sandbox, error) {
+	if cfg.Permissions == nil || cfg.Permissions.Sandbox == nil || !cfg.Permissions.Sandbox.Enabled {
+		return nil, nil
+	}
+	sc := cfg.Permissions.Sandbox
+	if sc.Outside != "" && sc.Outside != "deny" && sc.Outside != "ask" {
+		return nil, fmt.Errorf("invalid sandbox outside %q: must be deny or ask", sc.Outside)
+	}
+
+	roots := []string{cfg.WorkingDir(), os.TempDir()}
+	if len(sc.WritableRoots) > 0 {
+		roots = nil
+		for _, root := range sc.WritableRoots {
+			root = home.Long(root)
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(cfg.WorkingDir(), root)
+			}
+			roots = append(roots, root)
+		}
+	}
+	slog.Info("Sandbox enabled", "roots", roots, "outside", cmp.Or(sc.Outside, "deny"), "deny_network", sc.DenyNetwork)
+	return sandbox.New(sandbox.Options{
+		Roots:       roots,
+		Ask:         sc.Outside == "ask",
+		DenyNetwork: sc.DenyNetwork,
+	}), nil
+}
+
 // Config returns the application configuration.
 func (app *App) Config() *config.Config {
 	return app.config
 }
 
+// RunOptions configures a non-interactive run.
+type RunOptions struct {
+	// Quiet hides the spinner.
+	Quiet bool
+	// OutputFormat is the format used to print the results, defaults to
+	// [OutputFormatText].
+	OutputFormat OutputFormat
+	// SessionID is the ID of an existing session to continue. A new session is
+	// created if empty.
+	SessionID string
+	// Continue continues the most recent session.
+	Continue bool
+	// Agent is the ID of the configured agent to run, the coder agent if
+	// empty.
+	Agent string
+	// MaxCost is the maximum cost of the run in US dollars, zero for no
+	// limit.
+	MaxCost float64
+	// MaxTurns is the maximum number of requests to the model of the run,
+	// zero for no limit.
+	MaxTurns int
+}
+
 // RunNonInteractive handles the execution flow when a prompt is provided via
 // CLI flag.
-func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool) error {
+func (app *App) RunNonInteractive(ctx context.Context, prompt string, opts RunOptions) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Start spinner if not in quiet mode.
-	var spinner *format.Spinner
-	if !quiet {
-		spinner = format.NewSpinner(ctx, cancel, "Generating")
-		spinner.Start()
+	if opts.Agent != "" {
+		if err := app.SwitchAgent(opts.Agent); err != nil {
+			return err
+		}
 	}
+	app.config.Options.Budget.MaxRunCost = opts.MaxCost
+	app.config.Options.Budget.MaxRunTurns = opts.MaxTurns
 
-	// Helper function to stop spinner once.
-	stopSpinner := func() {
-		if !quiet && spinner != nil {
-			spinner.Stop()
-			spinner = nil
+	sess, err := app.runSession(ctx, prompt, opts)
+	if err != nil {
+		return err
+	}
+
+	// Automatically approve all permission requests for this non-interactive session
+	app.Permissions.AutoApproveSession(sess.ID)
+
+	switch opts.OutputFormat {
+	case OutputFormatJSON, OutputFormatStreamJSON:
+		return app.runNonInteractiveJSON(ctx, sess.ID, prompt, opts.OutputFormat)
+	default:
+		return app.runNonInteractiveText(ctx, cancel, sess.ID, prompt, opts.Quiet)
+	}
+}
+
+// runSession returns the session used by a non-interactive run: the requested
+// one, the most recent one, or a new one.
+func (app *App) runSession(ctx context.Context, prompt string, opts RunOptions) (session.Session, error) {
+	switch {
+	case opts.SessionID != "":
+		sess, err := app.Sessions.Get(ctx, opts.SessionID)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to get session %s: %w", opts.SessionID, err)
+		}
+		slog.Info("Resuming session for non-interactive run", "session_id", sess.ID)
+		return sess, nil
+	case opts.Continue:
+		sess, err := app.LatestSession(ctx)
+		if err == nil {
+			slog.Info("Continuing latest session for non-interactive run", "session_id", sess.ID)
+			return sess, nil
+		}
+		if !errors.Is(err, ErrNoSessions) {
+			return session.Session{}, err
 		}
+		slog.Info("No session to continue, creating a new one")
 	}
-	defer stopSpinner()
 
 	const maxPromptLengthForTitle = 100
 	titlePrefix := "Non-interactive: "
@@ -141,19 +301,51 @@ func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool
 
 	sess, err := app.Sessions.Create(ctx, title)
 	if err != nil {
-		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+		return session.Session{}, fmt.Errorf("failed to create session for non-interactive mode: %w", err)
 	}
 	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
+	return sess, nil
+}
 
-	// Automatically approve all permission requests for this non-interactive session
-	app.Permissions.AutoApproveSession(sess.ID)
+// LatestSession returns the most recently updated top-level session of the
+// project.
+func (app *App) LatestSession(ctx context.Context) (session.Session, error) {
+	sessions, err := app.Sessions.List(ctx)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return session.Session{}, ErrNoSessions
+	}
+	return slices.MaxFunc(sessions, func(a, b session.Session) int {
+		return cmp.Compare(a.UpdatedAt, b.UpdatedAt)
+	}), nil
+}
 
-	done, err := app.CoderAgent.Run(ctx, sess.ID, prompt)
+func (app *App) runNonInteractiveText(ctx context.Context, cancel context.CancelFunc, sessionID, prompt string, quiet bool) error {
+	// Start spinner if not in quiet mode.
+	var spinner *format.Spinner
+	if !quiet {
+		spinner = format.NewSpinner(ctx, cancel, "Generating")
+		spinner.Start()
+	}
+
+	// Helper function to stop spinner once.
+	stopSpinner := func() {
+		if !quiet && spinner != nil {
+			spinner.Stop()
+			spinner = nil
+		}
+	}
+	defer stopSpinner()
+
+	done, err := app.CoderAgent.Run(ctx, sessionID, prompt)
 	if err != nil {
 		return fmt.Errorf("failed to start agent processing stream: %w", err)
 	}
 
 	messageEvents := app.Messages.Subscribe(ctx)
+	agentEvents := app.CoderAgent.Subscribe(ctx)
 	messageReadBytes := make(map[string]int)
 
 	for {
@@ -163,7 +355,7 @@ func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool
 
 			if result.Error != nil {
 				if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, agent.ErrRequestCancelled) {
-					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
+					slog.Info("Non-interactive: agent processing cancelled", "session_id", sessionID)
 					return nil
 				}
 				return fmt.Errorf("agent processing failed: %w", result.Error)
@@ -179,12 +371,20 @@ func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool
 			fmt.Println(msgContent[readBts:])
 			messageReadBytes[result.Message.ID] = len(msgContent)
 
-			slog.Info("Non-interactive: run completed", "session_id", sess.ID)
+			if err := agent.BudgetError(result.Message); err != nil {
+				return err
+			}
+			slog.Info("Non-interactive: run completed", "session_id", sessionID)
 			return nil
 
+		case event := <-agentEvents:
+			if event.Payload.Type == agent.AgentEventTypeWarning && event.Payload.SessionID == sessionID {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", event.Payload.Warning)
+			}
+
 		case event := <-messageEvents:
 			msg := event.Payload
-			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
+			if msg.SessionID == sessionID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
 				content := msg.Content().String()
@@ -207,6 +407,113 @@ func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool
 	}
 }
 
+func (app *App) runNonInteractiveJSON(ctx context.Context, sessionID, prompt string, outputFormat OutputFormat) error {
+	out := newJSONOutput(os.Stdout, outputFormat, sessionID)
+
+	// Subscribe before starting the agent so no events are missed.
+	messageEvents := app.Messages.Subscribe(ctx)
+	permissionEvents := app.Permissions.SubscribeNotifications(ctx)
+	sessionEvents := app.Sessions.Subscribe(ctx)
+	agentEvents := app.CoderAgent.Subscribe(ctx)
+
+	done, err := app.CoderAgent.Run(ctx, sessionID, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to start agent processing stream: %w", err)
+	}
+
+	handle := func(event any) error {
+		switch event := event.(type) {
+		case pubsub.Event[message.Message]:
+			return out.handleMessage(event.Payload)
+		case pubsub.Event[permission.PermissionNotification]:
+			return out.handlePermission(event.Payload)
+		case pubsub.Event[session.Session]:
+			return out.handleSession(event.Payload)
+		case pubsub.Event[agent.AgentEvent]:
+			return out.handleAgentEvent(event.Payload)
+		}
+		return nil
+	}
+
+	// drain handles the events that were already published when the agent
+	// finished.
+	drain := func() error {
+		for {
+			var event any
+			var ok bool
+			select {
+			case event, ok = <-messageEvents:
+			case event, ok = <-permissionEvents:
+			case event, ok = <-sessionEvents:
+			case event, ok = <-agentEvents:
+			default:
+				return nil
+			}
+			if !ok {
+				return nil
+			}
+			if err := handle(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		var event any
+		select {
+		case result := <-done:
+			if err := drain(); err != nil {
+				return err
+			}
+			if err := out.handleMessage(result.Message); err != nil {
+				return err
+			}
+			if sess, err := app.Sessions.Get(ctx, sessionID); err == nil {
+				if err := out.handleSession(sess); err != nil {
+					return err
+				}
+			}
+			runErr := result.Error
+			if runErr == nil {
+				runErr = agent.BudgetError(result.Message)
+			}
+			if err := out.finish(result.Message, runErr); err != nil {
+				return err
+			}
+
+			if errors.Is(runErr, agent.ErrBudgetExceeded) {
+				return runErr
+			}
+			if result.Error != nil {
+				if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, agent.ErrRequestCancelled) {
+					slog.Info("Non-interactive: agent processing cancelled", "session_id", sessionID)
+					return nil
+				}
+				return fmt.Errorf("agent processing failed: %w", result.Error)
+			}
+			slog.Info("Non-interactive: run completed", "session_id", sessionID)
+			return nil
+		case event = <-messageEvents:
+		case event = <-permissionEvents:
+		case event = <-sessionEvents:
+		case event = <-agentEvents:
+		case <-ctx.Done():
+			// Still print the summary so callers get the events collected so
+			// far.
+			if err := drain(); err != nil {
+				return err
+			}
+			if err := out.finish(out.lastMessage, ctx.Err()); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+}
+
 func (app *App) UpdateAgentModel() error {
 	return app.CoderAgent.UpdateModel()
 }
@@ -221,6 +528,8 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", agent.SubscribeMCPEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "jobs", shell.SubscribeJobEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "bash-output", tools.SubscribeBashOutput, app.events)
 	cleanupFunc := func() {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -261,13 +570,14 @@ func setupSubscriber[T any](
 	})
 }
 
+// InitCoderAgent creates the primary agent, the one the user talks to. It's
+// the coder agent unless another one was selected with [App.SwitchAgent].
 func (app *App) InitCoderAgent() error {
-	coderAgentCfg := app.config.Agents["coder"]
+	coderAgentCfg := app.config.PrimaryAgent()
 	if coderAgentCfg.ID == "" {
 		return fmt.Errorf("coder agent configuration is missing")
 	}
-	var err error
-	app.CoderAgent, err = agent.NewAgent(
+	coderAgent, err := agent.NewAgent(
 		app.globalCtx,
 		coderAgentCfg,
 		app.Permissions,
@@ -277,17 +587,38 @@ func (app *App) InitCoderAgent() error {
 		app.LSPClients,
 	)
 	if err != nil {
-		slog.Error("Failed to create coder agent", "err", err)
+		slog.Error("Failed to create coder agent", "agent", coderAgentCfg.ID, "err", err)
 		return err
 	}
 
-	// Add MCP client cleanup to shutdown process
-	app.cleanupFuncs = append(app.cleanupFuncs, agent.CloseMCPClients)
+	if app.CoderAgent == nil {
+		// Add MCP client cleanup to shutdown process
+		app.cleanupFuncs = append(app.cleanupFuncs, agent.CloseMCPClients)
+	}
+	app.CoderAgent = coderAgent
 
 	setupSubscriber(app.eventsCtx, app.serviceEventsWG, "coderAgent", app.CoderAgent.Subscribe, app.events)
 	return nil
 }
 
+// SwitchAgent replaces the primary agent with the configured agent of the
+// given ID.
+func (app *App) SwitchAgent(id string) error {
+	if app.CoderAgent != nil && app.CoderAgent.IsBusy() {
+		return errors.New("agent is busy, please wait before switching agents")
+	}
+	previous := app.config.PrimaryAgent().ID
+	if err := app.config.SetPrimaryAgent(id); err != nil {
+		return err
+	}
+	if err := app.InitCoderAgent(); err != nil {
+		_ = app.config.SetPrimaryAgent(previous)
+		return err
+	}
+	slog.Info("Switched agent", "agent", id)
+	return nil
+}
+
 // Subscribe sends events to the TUI as tea.Msgs.
 func (app *App) Subscribe(program *tea.Program) {
 	defer log.RecoverPanic("app.Subscribe", func() {
@@ -325,6 +656,9 @@ func (app *App) Shutdown() {
 		app.CoderAgent.CancelAll()
 	}
 
+	// Kill the background jobs started by the bash tool.
+	shell.KillJobs()
+
 	for cancel := range app.watcherCancelFuncs.Seq() {
 		cancel()
 	}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/session"
+)
+
+// fakeSessions is an in-memory session service. Methods that aren't
+// overridden panic through the nil embedded interface.
+type fakeSessions struct {
+	session.Service
+	sessions []session.Session
+}
+
+func (f *fakeSessions) Create(_ context.Context, title string) (session.Session, error) {
+	sess := session.Session{ID: "new", Title: title}
+	f.sessions = append(f.sessions, sess)
+	return sess, nil
+}
+
+func (f *fakeSessions) Get(_ context.Context, id string) (session.Session, error) {
+	for _, sess := range f.sessions {
+		if sess.ID == id {
+			return sess, nil
+		}
+	}
+	return session.Session{}, sql.ErrNoRows
+}
+
+func (f *fakeSessions) List(context.Context) ([]session.Session, error) {
+	return f.sessions, nil
+}
+
+func TestRunSession(t *testing.T) {
+	t.Parallel()
+
+	// Sessions are listed by creation time, newest first.
+	existing := []session.Session{
+		{ID: "newest", CreatedAt: 300, UpdatedAt: 300},
+		{ID: "recently-used", CreatedAt: 200, UpdatedAt: 500},
+		{ID: "oldest", CreatedAt: 100, UpdatedAt: 100},
+	}
+
+	tests := []struct {
+		name      string
+		sessions  []session.Session
+		opts      RunOptions
+		wantID    string
+		wantErr   bool
+		wantTitle string
+	}{
+		{
+			name:      "creates a new session by default",
+			sessions:  existing,
+			wantID:    "new",
+			wantTitle: "Non-interactive: hello",
+		},
+		{
+			name:     "resumes the requested session",
+			sessions: existing,
+			opts:     RunOptions{SessionID: "oldest"},
+			wantID:   "oldest",
+		},
+		{
+			name:     "fails for an unknown session",
+			sessions: existing,
+			opts:     RunOptions{SessionID: "unknown"},
+			wantErr:  true,
+		},
+		{
+			name:     "continues the most recently updated session",
+			sessions: existing,
+			opts:     RunOptions{Continue: true},
+			wantID:   "recently-used",
+		},
+		{
+			name:      "creates a session when there is nothing to continue",
+			opts:      RunOptions{Continue: true},
+			wantID:    "new",
+			wantTitle: "Non-interactive: hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			app := &App{Sessions: &fakeSessions{sessions: tt.sessions}}
+			sess, err := app.runSession(t.Context(), "hello", tt.opts)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantID, sess.ID)
+			if tt.wantTitle != "" {
+				require.Equal(t, tt.wantTitle, sess.Title)
+			}
+		})
+	}
+}
+
+func TestLatestSession_NoSessions(t *testing.T) {
+	t.Parallel()
+
+	app := &App{Sessions: &fakeSessions{}}
+	_, err := app.LatestSession(t.Context())
+	require.ErrorIs(t, err, ErrNoSessions)
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/mcpserver"
+	"github.com/vikvang/zero/internal/version"
+)
+
+// MCPTransport is the transport used to serve zero as an MCP server.
+type MCPTransport string
+
+const (
+	MCPTransportStdio MCPTransport = "stdio"
+	MCPTransportHTTP  MCPTransport = "http"
+)
+
+// MCPServeOptions configures the MCP server.
+type MCPServeOptions struct {
+	Transport MCPTransport
+	// Addr is the address the HTTP transport listens on.
+	Addr string
+	// Token is the bearer token clients of the HTTP transport must send.
+	Token string
+	// Permissions decides the permission requests that would prompt the
+	// user.
+	Permissions mcpserver.PermissionMode
+	// Tools are the names of the tools to expose, defaults to
+	// [DefaultMCPServerTools].
+	Tools []string
+}
+
+// DefaultMCPServerTools are the tools exposed by default. The diagnostics
+// and agent tools are only available when LSPs and a provider are
+// configured, respectively.
+var DefaultMCPServerTools = []string{
+	tools.ViewToolName,
+	tools.LSToolName,
+	tools.GlobToolName,
+	tools.GrepToolName,
+	tools.EditToolName,
+	tools.MultiEditToolName,
+	tools.WriteToolName,
+	tools.DiagnosticsToolName,
+	agent.AgentToolName,
+}
+
+// ServeMCP serves zero's tools as an MCP server until the context is done or,
+// for stdio, the client disconnects.
+func (app *App) ServeMCP(ctx context.Context, opts MCPServeOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serverTools, err := app.mcpServerTools(ctx, opts.Tools)
+	if err != nil {
+		return err
+	}
+
+	go mcpserver.AnswerPermissions(ctx, app.Permissions, opts.Permissions)
+
+	srv := mcpserver.New(mcpserver.Options{
+		Name:    "zero",
+		Version: version.Version,
+		Tools:   serverTools,
+		NewSession: func(ctx context.Context) (string, error) {
+			sess, err := app.Sessions.Create(ctx, "MCP client")
+			if err != nil {
+				return "", err
+			}
+			slog.Info("Created session for mcp client", "session_id", sess.ID)
+			return sess.ID, nil
+		},
+	})
+
+	slog.Info("Serving mcp", "transport", opts.Transport, "addr", opts.Addr, "tools", len(serverTools))
+	switch opts.Transport {
+	case MCPTransportHTTP:
+		return srv.ServeHTTP(ctx, opts.Addr, opts.Token)
+	default:
+		return srv.ServeStdio(ctx, os.Stdin, os.Stdout)
+	}
+}
+
+// mcpServerTools returns the tools with the given names, or the default
+// ones.
+func (app *App) mcpServerTools(ctx context.Context, names []string) ([]tools.BaseTool, error) {
+	explicit := len(names) > 0
+	if !explicit {
+		names = DefaultMCPServerTools
+	}
+
+	available, err := app.availableMCPServerTools(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []tools.BaseTool
+	var unknown []string
+	for _, name := range names {
+		idx := slices.IndexFunc(available, func(t tools.BaseTool) bool {
+			return t.Name() == name
+		})
+		if idx < 0 {
+			unknown = append(unknown, name)
+			continue
+		}
+		result = append(result, available[idx])
+	}
+	if explicit && len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown or unavailable tools: %s", strings.Join(unknown, ", "))
+	}
+	return result, nil
+}
+
+func (app *App) availableMCPServerTools(ctx context.Context, names []string) ([]tools.BaseTool, error) {
+	cwd := app.config.WorkingDir()
+	available := []tools.BaseTool{
+		tools.NewBashTool(app.Permissions, cwd),
+		tools.NewDownloadTool(app.Permissions, cwd),
+		tools.NewEditTool(app.LSPClients, app.Permissions, app.History, cwd),
+		tools.NewMultiEditTool(app.LSPClients, app.Permissions, app.History, cwd),
+		tools.NewFetchTool(app.Permissions, cwd),
+		tools.NewGlobTool(cwd),
+		tools.NewGrepTool(cwd),
+		tools.NewJobKillTool(),
+		tools.NewJobOutputTool(),
+		tools.NewLsTool(app.Permissions, cwd),
+		tools.NewSourcegraphTool(),
+		tools.NewViewTool(app.LSPClients, app.Permissions, cwd),
+		tools.NewWriteTool(app.LSPClients, app.Permissions, app.History, cwd),
+	}
+
+	if len(app.config.LSP) > 0 {
+		available = append(available,
+			tools.NewDiagnosticsTool(app.LSPClients),
+			tools.NewLSPDefinitionTool(app.LSPClients, cwd),
+			tools.NewLSPReferencesTool(app.LSPClients, cwd),
+			tools.NewLSPSymbolsTool(app.LSPClients, cwd),
+			tools.NewLSPHoverTool(app.LSPClients, cwd),
+			tools.NewLSPCallHierarchyTool(app.LSPClients, cwd),
+			tools.NewLSPRefactorTool(app.LSPClients, app.Permissions, app.History, cwd),
+		)
+	}
+
+	// The task agent is only created when asked for, since it starts the MCP
+	// clients of the configuration.
+	if slices.Contains(names, agent.AgentToolName) && app.config.IsConfigured() {
+		taskAgentCfg, ok := app.config.Agents["task"]
+		if !ok {
+			return nil, fmt.Errorf("task agent not found in config")
+		}
+		taskAgent, err := agent.NewSubAgent(ctx, taskAgentCfg, app.Permissions, app.Sessions, app.Messages, app.History, app.LSPClients)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create task agent: %w", err)
+		}
+		available = append(available, agent.NewAgentTool(taskAgentCfg, taskAgent, app.Sessions, app.Messages))
+	}
+	return available, nil
+}
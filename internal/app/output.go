@@ -0,0 +1,341 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/permission"
+	"github.com/vikvang/zero/internal/session"
+)
+
+// OutputFormat is the format used to print the results of a non-interactive
+// run.
+type OutputFormat string
+
+const (
+	// OutputFormatText prints the assistant text as it is generated.
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatJSON prints a single summary object, including all events,
+	// once the run is complete.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatStreamJSON prints one event per line as they happen,
+	// followed by the summary object.
+	OutputFormatStreamJSON OutputFormat = "stream-json"
+)
+
+// OutputFormats lists all the supported output formats.
+var OutputFormats = []OutputFormat{
+	OutputFormatText,
+	OutputFormatJSON,
+	OutputFormatStreamJSON,
+}
+
+// IsValid reports whether the output format is supported.
+func (f OutputFormat) IsValid() bool {
+	switch f {
+	case OutputFormatText, OutputFormatJSON, OutputFormatStreamJSON:
+		return true
+	}
+	return false
+}
+
+type RunEventType string
+
+const (
+	RunEventMessageDelta  RunEventType = "message_delta"
+	RunEventMessageFinish RunEventType = "message_finish"
+	RunEventToolUseStart  RunEventType = "tool_use_start"
+	RunEventToolUseStop   RunEventType = "tool_use_stop"
+	RunEventToolResult    RunEventType = "tool_result"
+	RunEventPermission    RunEventType = "permission"
+	RunEventUsage         RunEventType = "usage"
+	RunEventWarning       RunEventType = "warning"
+	RunEventResult        RunEventType = "result"
+)
+
+// RunUsage holds the token usage and cost of a session.
+type RunUsage struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// RunEvent is a single event emitted while running in non-interactive mode
+// with one of the JSON output formats.
+type RunEvent struct {
+	Type         RunEventType         `json:"type"`
+	SessionID    string               `json:"session_id"`
+	MessageID    string               `json:"message_id,omitempty"`
+	Text         string               `json:"text,omitempty"`
+	ToolCallID   string               `json:"tool_call_id,omitempty"`
+	ToolName     string               `json:"tool_name,omitempty"`
+	Input        json.RawMessage      `json:"input,omitempty"`
+	Content      string               `json:"content,omitempty"`
+	Metadata     json.RawMessage      `json:"metadata,omitempty"`
+	IsError      bool                 `json:"is_error,omitempty"`
+	Granted      *bool                `json:"granted,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	Usage        *RunUsage            `json:"usage,omitempty"`
+}
+
+// RunSummary is the last object printed by a non-interactive run with one of
+// the JSON output formats.
+type RunSummary struct {
+	Type         RunEventType         `json:"type"`
+	SessionID    string               `json:"session_id"`
+	Result       string               `json:"result"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	IsError      bool                 `json:"is_error"`
+	Error        string               `json:"error,omitempty"`
+	Usage        RunUsage             `json:"usage"`
+	NumTurns     int                  `json:"num_turns"`
+	DurationMS   int64                `json:"duration_ms"`
+	Events       []RunEvent           `json:"events,omitempty"`
+}
+
+// jsonOutput turns message, permission and session updates into [RunEvent]s
+// and writes them in the requested format.
+type jsonOutput struct {
+	w         io.Writer
+	format    OutputFormat
+	sessionID string
+	startedAt time.Time
+
+	events    []RunEvent
+	readBytes map[string]int
+	toolCalls map[string]message.ToolCall
+	stopped   map[string]bool
+	finished  map[string]bool
+	turns     map[string]bool
+	usage     RunUsage
+
+	// lastMessage is the latest assistant message, used as the result when
+	// the run is interrupted before the agent returns one.
+	lastMessage message.Message
+
+	// Permission decisions can arrive before the tool call they belong to.
+	pendingPermissions map[string]bool
+}
+
+func newJSONOutput(w io.Writer, format OutputFormat, sessionID string) *jsonOutput {
+	return &jsonOutput{
+		w:         w,
+		format:    format,
+		sessionID: sessionID,
+		startedAt: time.Now(),
+		readBytes: make(map[string]int),
+		toolCalls: make(map[string]message.ToolCall),
+		stopped:   make(map[string]bool),
+		finished:  make(map[string]bool),
+		turns:     make(map[string]bool),
+
+		pendingPermissions: make(map[string]bool),
+	}
+}
+
+func (o *jsonOutput) emit(event RunEvent) error {
+	event.SessionID = o.sessionID
+	if o.format != OutputFormatStreamJSON {
+		o.events = append(o.events, event)
+		return nil
+	}
+	return o.writeLine(event)
+}
+
+func (o *jsonOutput) writeLine(v any) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, err = fmt.Fprintln(o.w, string(bts))
+	return err
+}
+
+// handleMessage emits the events for a new or updated message of the
+// session.
+func (o *jsonOutput) handleMessage(msg message.Message) error {
+	if msg.SessionID != o.sessionID {
+		return nil
+	}
+	switch msg.Role {
+	case message.Assistant:
+		return o.handleAssistantMessage(msg)
+	case message.Tool:
+		for _, result := range msg.ToolResults() {
+			if err := o.emit(RunEvent{
+				Type:       RunEventToolResult,
+				MessageID:  msg.ID,
+				ToolCallID: result.ToolCallID,
+				ToolName:   o.toolCalls[result.ToolCallID].Name,
+				Content:    result.Content,
+				Metadata:   rawJSON(result.Metadata),
+				IsError:    result.IsError,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (o *jsonOutput) handleAssistantMessage(msg message.Message) error {
+	o.turns[msg.ID] = true
+	o.lastMessage = msg
+
+	content := msg.Content().String()
+	readBytes := o.readBytes[msg.ID]
+	if len(content) < readBytes {
+		return fmt.Errorf("message content is shorter than read bytes: %d < %d", len(content), readBytes)
+	}
+	if delta := content[readBytes:]; delta != "" {
+		if err := o.emit(RunEvent{
+			Type:      RunEventMessageDelta,
+			MessageID: msg.ID,
+			Text:      delta,
+		}); err != nil {
+			return err
+		}
+		o.readBytes[msg.ID] = len(content)
+	}
+
+	for _, tc := range msg.ToolCalls() {
+		if _, ok := o.toolCalls[tc.ID]; !ok {
+			if err := o.emit(RunEvent{
+				Type:       RunEventToolUseStart,
+				MessageID:  msg.ID,
+				ToolCallID: tc.ID,
+				ToolName:   tc.Name,
+			}); err != nil {
+				return err
+			}
+		}
+		o.toolCalls[tc.ID] = tc
+		if tc.Finished && !o.stopped[tc.ID] {
+			o.stopped[tc.ID] = true
+			if err := o.emit(RunEvent{
+				Type:       RunEventToolUseStop,
+				MessageID:  msg.ID,
+				ToolCallID: tc.ID,
+				ToolName:   tc.Name,
+				Input:      rawJSON(tc.Input),
+			}); err != nil {
+				return err
+			}
+		}
+		if granted, ok := o.pendingPermissions[tc.ID]; ok {
+			delete(o.pendingPermissions, tc.ID)
+			if err := o.emitPermission(tc, granted); err != nil {
+				return err
+			}
+		}
+	}
+
+	if msg.IsFinished() && !o.finished[msg.ID] {
+		o.finished[msg.ID] = true
+		return o.emit(RunEvent{
+			Type:         RunEventMessageFinish,
+			MessageID:    msg.ID,
+			FinishReason: msg.FinishReason(),
+		})
+	}
+	return nil
+}
+
+// handlePermission emits the decision of a permission request. Pending
+// requests and requests from other sessions, such as the ones of sub-agents,
+// are ignored.
+func (o *jsonOutput) handlePermission(n permission.PermissionNotification) error {
+	if n.SessionID != o.sessionID || (!n.Granted && !n.Denied) {
+		return nil
+	}
+	tc, ok := o.toolCalls[n.ToolCallID]
+	if !ok {
+		o.pendingPermissions[n.ToolCallID] = n.Granted
+		return nil
+	}
+	return o.emitPermission(tc, n.Granted)
+}
+
+func (o *jsonOutput) emitPermission(tc message.ToolCall, granted bool) error {
+	return o.emit(RunEvent{
+		Type:       RunEventPermission,
+		ToolCallID: tc.ID,
+		ToolName:   tc.Name,
+		Granted:    &granted,
+	})
+}
+
+// handleSession emits the usage of the session whenever it changes.
+func (o *jsonOutput) handleSession(sess session.Session) error {
+	if sess.ID != o.sessionID {
+		return nil
+	}
+	usage := RunUsage{
+		PromptTokens:     sess.PromptTokens,
+		CompletionTokens: sess.CompletionTokens,
+		Cost:             sess.Cost,
+	}
+	if usage == o.usage {
+		return nil
+	}
+	o.usage = usage
+	return o.emit(RunEvent{
+		Type:  RunEventUsage,
+		Usage: &usage,
+	})
+}
+
+// handleAgentEvent emits the warnings of the agent about the session, such as
+// budgets that are nearly used.
+func (o *jsonOutput) handleAgentEvent(event agent.AgentEvent) error {
+	if event.Type != agent.AgentEventTypeWarning || event.SessionID != o.sessionID {
+		return nil
+	}
+	return o.emit(RunEvent{
+		Type: RunEventWarning,
+		Text: event.Warning,
+	})
+}
+
+// finish writes the summary of the run.
+func (o *jsonOutput) finish(result message.Message, runErr error) error {
+	summary := RunSummary{
+		Type:         RunEventResult,
+		SessionID:    o.sessionID,
+		Result:       result.Content().String(),
+		FinishReason: result.FinishReason(),
+		IsError:      runErr != nil,
+		Usage:        o.usage,
+		NumTurns:     len(o.turns),
+		DurationMS:   time.Since(o.startedAt).Milliseconds(),
+		Events:       o.events,
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+	if o.format == OutputFormatJSON {
+		bts, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal summary: %w", err)
+		}
+		_, err = fmt.Fprintln(o.w, string(bts))
+		return err
+	}
+	return o.writeLine(summary)
+}
+
+// rawJSON returns s as a raw JSON value, quoting it if it isn't valid JSON.
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	bts, _ := json.Marshal(s)
+	return bts
+}
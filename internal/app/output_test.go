@@ -0,0 +1,184 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/permission"
+	"github.com/vikvang/zero/internal/session"
+)
+
+func TestJSONOutput_StreamJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newJSONOutput(&buf, OutputFormatStreamJSON, "session")
+
+	msg := message.Message{
+		ID:        "msg",
+		SessionID: "session",
+		Role:      message.Assistant,
+		Parts:     []message.ContentPart{message.TextContent{Text: "Hello"}},
+	}
+	require.NoError(t, out.handleMessage(msg))
+
+	msg.Parts = []message.ContentPart{
+		message.TextContent{Text: "Hello, world"},
+		message.ToolCall{ID: "call", Name: "view", Input: `{"file_path":"main.go"}`, Finished: true},
+		message.Finish{Reason: message.FinishReasonToolUse},
+	}
+	require.NoError(t, out.handleMessage(msg))
+	require.NoError(t, out.handlePermission(permission.PermissionNotification{SessionID: "session", ToolCallID: "call", Granted: true}))
+	require.NoError(t, out.handleMessage(message.Message{
+		ID:        "tool",
+		SessionID: "session",
+		Role:      message.Tool,
+		Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call", Content: "package main", Metadata: `{"lines":1}`},
+		},
+	}))
+	require.NoError(t, out.handleSession(session.Session{ID: "session", PromptTokens: 10, CompletionTokens: 5, Cost: 0.5}))
+	require.NoError(t, out.finish(msg, nil))
+
+	var events []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event["type"].(string))
+	}
+	require.Equal(t, []string{
+		"message_delta",
+		"message_delta",
+		"tool_use_start",
+		"tool_use_stop",
+		"message_finish",
+		"permission",
+		"tool_result",
+		"usage",
+		"result",
+	}, types)
+
+	require.Equal(t, ", world", events[1]["text"])
+	require.Equal(t, map[string]any{"file_path": "main.go"}, events[3]["input"])
+	require.Equal(t, "view", events[6]["tool_name"])
+	require.Equal(t, map[string]any{"lines": float64(1)}, events[6]["metadata"])
+
+	summary := events[len(events)-1]
+	require.Equal(t, "Hello, world", summary["result"])
+	require.Equal(t, "tool_use", summary["finish_reason"])
+	require.Equal(t, float64(1), summary["num_turns"])
+	require.Equal(t, 0.5, summary["usage"].(map[string]any)["cost"])
+}
+
+func TestJSONOutput_JSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newJSONOutput(&buf, OutputFormatJSON, "session")
+
+	msg := message.Message{
+		ID:        "msg",
+		SessionID: "session",
+		Role:      message.Assistant,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: "Done"},
+			message.Finish{Reason: message.FinishReasonEndTurn},
+		},
+	}
+	require.NoError(t, out.handleMessage(msg))
+	require.NoError(t, out.handleMessage(message.Message{ID: "other", SessionID: "other", Role: message.Assistant}))
+	require.Empty(t, buf.String(), "events should only be written with the summary")
+
+	require.NoError(t, out.finish(msg, nil))
+
+	var summary RunSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	require.Equal(t, RunEventResult, summary.Type)
+	require.Equal(t, "Done", summary.Result)
+	require.False(t, summary.IsError)
+	require.Len(t, summary.Events, 2)
+	require.Equal(t, RunEventMessageDelta, summary.Events[0].Type)
+	require.Equal(t, RunEventMessageFinish, summary.Events[1].Type)
+}
+
+func TestJSONOutput_IgnoresOtherSessions(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newJSONOutput(&buf, OutputFormatStreamJSON, "session")
+
+	// Grants for tool calls of sub-agents are published before their tool
+	// calls are stored in the child session.
+	require.NoError(t, out.handlePermission(permission.PermissionNotification{SessionID: "child", ToolCallID: "child-call", Granted: true}))
+	require.NoError(t, out.handleMessage(message.Message{
+		ID:        "child-msg",
+		SessionID: "child",
+		Role:      message.Assistant,
+		Parts:     []message.ContentPart{message.ToolCall{ID: "child-call", Name: "view", Finished: true}},
+	}))
+	require.Empty(t, out.pendingPermissions)
+	require.Empty(t, buf.String())
+}
+
+func TestJSONOutput_FinishInterrupted(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newJSONOutput(&buf, OutputFormatJSON, "session")
+
+	require.NoError(t, out.handleMessage(message.Message{
+		ID:        "msg",
+		SessionID: "session",
+		Role:      message.Assistant,
+		Parts:     []message.ContentPart{message.TextContent{Text: "Partial"}},
+	}))
+	require.NoError(t, out.finish(out.lastMessage, context.Canceled))
+
+	var summary RunSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	require.True(t, summary.IsError)
+	require.Equal(t, context.Canceled.Error(), summary.Error)
+	require.Equal(t, "Partial", summary.Result)
+	require.Len(t, summary.Events, 1)
+}
+
+func TestJSONOutput_BudgetExceeded(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newJSONOutput(&buf, OutputFormatJSON, "session")
+
+	require.NoError(t, out.handleAgentEvent(agent.AgentEvent{Type: agent.AgentEventTypeWarning, SessionID: "session", Warning: "Run budget: used $0.85 of $1.00"}))
+	require.NoError(t, out.handleAgentEvent(agent.AgentEvent{Type: agent.AgentEventTypeWarning, SessionID: "child", Warning: "Session budget: used $4.50 of $5.00"}))
+	msg := message.Message{
+		ID:        "msg",
+		SessionID: "session",
+		Role:      message.Assistant,
+		Parts: []message.ContentPart{
+			message.Finish{Reason: message.FinishReasonBudgetExceeded, Message: "Budget exceeded", Details: "Run budget: used $1.05 of $1.00"},
+		},
+	}
+	require.NoError(t, out.handleMessage(msg))
+	require.NoError(t, out.finish(msg, agent.BudgetError(msg)))
+
+	var summary RunSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	require.True(t, summary.IsError)
+	require.Equal(t, message.FinishReasonBudgetExceeded, summary.FinishReason)
+	require.Equal(t, "budget exceeded: Run budget: used $1.05 of $1.00", summary.Error)
+	require.Len(t, summary.Events, 2)
+	require.Equal(t, RunEventWarning, summary.Events[0].Type)
+	require.Equal(t, "Run budget: used $0.85 of $1.00", summary.Events[0].Text)
+	require.Equal(t, RunEventMessageFinish, summary.Events[1].Type)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/vikvang/zero/internal/history"
+	"github.com/vikvang/zero/internal/message"
+)
+
+// ErrSessionBusy is returned when rewinding a session the agent is working
+// on.
+var ErrSessionBusy = errors.New("session is busy, cancel the current request first")
+
+// Rewind restores the files of a session to the checkpoint. If conversation
+// is set, it also deletes the messages of the checkpoint's turn and every
+// turn after it, so the conversation continues from before the turn.
+func (app *App) Rewind(ctx context.Context, checkpoint history.Checkpoint, conversation bool) ([]history.FileChange, error) {
+	if app.CoderAgent != nil && app.CoderAgent.IsSessionBusy(checkpoint.SessionID) {
+		return nil, ErrSessionBusy
+	}
+
+	var deleted []message.Message
+	if conversation {
+		msgs, err := app.Messages.List(ctx, checkpoint.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list messages: %w", err)
+		}
+		idx := slices.IndexFunc(msgs, func(msg message.Message) bool {
+			return msg.ID == checkpoint.MessageID
+		})
+		if idx == -1 {
+			return nil, fmt.Errorf("message %s of the checkpoint not found", checkpoint.MessageID)
+		}
+		deleted = msgs[idx:]
+	}
+
+	changes, err := app.History.RestoreCheckpoint(ctx, checkpoint.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore checkpoint: %w", err)
+	}
+	if !conversation {
+		return changes, nil
+	}
+
+	// A summary from a rewound turn doesn't summarize the conversation
+	// anymore.
+	sess, err := app.Sessions.Get(ctx, checkpoint.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if slices.ContainsFunc(deleted, func(msg message.Message) bool { return msg.ID == sess.SummaryMessageID }) {
+		sess.SummaryMessageID = ""
+		if _, err := app.Sessions.Save(ctx, sess); err != nil {
+			return nil, fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+
+	// Newest first, so the conversation is never left with a response
+	// without its prompt.
+	for _, msg := range slices.Backward(deleted) {
+		if err := app.Messages.Delete(ctx, msg.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete message %s: %w", msg.ID, err)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/history"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/session"
+)
+
+type fakeHistory struct {
+	history.Service
+	restored []string
+}
+
+func (f *fakeHistory) RestoreCheckpoint(_ context.Context, checkpointID string) ([]history.FileChange, error) {
+	f.restored = append(f.restored, checkpointID)
+	return []history.FileChange{{Path: "/project/main.go"}}, nil
+}
+
+type fakeMessages struct {
+	message.Service
+	messages []message.Message
+}
+
+func (f *fakeMessages) List(context.Context, string) ([]message.Message, error) {
+	return slices.Clone(f.messages), nil
+}
+
+func (f *fakeMessages) Delete(_ context.Context, id string) error {
+	f.messages = slices.DeleteFunc(f.messages, func(msg message.Message) bool {
+		return msg.ID == id
+	})
+	return nil
+}
+
+func (f *fakeSessions) Save(_ context.Context, sess session.Session) (session.Session, error) {
+	for i := range f.sessions {
+		if f.sessions[i].ID == sess.ID {
+			f.sessions[i] = sess
+		}
+	}
+	return sess, nil
+}
+
+func TestRewind(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() (*App, *fakeHistory, *fakeMessages, *fakeSessions) {
+		files := &fakeHistory{}
+		msgs := &fakeMessages{messages: []message.Message{
+			{ID: "user-1", Role: message.User},
+			{ID: "assistant-1", Role: message.Assistant},
+			{ID: "user-2", Role: message.User},
+			{ID: "assistant-2", Role: message.Assistant},
+			{ID: "summary", Role: message.Assistant},
+		}}
+		sessions := &fakeSessions{sessions: []session.Session{{ID: "session", SummaryMessageID: "summary"}}}
+		return &App{History: files, Messages: msgs, Sessions: sessions}, files, msgs, sessions
+	}
+	checkpoint := history.Checkpoint{ID: "checkpoint-2", SessionID: "session", MessageID: "user-2"}
+
+	t.Run("restores only the files", func(t *testing.T) {
+		t.Parallel()
+		app, files, msgs, _ := newApp()
+
+		changes, err := app.Rewind(t.Context(), checkpoint, false)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, []string{"checkpoint-2"}, files.restored)
+		require.Len(t, msgs.messages, 5)
+	})
+
+	t.Run("rewinds the conversation", func(t *testing.T) {
+		t.Parallel()
+		app, files, msgs, sessions := newApp()
+
+		_, err := app.Rewind(t.Context(), checkpoint, true)
+		require.NoError(t, err)
+		require.Equal(t, []string{"checkpoint-2"}, files.restored)
+
+		var ids []string
+		for _, msg := range msgs.messages {
+			ids = append(ids, msg.ID)
+		}
+		require.Equal(t, []string{"user-1", "assistant-1"}, ids)
+		require.Empty(t, sessions.sessions[0].SummaryMessageID, "the summary was rewound")
+	})
+
+	t.Run("fails for an unknown message", func(t *testing.T) {
+		t.Parallel()
+		app, files, _, _ := newApp()
+
+		_, err := app.Rewind(t.Context(), history.Checkpoint{ID: "checkpoint", SessionID: "session", MessageID: "unknown"}, true)
+		require.Error(t, err)
+		require.Empty(t, files.restored, "files must not be restored when the conversation can't be")
+	})
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vikvang/zero/internal/app"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/mcpserver"
+)
+
+// mcpLoginTimeout bounds the time the user has to authorize in the browser.
+const mcpLoginTimeout = 5 * time.Minute
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve and authorize MCP servers",
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve zero's tools as an MCP server",
+	Long: `Serve zero's tools to other agents and editors as a Model Context Protocol server.
+Each client gets its own session. Since there's no one to answer the permission
+prompts, they are decided by the --permissions mode, after the permission rules
+and allowed tools of the configuration.`,
+	Example: `
+# Serve the default tools over stdio
+zero mcp serve
+
+# Serve over streamable HTTP, printing the token clients must send
+zero mcp serve --transport http --addr 127.0.0.1:8765
+
+# Only expose the read-only tools
+zero mcp serve --tools view,ls,glob,grep
+
+# Grant the permission requests not denied by rules
+zero mcp serve --permissions allow
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		transport, _ := cmd.Flags().GetString("transport")
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		permissions, _ := cmd.Flags().GetString("permissions")
+		toolNames, _ := cmd.Flags().GetStringSlice("tools")
+
+		mode := mcpserver.PermissionMode(permissions)
+		if !mode.IsValid() {
+			return fmt.Errorf("invalid permissions mode %q, must be one of: %s", permissions, strings.Join(permissionModes(), ", "))
+		}
+		switch app.MCPTransport(transport) {
+		case app.MCPTransportStdio, app.MCPTransportHTTP:
+		default:
+			return fmt.Errorf("invalid transport %q, must be one of: stdio, http", transport)
+		}
+
+		appInstance, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer appInstance.Shutdown()
+
+		if app.MCPTransport(transport) == app.MCPTransportHTTP {
+			if token == "" {
+				token = mcpserver.NewToken()
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Serving MCP on http://%s%s\nClients must send the header:\n\nAuthorization: Bearer %s\n\n", addr, mcpserver.HTTPPath, token)
+		}
+
+		return appInstance.ServeMCP(cmd.Context(), app.MCPServeOptions{
+			Transport:   app.MCPTransport(transport),
+			Addr:        addr,
+			Token:       token,
+			Permissions: mode,
+			Tools:       toolNames,
+		})
+	},
+}
+
+var mcpLoginCmd = &cobra.Command{
+	Use:   "login <name>",
+	Short: "Authorize an MCP server that uses OAuth",
+	Long: `Authorize an MCP server configured with "auth": "oauth". The authorization
+page opens in the browser; once access is granted, the token is stored in the
+data directory and refreshed automatically.`,
+	Example: `
+# Authorize the server named "linear" in the configuration
+zero mcp login linear
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadMCPConfig(cmd); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), mcpLoginTimeout)
+		defer cancel()
+		err := agent.AuthorizeMCP(ctx, args[0], func(authURL string) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Open this URL to authorize %s:\n\n%s\n\n", args[0], authURL)
+			if err := openBrowser(authURL); err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Could not open the browser, please open the URL manually.")
+			}
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Authorized %s.\n", args[0])
+		return nil
+	},
+}
+
+var mcpLogoutCmd = &cobra.Command{
+	Use:   "logout <name>",
+	Short: "Delete the stored OAuth credentials of an MCP server",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadMCPConfig(cmd); err != nil {
+			return err
+		}
+		if err := agent.LogoutMCP(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Logged out of %s.\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	mcpServeCmd.Flags().String("transport", string(app.MCPTransportStdio), "Transport: stdio or http")
+	mcpServeCmd.Flags().String("addr", "127.0.0.1:8765", "Address to listen on with the http transport")
+	mcpServeCmd.Flags().String("token", "", "Bearer token clients of the http transport must send (default a random token printed at startup)")
+	mcpServeCmd.Flags().String("permissions", string(mcpserver.PermissionModeDeny), "Answer to permission prompts: "+strings.Join(permissionModes(), ", "))
+	mcpServeCmd.Flags().StringSlice("tools", nil, "Tools to expose (default "+strings.Join(app.DefaultMCPServerTools, ",")+")")
+
+	mcpCmd.AddCommand(mcpServeCmd, mcpLoginCmd, mcpLogoutCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func permissionModes() []string {
+	modes := make([]string, 0, len(mcpserver.PermissionModes))
+	for _, m := range mcpserver.PermissionModes {
+		modes = append(modes, string(m))
+	}
+	return modes
+}
+
+// loadMCPConfig loads the configuration without setting up the app, which
+// would connect to the MCP servers.
+func loadMCPConfig(cmd *cobra.Command) error {
+	debug, _ := cmd.Flags().GetBool("debug")
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Init(cwd, dataDir, debug)
+	if err != nil {
+		return err
+	}
+	return createDotCrushDir(cfg.Options.DataDirectory)
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vikvang/zero/internal/app"
+	"github.com/vikvang/zero/internal/db"
+	"github.com/vikvang/zero/internal/grants"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Review and revoke saved permission grants",
+	Long: `Review and revoke the permission grants saved with "Allow for Session",
+"Allow for Project" and "Allow Globally".`,
+	Example: `
+# List the grants that apply to the current project
+zero permissions list
+
+# Revoke a grant
+zero permissions revoke 3f2a9c1e-...
+
+# Revoke all global grants
+zero permissions revoke --all --scope global
+  `,
+}
+
+var permissionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List permission grants",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scope, err := scopeFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		store, closeStore, err := openGrantStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		grantList, err := listGrants(cmd, store, scope)
+		if err != nil {
+			return err
+		}
+		if len(grantList) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No permission grants found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSCOPE\tTOOL\tACTION\tPATH\tCOMMAND\tSESSION\tCREATED")
+		for _, g := range grantList {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				g.ID,
+				g.Scope,
+				g.ToolName,
+				g.Action,
+				valueOrDash(g.Path),
+				valueOrDash(g.Command),
+				valueOrDash(g.SessionID),
+				formatUnix(g.CreatedAt),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+var permissionsRevokeCmd = &cobra.Command{
+	Use:   "revoke [id]...",
+	Short: "Revoke permission grants",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		scope, err := scopeFlag(cmd)
+		if err != nil {
+			return err
+		}
+		switch {
+		case all && len(args) > 0:
+			return fmt.Errorf("pass either grant IDs or --all, not both")
+		case !all && len(args) == 0:
+			return fmt.Errorf("pass the IDs of the grants to revoke, or --all")
+		}
+
+		store, closeStore, err := openGrantStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+
+		grantList, err := listGrants(cmd, store, scope)
+		if err != nil {
+			return err
+		}
+
+		ids := args
+		if all {
+			ids = nil
+			for _, g := range grantList {
+				ids = append(ids, g.ID)
+			}
+		}
+		for _, id := range ids {
+			if !slices.ContainsFunc(grantList, func(g permission.Grant) bool { return g.ID == id }) {
+				return fmt.Errorf("permission grant %s not found", id)
+			}
+			if err := store.Delete(cmd.Context(), id); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Revoked permission grant %s\n", id)
+		}
+		return nil
+	},
+}
+
+func init() {
+	permissionsCmd.PersistentFlags().String("scope", "", "Only include grants with this scope: session, project or global")
+	permissionsRevokeCmd.Flags().Bool("all", false, "Revoke all grants, or all grants of --scope")
+
+	permissionsCmd.AddCommand(
+		permissionsListCmd,
+		permissionsRevokeCmd,
+	)
+	rootCmd.AddCommand(permissionsCmd)
+}
+
+// openGrantStore opens the grants of the project and the global ones. The
+// returned function closes the databases.
+func openGrantStore(cmd *cobra.Command) (permission.GrantStore, func(), error) {
+	conn, err := openProjectDB(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	conns := []*sql.DB{conn}
+	var global db.Querier
+	if globalConn, err := app.ConnectGlobalDB(cmd.Context()); err != nil {
+		slog.Warn("Global permission grants are not available", "error", err)
+	} else {
+		conns = append(conns, globalConn)
+		global = db.New(globalConn)
+	}
+	closeAll := func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}
+	return grants.NewStore(db.New(conn), global), closeAll, nil
+}
+
+func listGrants(cmd *cobra.Command, store permission.GrantStore, scope permission.GrantScope) ([]permission.Grant, error) {
+	grantList, err := store.List(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	if scope == "" {
+		return grantList, nil
+	}
+	return slices.DeleteFunc(grantList, func(g permission.Grant) bool {
+		return g.Scope != scope
+	}), nil
+}
+
+func scopeFlag(cmd *cobra.Command) (permission.GrantScope, error) {
+	scope, _ := cmd.Flags().GetString("scope")
+	if scope == "" {
+		return "", nil
+	}
+	return permission.ParseGrantScope(scope)
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -26,6 +26,7 @@ func init() {
 
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+	rootCmd.Flags().StringP("session", "s", "", "Open the session with the given ID")
 
 	rootCmd.AddCommand(runCmd)
 }
@@ -57,6 +58,9 @@ zero run "Explain the use of context in Go"
 
 # Run in dangerous mode (auto-accept all permissions)
 zero -y
+
+# Open an existing session
+zero -s 3f2a9c1e-...
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		app, err := setupApp(cmd)
@@ -65,9 +69,21 @@ zero -y
 		}
 		defer app.Shutdown()
 
+		var opts []tui.Option
+		if sessionID, _ := cmd.Flags().GetString("session"); sessionID != "" {
+			if !config.HasInitialDataConfig() {
+				return fmt.Errorf("cannot open session %s: no provider is configured yet, run zero without --session first", sessionID)
+			}
+			sess, err := app.Sessions.Get(cmd.Context(), sessionID)
+			if err != nil {
+				return fmt.Errorf("failed to get session %s: %w", sessionID, err)
+			}
+			opts = append(opts, tui.WithSession(sess))
+		}
+
 		// Set up the TUI.
 		program := tea.NewProgram(
-			tui.New(app),
+			tui.New(app, opts...),
 			tea.WithAltScreen(),
 			tea.WithContext(cmd.Context()),
 			tea.WithMouseCellMotion(),            // Use cell motion instead of all motion to reduce event flooding
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/vikvang/zero/internal/app"
 )
 
 var runCmd = &cobra.Command{
@@ -22,17 +23,47 @@ echo "What is this code doing?" | zero run
 
 # Run with quiet mode (no spinner)
 zero run -q "Generate a README for this project"
+
+# Continue the most recent session
+zero run --continue "Now add tests for it"
+
+# Send a follow-up prompt to a specific session
+zero run --session 3f2a9c1e-... "Summarize what you did"
+
+# Stream events as newline-delimited JSON
+zero run --output-format stream-json "Fix the failing tests"
+
+# Run with an agent defined in the configuration
+zero run --agent reviewer "Review the changes of the last commit"
+
+# Stop once the run costs $2 or after 30 requests to the model
+zero run --max-cost 2 --max-turns 30 "Fix the failing tests"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		outputFormat, _ := cmd.Flags().GetString("output-format")
+		sessionID, _ := cmd.Flags().GetString("session")
+		continueLast, _ := cmd.Flags().GetBool("continue")
+		agentID, _ := cmd.Flags().GetString("agent")
+		maxCost, _ := cmd.Flags().GetFloat64("max-cost")
+		maxTurns, _ := cmd.Flags().GetInt("max-turns")
 
-		app, err := setupApp(cmd)
+		format := app.OutputFormat(outputFormat)
+		if !format.IsValid() {
+			return fmt.Errorf("invalid output format %q, must be one of: %s", outputFormat, strings.Join(outputFormats(), ", "))
+		}
+
+		if maxCost < 0 || maxTurns < 0 {
+			return fmt.Errorf("--max-cost and --max-turns must not be negative")
+		}
+
+		appInstance, err := setupApp(cmd)
 		if err != nil {
 			return err
 		}
-		defer app.Shutdown()
+		defer appInstance.Shutdown()
 
-		if !app.Config().IsConfigured() {
+		if !appInstance.Config().IsConfigured() {
 			return fmt.Errorf("no providers configured - please run 'zero' to set up a provider interactively")
 		}
 
@@ -49,10 +80,33 @@ zero run -q "Generate a README for this project"
 		}
 
 		// Run non-interactive flow using the App method
-		return app.RunNonInteractive(cmd.Context(), prompt, quiet)
+		return appInstance.RunNonInteractive(cmd.Context(), prompt, app.RunOptions{
+			Quiet:        quiet,
+			OutputFormat: format,
+			SessionID:    sessionID,
+			Continue:     continueLast,
+			Agent:        agentID,
+			MaxCost:      maxCost,
+			MaxTurns:     maxTurns,
+		})
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().String("output-format", string(app.OutputFormatText), "Output format: "+strings.Join(outputFormats(), ", "))
+	runCmd.Flags().StringP("session", "s", "", "Continue the session with the given ID")
+	runCmd.Flags().Bool("continue", false, "Continue the most recent session")
+	runCmd.Flags().StringP("agent", "a", "", "Run with the agent of the given ID from the configuration")
+	runCmd.Flags().Float64("max-cost", 0, "Stop once the run costs this many US dollars")
+	runCmd.Flags().Int("max-turns", 0, "Stop after this many requests to the model")
+	runCmd.MarkFlagsMutuallyExclusive("session", "continue")
+}
+
+func outputFormats() []string {
+	formats := make([]string, 0, len(app.OutputFormats))
+	for _, f := range app.OutputFormats {
+		formats = append(formats, string(f))
+	}
+	return formats
 }
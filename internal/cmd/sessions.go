@@ -0,0 +1,585 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/db"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/session"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Inspect and manage sessions",
+	Long:  `List, show, export and delete the sessions stored for the current project.`,
+	Example: `
+# List all sessions of the project
+zero sessions list
+
+# Show the full transcript of a session, including tool calls
+zero sessions show 3f2a9c1e-...
+
+# Export a session as markdown
+zero sessions export 3f2a9c1e-... --format markdown > session.md
+
+# Delete sessions that haven't been used in 30 days
+zero sessions prune --older-than 30d
+  `,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openSessionStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		sessions, err := store.sessions.List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if len(sessions) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No sessions found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTITLE\tMESSAGES\tPROMPT TOKENS\tCOMPLETION TOKENS\tCOST\tUPDATED")
+		for _, sess := range sessions {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%d\t%d\t%d\t$%.4f\t%s\n",
+				sess.ID,
+				truncateTitle(sess.Title, 50),
+				sess.MessageCount,
+				sess.PromptTokens,
+				sess.CompletionTokens,
+				sess.Cost,
+				formatUnix(sess.UpdatedAt),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the transcript of a session",
+	Long:  `Show the transcript of a session, including tool calls, tool results and the sessions of sub-agents.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openSessionStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		transcript, err := store.transcript(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		return writeTranscriptText(cmd.OutOrStdout(), transcript, "")
+	},
+}
+
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Export a session as markdown or JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		if format != "markdown" && format != "json" {
+			return fmt.Errorf("invalid export format %q, must be one of: markdown, json", format)
+		}
+
+		store, err := openSessionStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		transcript, err := store.transcript(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if format == "json" {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(transcript.export())
+		}
+		return writeTranscriptMarkdown(w, transcript, 1)
+	},
+}
+
+var sessionsDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete sessions",
+	Long:  `Delete sessions along with their messages, file history and sub-agent sessions.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openSessionStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		for _, id := range args {
+			if err := store.sessions.Delete(cmd.Context(), id); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("session %s not found", id)
+				}
+				return fmt.Errorf("failed to delete session %s: %w", id, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted session %s\n", id)
+		}
+		return nil
+	},
+}
+
+var sessionsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete sessions that haven't been used for a while",
+	Args:  cobra.NoArgs,
+	Example: `
+# Delete sessions that haven't been updated in two weeks
+zero sessions prune --older-than 2w
+
+# See what would be deleted
+zero sessions prune --older-than 72h --dry-run
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return err
+		}
+
+		store, err := openSessionStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		sessions, err := store.sessions.List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+
+		pruned := sessionsToPrune(sessions, time.Now().Add(-age))
+		for _, sess := range pruned {
+			if !dryRun {
+				if err := store.sessions.Delete(cmd.Context(), sess.ID); err != nil {
+					return fmt.Errorf("failed to delete session %s: %w", sess.ID, err)
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", sess.ID, formatUnix(sess.UpdatedAt), truncateTitle(sess.Title, 50))
+		}
+
+		verb := "Deleted"
+		if dryRun {
+			verb = "Would delete"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %d session(s)\n", verb, len(pruned))
+		return nil
+	},
+}
+
+func init() {
+	sessionsExportCmd.Flags().StringP("format", "f", "markdown", "Export format: markdown or json")
+	sessionsExportCmd.Flags().StringP("output", "o", "", "Write the export to a file instead of stdout")
+
+	sessionsPruneCmd.Flags().String("older-than", "", "Delete sessions last updated before this age, e.g. 72h, 30d or 2w")
+	sessionsPruneCmd.Flags().Bool("dry-run", false, "Only print the sessions that would be deleted")
+	_ = sessionsPruneCmd.MarkFlagRequired("older-than")
+
+	sessionsCmd.AddCommand(
+		sessionsListCmd,
+		sessionsShowCmd,
+		sessionsExportCmd,
+		sessionsDeleteCmd,
+		sessionsPruneCmd,
+	)
+	rootCmd.AddCommand(sessionsCmd)
+}
+
+// sessionStore gives access to the stored sessions of a project without
+// setting up the rest of the app, e.g. LSP and MCP clients.
+type sessionStore struct {
+	conn     *sql.DB
+	sessions session.Service
+	messages message.Service
+}
+
+func openSessionStore(cmd *cobra.Command) (*sessionStore, error) {
+	conn, err := openProjectDB(cmd)
+	if err != nil {
+		return nil, err
+	}
+	q := db.New(conn)
+	return &sessionStore{
+		conn:     conn,
+		sessions: session.NewService(q),
+		messages: message.NewService(q),
+	}, nil
+}
+
+// openProjectDB connects to the database of the project. Unlike setupApp, it
+// doesn't create the data directory if there is none.
+func openProjectDB(cmd *cobra.Command) (*sql.DB, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(cwd, dataDir, debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if _, err := os.Stat(db.Path(cfg.Options.DataDirectory)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no database found in %s, looks like you are not in a zero project", cfg.Options.DataDirectory)
+		}
+		return nil, fmt.Errorf("failed to check database: %w", err)
+	}
+
+	conn, err := db.Connect(cmd.Context(), cfg.Options.DataDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *sessionStore) Close() error {
+	return s.conn.Close()
+}
+
+// sessionTranscript is a session with its messages and the transcripts of
+// the sessions it spawned.
+type sessionTranscript struct {
+	Session  session.Session
+	Messages []message.Message
+	Children []sessionTranscript
+}
+
+func (s *sessionStore) transcript(ctx context.Context, id string) (sessionTranscript, error) {
+	sess, err := s.sessions.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sessionTranscript{}, fmt.Errorf("session %s not found", id)
+		}
+		return sessionTranscript{}, fmt.Errorf("failed to get session %s: %w", id, err)
+	}
+	msgs, err := s.messages.List(ctx, sess.ID)
+	if err != nil {
+		return sessionTranscript{}, fmt.Errorf("failed to list messages of session %s: %w", id, err)
+	}
+	children, err := s.sessions.ListChildren(ctx, sess.ID)
+	if err != nil {
+		return sessionTranscript{}, fmt.Errorf("failed to list child sessions of %s: %w", id, err)
+	}
+
+	transcript := sessionTranscript{Session: sess, Messages: msgs}
+	for _, child := range children {
+		// Title generation isn't part of the conversation.
+		if strings.HasPrefix(child.ID, "title-") {
+			continue
+		}
+		childTranscript, err := s.transcript(ctx, child.ID)
+		if err != nil {
+			return sessionTranscript{}, err
+		}
+		transcript.Children = append(transcript.Children, childTranscript)
+	}
+	return transcript, nil
+}
+
+func writeTranscriptText(w io.Writer, t sessionTranscript, indent string) error {
+	p := func(format string, args ...any) {
+		text := fmt.Sprintf(format, args...)
+		for line := range strings.SplitSeq(text, "\n") {
+			fmt.Fprintf(w, "%s%s\n", indent, line)
+		}
+	}
+
+	p("Session:  %s", t.Session.Title)
+	p("ID:       %s", t.Session.ID)
+	p("Created:  %s", formatUnix(t.Session.CreatedAt))
+	p("Updated:  %s", formatUnix(t.Session.UpdatedAt))
+	p("Messages: %d", t.Session.MessageCount)
+	p("Tokens:   %d prompt, %d completion", t.Session.PromptTokens, t.Session.CompletionTokens)
+	p("Cost:     $%.4f", t.Session.Cost)
+
+	for _, msg := range t.Messages {
+		p("")
+		p("--- %s ---", messageHeading(msg))
+		for _, part := range msg.Parts {
+			switch part := part.(type) {
+			case message.ReasoningContent:
+				if part.Thinking != "" {
+					p("[thinking]\n%s", strings.TrimSpace(part.Thinking))
+				}
+			case message.TextContent:
+				if part.Text != "" {
+					p("%s", strings.TrimSpace(part.Text))
+				}
+			case message.ImageURLContent:
+				p("[image] %s", part.URL)
+			case message.BinaryContent:
+				p("[attachment] %s (%s)", part.Path, part.MIMEType)
+			case message.ToolCall:
+				p("[tool call %s] %s %s", part.ID, part.Name, part.Input)
+			case message.ToolResult:
+				status := "result"
+				if part.IsError {
+					status = "error"
+				}
+				p("[tool %s %s]\n%s", status, part.ToolCallID, strings.TrimRight(part.Content, "\n"))
+			case message.Finish:
+				if part.Reason != message.FinishReasonEndTurn && part.Reason != message.FinishReasonToolUse && part.Reason != "stop" {
+					p("[finished: %s] %s", part.Reason, part.Message)
+				}
+			}
+		}
+	}
+
+	for _, child := range t.Children {
+		p("")
+		p("=== Sub-agent session %s ===", child.Session.ID)
+		if err := writeTranscriptText(w, child, indent+"    "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTranscriptMarkdown(w io.Writer, t sessionTranscript, level int) error {
+	heading := strings.Repeat("#", min(level, 6))
+	sub := strings.Repeat("#", min(level+1, 6))
+
+	fmt.Fprintf(w, "%s %s\n\n", heading, t.Session.Title)
+	fmt.Fprintf(w, "- **ID:** `%s`\n", t.Session.ID)
+	fmt.Fprintf(w, "- **Created:** %s\n", formatUnix(t.Session.CreatedAt))
+	fmt.Fprintf(w, "- **Updated:** %s\n", formatUnix(t.Session.UpdatedAt))
+	fmt.Fprintf(w, "- **Tokens:** %d prompt, %d completion\n", t.Session.PromptTokens, t.Session.CompletionTokens)
+	fmt.Fprintf(w, "- **Cost:** $%.4f\n\n", t.Session.Cost)
+
+	for _, msg := range t.Messages {
+		fmt.Fprintf(w, "%s %s\n\n", sub, messageHeading(msg))
+		for _, part := range msg.Parts {
+			switch part := part.(type) {
+			case message.ReasoningContent:
+				if part.Thinking != "" {
+					fmt.Fprintf(w, "<details>\n<summary>Thinking</summary>\n\n%s\n\n</details>\n\n", strings.TrimSpace(part.Thinking))
+				}
+			case message.TextContent:
+				if part.Text != "" {
+					fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(part.Text))
+				}
+			case message.ImageURLContent:
+				fmt.Fprintf(w, "![image](%s)\n\n", part.URL)
+			case message.BinaryContent:
+				fmt.Fprintf(w, "_Attachment: `%s` (%s)_\n\n", part.Path, part.MIMEType)
+			case message.ToolCall:
+				fmt.Fprintf(w, "**Tool call** `%s` (`%s`)\n\n%s\n", part.Name, part.ID, codeBlock("json", part.Input))
+			case message.ToolResult:
+				label := "Tool result"
+				if part.IsError {
+					label = "Tool error"
+				}
+				fmt.Fprintf(w, "**%s** (`%s`)\n\n%s\n", label, part.ToolCallID, codeBlock("", part.Content))
+			case message.Finish:
+				if part.Reason != message.FinishReasonEndTurn && part.Reason != message.FinishReasonToolUse && part.Reason != "stop" {
+					fmt.Fprintf(w, "_Finished: %s %s_\n\n", part.Reason, part.Message)
+				}
+			}
+		}
+	}
+
+	for _, child := range t.Children {
+		fmt.Fprintf(w, "%s Sub-agent session\n\n", sub)
+		if err := writeTranscriptMarkdown(w, child, level+2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func messageHeading(msg message.Message) string {
+	var role string
+	switch msg.Role {
+	case message.User:
+		role = "User"
+	case message.Assistant:
+		role = "Assistant"
+		if msg.Model != "" {
+			role += " (" + msg.Model + ")"
+		}
+	case message.Tool:
+		role = "Tool"
+	case message.System:
+		role = "System"
+	default:
+		role = string(msg.Role)
+	}
+	return role + " - " + formatUnix(msg.CreatedAt)
+}
+
+// codeBlock wraps content in a fenced code block, using a fence longer than
+// any backtick run in the content.
+func codeBlock(lang, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(content, "\n") + "\n" + fence + "\n"
+}
+
+type sessionExport struct {
+	ID               string          `json:"id"`
+	ParentSessionID  string          `json:"parent_session_id,omitempty"`
+	Title            string          `json:"title"`
+	MessageCount     int64           `json:"message_count"`
+	PromptTokens     int64           `json:"prompt_tokens"`
+	CompletionTokens int64           `json:"completion_tokens"`
+	Cost             float64         `json:"cost"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	Messages         []messageExport `json:"messages"`
+	Children         []sessionExport `json:"children,omitempty"`
+}
+
+type messageExport struct {
+	ID           string               `json:"id"`
+	Role         message.MessageRole  `json:"role"`
+	Model        string               `json:"model,omitempty"`
+	Provider     string               `json:"provider,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	Text         string               `json:"text,omitempty"`
+	Reasoning    string               `json:"reasoning,omitempty"`
+	Attachments  []string             `json:"attachments,omitempty"`
+	ToolCalls    []message.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults  []message.ToolResult `json:"tool_results,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+}
+
+func (t sessionTranscript) export() sessionExport {
+	out := sessionExport{
+		ID:               t.Session.ID,
+		ParentSessionID:  t.Session.ParentSessionID,
+		Title:            t.Session.Title,
+		MessageCount:     t.Session.MessageCount,
+		PromptTokens:     t.Session.PromptTokens,
+		CompletionTokens: t.Session.CompletionTokens,
+		Cost:             t.Session.Cost,
+		CreatedAt:        time.Unix(t.Session.CreatedAt, 0).UTC(),
+		UpdatedAt:        time.Unix(t.Session.UpdatedAt, 0).UTC(),
+		Messages:         make([]messageExport, 0, len(t.Messages)),
+	}
+	for _, msg := range t.Messages {
+		m := messageExport{
+			ID:           msg.ID,
+			Role:         msg.Role,
+			Model:        msg.Model,
+			Provider:     msg.Provider,
+			CreatedAt:    time.Unix(msg.CreatedAt, 0).UTC(),
+			Text:         msg.Content().Text,
+			Reasoning:    msg.ReasoningContent().Thinking,
+			ToolCalls:    msg.ToolCalls(),
+			ToolResults:  msg.ToolResults(),
+			FinishReason: msg.FinishReason(),
+		}
+		for _, img := range msg.ImageURLContent() {
+			m.Attachments = append(m.Attachments, img.URL)
+		}
+		for _, bin := range msg.BinaryContent() {
+			m.Attachments = append(m.Attachments, bin.Path)
+		}
+		out.Messages = append(out.Messages, m)
+	}
+	for _, child := range t.Children {
+		out.Children = append(out.Children, child.export())
+	}
+	return out
+}
+
+// sessionsToPrune returns the sessions last updated before the cutoff.
+func sessionsToPrune(sessions []session.Session, cutoff time.Time) []session.Session {
+	var pruned []session.Session
+	for _, sess := range sessions {
+		if sess.UpdatedAt < cutoff.Unix() {
+			pruned = append(pruned, sess)
+		}
+	}
+	return pruned
+}
+
+// parseAge parses a duration that can also be expressed in days (d) or weeks
+// (w), e.g. 30d.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q, use a duration like 72h, 30d or 2w", s)
+	}
+	return d, nil
+}
+
+func formatUnix(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.Unix(ts, 0).Format("2006-01-02 15:04")
+}
+
+func truncateTitle(title string, limit int) string {
+	title = strings.ReplaceAll(title, "\n", " ")
+	runes := []rune(title)
+	if len(runes) <= limit {
+		return title
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/session"
+)
+
+func TestSessionsToPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	sessions := []session.Session{
+		{ID: "old", UpdatedAt: now.Add(-72 * time.Hour).Unix()},
+		{ID: "recent", UpdatedAt: now.Add(-time.Hour).Unix()},
+		{ID: "at-cutoff", UpdatedAt: now.Add(-48 * time.Hour).Unix()},
+		{ID: "older", UpdatedAt: now.Add(-30 * 24 * time.Hour).Unix()},
+	}
+
+	var ids []string
+	for _, sess := range sessionsToPrune(sessions, now.Add(-48*time.Hour)) {
+		ids = append(ids, sess.ID)
+	}
+	require.Equal(t, []string{"old", "older"}, ids)
+	require.Empty(t, sessionsToPrune(sessions, now.Add(-365*24*time.Hour)))
+}
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]time.Duration{
+		"72h": 72 * time.Hour,
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+	} {
+		got, err := parseAge(input)
+		require.NoError(t, err)
+		require.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "0d", "-1w", "soon", "-5h"} {
+		_, err := parseAge(input)
+		require.Error(t, err, input)
+	}
+}
+
+func testTranscript() sessionTranscript {
+	created := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC).Unix()
+	return sessionTranscript{
+		Session: session.Session{ID: "session", Title: "Fix the build", CreatedAt: created, UpdatedAt: created, Cost: 0.5},
+		Messages: []message.Message{
+			{ID: "user", Role: message.User, CreatedAt: created, Parts: []message.ContentPart{
+				message.TextContent{Text: "Why does the build fail?"},
+			}},
+			{ID: "assistant", Role: message.Assistant, Model: "model", CreatedAt: created, Parts: []message.ContentPart{
+				message.ReasoningContent{Thinking: "Let me look."},
+				message.ToolCall{ID: "call", Name: "view", Input: `{"file_path": "main.go"}`},
+				message.Finish{Reason: message.FinishReasonToolUse},
+			}},
+			{ID: "tool", Role: message.Tool, CreatedAt: created, Parts: []message.ContentPart{
+				message.ToolResult{ToolCallID: "call", Content: "```go\npackage main\n```"},
+			}},
+		},
+		Children: []sessionTranscript{{
+			Session: session.Session{ID: "child", ParentSessionID: "session", Title: "Search", CreatedAt: created, UpdatedAt: created},
+		}},
+	}
+}
+
+func TestSessionTranscriptExport(t *testing.T) {
+	t.Parallel()
+
+	export := testTranscript().export()
+	require.Equal(t, "session", export.ID)
+	require.Equal(t, "Fix the build", export.Title)
+	require.InDelta(t, 0.5, export.Cost, 1e-9)
+	require.Len(t, export.Messages, 3)
+
+	user, assistant, tool := export.Messages[0], export.Messages[1], export.Messages[2]
+	require.Equal(t, "Why does the build fail?", user.Text)
+	require.Equal(t, "Let me look.", assistant.Reasoning)
+	require.Equal(t, []message.ToolCall{{ID: "call", Name: "view", Input: `{"file_path": "main.go"}`}}, assistant.ToolCalls)
+	require.Equal(t, message.FinishReasonToolUse, assistant.FinishReason)
+	require.Len(t, tool.ToolResults, 1)
+
+	require.Len(t, export.Children, 1)
+	require.Equal(t, "child", export.Children[0].ID)
+	require.Equal(t, "session", export.Children[0].ParentSessionID)
+	require.NotNil(t, export.Children[0].Messages, "messages are exported as an empty list")
+}
+
+func TestWriteTranscriptMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	require.NoError(t, writeTranscriptMarkdown(&sb, testTranscript(), 1))
+	out := sb.String()
+
+	require.True(t, strings.HasPrefix(out, "# Fix the build\n"))
+	require.Contains(t, out, "## User - ")
+	require.Contains(t, out, "## Assistant (model) - ")
+	require.Contains(t, out, "<summary>Thinking</summary>\n\nLet me look.")
+	require.Contains(t, out, "**Tool call** `view` (`call`)\n\n```json\n{\"file_path\": \"main.go\"}\n```\n")
+	require.Contains(t, out, "````\n```go\npackage main\n```\n````\n", "the fence is longer than the backticks in the content")
+	require.Contains(t, out, "## Sub-agent session\n\n### Search\n")
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vikvang/zero/internal/db"
+	"github.com/vikvang/zero/internal/usage"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report token usage and cost",
+	Long: `Report the token usage and cost of the sessions of the current project, grouped
+by day, model, provider, session or turn. The usage of sub-agents is included in
+the session that started them.`,
+	Example: `
+# Cost per day
+zero usage
+
+# Cost per model over the last week
+zero usage --by model --since 7d
+
+# Cost of each turn of a session
+zero usage --by turn --session 3f2a9c1e-...
+
+# Export the cost per session since the start of the month as CSV
+zero usage --by session --since 2025-08-01 --csv > usage.csv
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, _ := cmd.Flags().GetString("by")
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		sessionID, _ := cmd.Flags().GetString("session")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		asCSV, _ := cmd.Flags().GetBool("csv")
+
+		groupBy := usage.GroupBy(by)
+		if !groupBy.IsValid() {
+			return fmt.Errorf("invalid grouping %q, must be one of: %s", by, strings.Join(groupBys(), ", "))
+		}
+		var since time.Time
+		if sinceFlag != "" {
+			var err error
+			if since, err = parseSince(sinceFlag); err != nil {
+				return err
+			}
+		}
+
+		conn, err := openProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		records, err := usage.List(cmd.Context(), db.New(conn), since)
+		if err != nil {
+			return err
+		}
+		if sessionID != "" {
+			records = slices.DeleteFunc(records, func(r usage.Record) bool {
+				return r.RootSessionID != sessionID && r.SessionID != sessionID
+			})
+		}
+		report := usage.NewReport(records, groupBy, since)
+
+		switch {
+		case asJSON:
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		case asCSV:
+			return writeUsageCSV(cmd.OutOrStdout(), report)
+		}
+		if len(report.Rows) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No usage found.")
+			return nil
+		}
+		return writeUsageTable(cmd.OutOrStdout(), report)
+	},
+}
+
+func init() {
+	usageCmd.Flags().String("by", string(usage.GroupByDay), "Group the usage by: "+strings.Join(groupBys(), ", "))
+	usageCmd.Flags().String("since", "", "Only include usage since a date, e.g. 2025-08-01, or for a duration, e.g. 24h, 7d or 2w")
+	usageCmd.Flags().StringP("session", "s", "", "Only include the usage of the session with the given ID and its sub-agents")
+	usageCmd.Flags().Bool("json", false, "Print the report as JSON")
+	usageCmd.Flags().Bool("csv", false, "Print the report as CSV")
+	usageCmd.MarkFlagsMutuallyExclusive("json", "csv")
+	rootCmd.AddCommand(usageCmd)
+}
+
+func groupBys() []string {
+	groupBys := make([]string, 0, len(usage.GroupBys))
+	for _, g := range usage.GroupBys {
+		groupBys = append(groupBys, string(g))
+	}
+	return groupBys
+}
+
+// parseSince parses a date, or an age relative to now.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s), time.Local); err == nil {
+		return t, nil
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q, use a date like 2025-08-01 or a duration like 24h, 7d or 2w", s)
+	}
+	return time.Now().Add(-age), nil
+}
+
+// usageHeaders returns the headers of the key and label columns of the
+// report, the label header is empty when rows have no label.
+func usageHeaders(groupBy usage.GroupBy) (key, label string) {
+	switch groupBy {
+	case usage.GroupByModel:
+		return "model", "provider"
+	case usage.GroupByProvider:
+		return "provider", ""
+	case usage.GroupBySession:
+		return "session", "title"
+	case usage.GroupByTurn:
+		return "message", "turn"
+	default:
+		return "day", ""
+	}
+}
+
+func writeUsageTable(w io.Writer, report usage.Report) error {
+	keyHeader, labelHeader := usageHeaders(report.GroupBy)
+	headers := []string{strings.ToUpper(keyHeader)}
+	if labelHeader != "" {
+		headers = append(headers, strings.ToUpper(labelHeader))
+	}
+	headers = append(headers, "RESPONSES", "PROMPT TOKENS", "COMPLETION TOKENS", "COST")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	writeRow := func(row usage.Row) {
+		cols := []string{valueOrDash(row.Key)}
+		if labelHeader != "" {
+			cols = append(cols, valueOrDash(truncateTitle(row.Label, 50)))
+		}
+		cols = append(
+			cols,
+			strconv.Itoa(row.Responses),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			fmt.Sprintf("$%.4f", row.Cost),
+		)
+		fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	}
+	for _, row := range report.Rows {
+		writeRow(row)
+	}
+	total := report.Total
+	total.Key = "TOTAL"
+	writeRow(total)
+	return tw.Flush()
+}
+
+func writeUsageCSV(w io.Writer, report usage.Report) error {
+	keyHeader, labelHeader := usageHeaders(report.GroupBy)
+	headers := []string{keyHeader}
+	if labelHeader != "" {
+		headers = append(headers, labelHeader)
+	}
+	headers = append(headers, "responses", "prompt_tokens", "completion_tokens", "cost")
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		record := []string{row.Key}
+		if labelHeader != "" {
+			record = append(record, row.Label)
+		}
+		record = append(
+			record,
+			strconv.Itoa(row.Responses),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatFloat(row.Cost, 'f', -1, 64),
+		)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
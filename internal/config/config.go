@@ -14,12 +14,14 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/vikvang/zero/internal/csync"
 	"github.com/vikvang/zero/internal/env"
+	"github.com/vikvang/zero/internal/ollama"
 	"github.com/tidwall/sjson"
 )
 
 const (
 	appName              = "crush"
 	defaultDataDirectory = ".crush"
+	defaultBudgetWarnAt  = 0.8
 )
 
 var defaultContextPaths = []string{
@@ -64,8 +66,16 @@ type SelectedModel struct {
 
 	// Used by anthropic models that can reason to indicate if the model should think.
 	Think bool `json:"think,omitempty" jsonschema:"description=Enable thinking mode for Anthropic models that support reasoning"`
+
+	// Models to fall back to, in order, when the provider of the model is
+	// overloaded or erroring. Their own fallbacks are ignored.
+	Fallbacks []SelectedModel `json:"fallbacks,omitempty" jsonschema:"description=Models to fall back to in order when the provider is overloaded or erroring"`
 }
 
+// TypeOllama is the provider type of Ollama and compatible local model
+// servers, whose installed models are discovered when the config is loaded.
+const TypeOllama catwalk.Type = "ollama"
+
 type ProviderConfig struct {
 	// The provider's id.
 	ID string `json:"id,omitempty" jsonschema:"description=Unique identifier for the provider,example=openai"`
@@ -74,7 +84,7 @@ type ProviderConfig struct {
 	// The provider's API endpoint.
 	BaseURL string `json:"base_url,omitempty" jsonschema:"description=Base URL for the provider's API,format=uri,example=https://api.openai.com/v1"`
 	// The provider type, e.g. "openai", "anthropic", etc. if empty it defaults to openai.
-	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,default=openai"`
+	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,enum=ollama,default=openai"`
 	// The provider's API key.
 	APIKey string `json:"api_key,omitempty" jsonschema:"description=API key for authentication with the provider,example=$OPENAI_API_KEY"`
 	// Marks the provider as disabled.
@@ -103,6 +113,14 @@ const (
 	MCPHttp  MCPType = "http"
 )
 
+type MCPAuth string
+
+const (
+	// MCPAuthOAuth authorizes the requests to HTTP and SSE servers with the
+	// OAuth 2.1 authorization flow.
+	MCPAuthOAuth MCPAuth = "oauth"
+)
+
 type MCPConfig struct {
 	Command  string            `json:"command,omitempty" jsonschema:"description=Command to execute for stdio MCP servers,example=npx"`
 	Env      map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set for the MCP server"`
@@ -114,6 +132,17 @@ type MCPConfig struct {
 
 	// TODO: maybe make it possible to get the value from the env
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	Auth  MCPAuth         `json:"auth,omitempty" jsonschema:"description=Authorization mode for HTTP/SSE MCP servers,enum=oauth"`
+	OAuth *MCPOAuthConfig `json:"oauth,omitempty" jsonschema:"description=OAuth settings for the oauth authorization mode"`
+}
+
+type MCPOAuthConfig struct {
+	ClientID     string   `json:"client_id,omitempty" jsonschema:"description=Pre-registered OAuth client ID; the client is registered dynamically when empty"`
+	ClientSecret string   `json:"client_secret,omitempty" jsonschema:"description=OAuth client secret of the pre-registered client"`
+	Scopes       []string `json:"scopes,omitempty" jsonschema:"description=OAuth scopes to request"`
+	MetadataURL  string   `json:"metadata_url,omitempty" jsonschema:"description=URL of the authorization server metadata; discovered from the MCP server when empty,format=uri"`
+	RedirectPort int      `json:"redirect_port,omitempty" jsonschema:"description=Port of the loopback redirect URI; random when empty,example=8976"`
 }
 
 type LSPConfig struct {
@@ -132,8 +161,41 @@ type TUIOptions struct {
 }
 
 type Permissions struct {
-	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
-	SkipRequests bool     `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
+	AllowedTools []string         `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
+	SkipRequests bool             `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
+	Rules        []PermissionRule `json:"rules,omitempty" jsonschema:"description=Rules that allow or deny matching tool calls or always ask for them. The first matching rule wins but deny rules always win"`
+	Sandbox      *Sandbox         `json:"sandbox,omitempty" jsonschema:"description=Limit the files tools and shell commands can write"`
+}
+
+// Sandbox limits the files tools and shell commands can write to the
+// writable roots.
+type Sandbox struct {
+	Enabled       bool     `json:"enabled,omitempty" jsonschema:"description=Enable the sandbox,default=false"`
+	WritableRoots []string `json:"writable_roots,omitempty" jsonschema:"description=Directories that can be written relative to the working directory unless absolute or starting with ~; defaults to the working directory and the temporary directory,example=.,example=/tmp"`
+	Outside       string   `json:"outside,omitempty" jsonschema:"description=What to do with writes outside the writable roots,enum=deny,enum=ask,default=deny"`
+	DenyNetwork   bool     `json:"deny_network,omitempty" jsonschema:"description=Deny network access to shell commands by pointing the proxy environment variables to a proxy that refuses all connections,default=false"`
+}
+
+// PermissionRule matches tool calls by tool, action, path, command or host.
+// Empty fields match anything. Patterns are globs, or regular expressions
+// when prefixed with "re:".
+type PermissionRule struct {
+	Decision string `json:"decision" jsonschema:"required,description=What to do with matching tool calls,enum=allow,enum=deny,enum=ask"`
+	Tool     string `json:"tool,omitempty" jsonschema:"description=Tool name pattern,example=bash,example=mcp_*"`
+	Action   string `json:"action,omitempty" jsonschema:"description=Tool action pattern,example=write,example=execute"`
+	Path     string `json:"path,omitempty" jsonschema:"description=File path pattern relative to the working directory unless absolute,example=vendor/**,example=**/*.go"`
+	Command  string `json:"command,omitempty" jsonschema:"description=Bash command pattern where * matches anything,example=go test *,example=rm -rf *"`
+	Host     string `json:"host,omitempty" jsonschema:"description=Host pattern for fetch and download URLs,example=*.github.com"`
+}
+
+// Budget limits what the agent spends. Costs are in US dollars and zero means
+// no limit.
+type Budget struct {
+	MaxSessionCost float64 `json:"max_session_cost,omitempty" jsonschema:"description=Maximum cost of a session in US dollars,minimum=0,example=5"`
+	MaxDailyCost   float64 `json:"max_daily_cost,omitempty" jsonschema:"description=Maximum cost of all the sessions of the project in a day in US dollars,minimum=0,example=20"`
+	WarnAt         float64 `json:"warn_at,omitempty" jsonschema:"description=Fraction of a budget at which to warn that it is nearly used,default=0.8,minimum=0,maximum=1"`
+	MaxRunCost     float64 `json:"-"` // Maximum cost of a non-interactive run, set with --max-cost
+	MaxRunTurns    int     `json:"-"` // Maximum model requests of a non-interactive run, set with --max-turns
 }
 
 type Options struct {
@@ -143,6 +205,7 @@ type Options struct {
 	DebugLSP             bool        `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
 	DisableAutoSummarize bool        `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
 	DataDirectory        string      `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
+	Budget               *Budget     `json:"budget,omitempty" jsonschema:"description=Cost budgets that stop the agent when exceeded"`
 }
 
 type MCPs map[string]MCPConfig
@@ -208,31 +271,55 @@ func (m MCPConfig) ResolvedHeaders() map[string]string {
 	return m.Headers
 }
 
+// ResolvedOAuth returns the OAuth settings with the variables of the client
+// secret resolved.
+func (m MCPConfig) ResolvedOAuth() MCPOAuthConfig {
+	if m.OAuth == nil {
+		return MCPOAuthConfig{}
+	}
+	oauth := *m.OAuth
+	resolver := NewShellVariableResolver(env.New())
+	secret, err := resolver.ResolveValue(oauth.ClientSecret)
+	if err != nil {
+		slog.Error("error resolving oauth client secret", "error", err)
+		return oauth
+	}
+	oauth.ClientSecret = secret
+	return oauth
+}
+
 type Agent struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	ID          string `json:"id,omitempty" jsonschema:"-"`
+	Name        string `json:"name,omitempty" jsonschema:"description=Display name of the agent,example=Reviewer"`
+	Description string `json:"description,omitempty" jsonschema:"description=Description of what the agent does"`
 	// This is the id of the system prompt used by the agent
-	Disabled bool `json:"disabled,omitempty"`
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Whether this agent is disabled,default=false"`
+	// Sub-agents are only delegated to by the primary agent, the user can't
+	// switch to them.
+	SubAgent bool `json:"sub_agent,omitempty" jsonschema:"description=Whether the agent can only be delegated to by the primary agent,default=false"`
 
-	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	Model SelectedModelType `json:"model,omitempty" jsonschema:"description=The model type to use for this agent,enum=large,enum=small,default=large"`
+
+	// Path of a file with the system prompt of the agent, relative to the
+	// working directory. If empty, the coder prompt is used.
+	Prompt string `json:"prompt,omitempty" jsonschema:"description=Path of a file with the system prompt of the agent; the coder prompt is used when empty,example=.zero/agents/reviewer.md"`
 
 	// The available tools for the agent
 	//  if this is nil, all tools are available
-	AllowedTools []string `json:"allowed_tools,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=Tools available to the agent; all tools are available when omitted,example=view,example=grep"`
 
 	// this tells us which MCPs are available for this agent
-	//  if this is empty all mcps are available
+	//  if this is nil all mcps are available
 	//  the string array is the list of tools from the AllowedMCP the agent has available
 	//  if the string array is nil, all tools from the AllowedMCP are available
-	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty"`
+	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty" jsonschema:"description=MCP servers available to the agent, each with the list of its tools or null for all of them; all servers are available when omitted"`
 
 	// The list of LSPs that this agent can use
 	//  if this is nil, all LSPs are available
-	AllowedLSP []string `json:"allowed_lsp,omitempty"`
+	AllowedLSP []string `json:"allowed_lsp,omitempty" jsonschema:"description=LSP servers available to the agent; all servers are available when omitted"`
 
 	// Overrides the context paths for this agent
-	ContextPaths []string `json:"context_paths,omitempty"`
+	ContextPaths []string `json:"context_paths,omitempty" jsonschema:"description=Context files of the agent; the context paths of the options are used when omitted"`
 }
 
 // Config holds the configuration for crush.
@@ -253,10 +340,14 @@ type Config struct {
 
 	Permissions *Permissions `json:"permissions,omitempty" jsonschema:"description=Permission settings for tool usage"`
 
+	Agents map[string]Agent `json:"agents,omitempty" jsonschema:"description=Agents to switch between in addition to the built-in coder and task agents"`
+
+	Shell *ShellConfig `json:"shell,omitempty" jsonschema:"description=Environment and setup of the persistent shell used by the bash tool"`
+
 	// Internal
 	workingDir string `json:"-"`
-	// TODO: most likely remove this concept when I come back to it
-	Agents map[string]Agent `json:"-"`
+	// primaryAgent is the ID of the agent the user talks to.
+	primaryAgent string
 	// TODO: find a better way to do this this should probably not be part of the config
 	resolver       VariableResolver
 	dataConfigDir  string             `json:"-"`
@@ -344,6 +435,10 @@ func (c *Config) Resolve(key string) (string, error) {
 }
 
 func (c *Config) UpdatePreferredModel(modelType SelectedModelType, model SelectedModel) error {
+	// Switching models keeps the fallbacks.
+	if model.Fallbacks == nil {
+		model.Fallbacks = c.Models[modelType].Fallbacks
+	}
 	c.Models[modelType] = model
 	if err := c.SetConfigField(fmt.Sprintf("models.%s", modelType), model); err != nil {
 		return fmt.Errorf("failed to update preferred model: %w", err)
@@ -431,21 +526,98 @@ func (c *Config) SetupAgents() {
 			Description:  "An agent that helps with searching for context and finding implementation details.",
 			Model:        SelectedModelTypeLarge,
 			ContextPaths: c.Options.ContextPaths,
+			SubAgent:     true,
 			AllowedTools: []string{
 				"glob",
 				"grep",
 				"ls",
+				"lsp_call_hierarchy",
+				"lsp_definition",
+				"lsp_hover",
+				"lsp_references",
+				"lsp_symbols",
 				"sourcegraph",
 				"view",
 			},
-			// NO MCPs or LSPs by default
+			// NO MCPs by default
 			AllowedMCP: map[string][]string{},
-			AllowedLSP: []string{},
 		},
 	}
+
+	// User-defined agents are added to the built-in ones, or replace them.
+	for id, agent := range c.Agents {
+		agent.ID = id
+		if agent.Name == "" {
+			agent.Name = id
+		}
+		if agent.Model == "" {
+			agent.Model = SelectedModelTypeLarge
+		}
+		if agent.ContextPaths == nil {
+			agent.ContextPaths = c.Options.ContextPaths
+		}
+		agents[id] = agent
+	}
 	c.Agents = agents
 }
 
+// PrimaryAgent returns the agent the user talks to, the coder agent unless
+// another one was selected with [Config.SetPrimaryAgent].
+func (c *Config) PrimaryAgent() Agent {
+	if agent, ok := c.Agents[c.primaryAgent]; ok {
+		return agent
+	}
+	return c.Agents["coder"]
+}
+
+// SetPrimaryAgent selects the agent the user talks to.
+func (c *Config) SetPrimaryAgent(id string) error {
+	agent, ok := c.Agents[id]
+	if !ok {
+		return fmt.Errorf("agent %q not found in config", id)
+	}
+	if agent.Disabled {
+		return fmt.Errorf("agent %q is disabled", id)
+	}
+	if agent.SubAgent {
+		return fmt.Errorf("agent %q is a sub-agent", id)
+	}
+	c.primaryAgent = id
+	return nil
+}
+
+// PrimaryAgents returns the agents that can be selected as the primary
+// agent, sorted by name.
+func (c *Config) PrimaryAgents() []Agent {
+	var agents []Agent
+	for _, agent := range c.Agents {
+		if agent.Disabled || agent.SubAgent {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	slices.SortFunc(agents, func(a, b Agent) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return agents
+}
+
+// SubAgents returns the agents the given agent can delegate to, sorted by
+// ID: every enabled agent but itself.
+func (c *Config) SubAgents(agentID string) []Agent {
+	var agents []Agent
+	for _, agent := range c.Agents {
+		if agent.Disabled || agent.ID == agentID {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	slices.SortFunc(agents, func(a, b Agent) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return agents
+}
+
 func (c *Config) Resolver() VariableResolver {
 	return c.resolver
 }
@@ -476,6 +648,9 @@ func (c *ProviderConfig) TestConnection(resolver VariableResolver) error {
 			baseURL = "https://generativelanguage.googleapis.com"
 		}
 		testURL = baseURL + "/v1beta/models?key=" + url.QueryEscape(apiKey)
+	case TypeOllama:
+		baseURL, _ := resolver.ResolveValue(c.BaseURL)
+		testURL = ollama.NormalizeBaseURL(baseURL) + "/api/version"
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -265,7 +265,9 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			c.Providers.Del(id)
 			continue
 		}
-		if providerConfig.APIKey == "" {
+		if providerConfig.Type == TypeOllama {
+			configureOllama(&providerConfig, env, resolver)
+		} else if providerConfig.APIKey == "" {
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
 		if providerConfig.BaseURL == "" {
@@ -278,14 +280,14 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			c.Providers.Del(id)
 			continue
 		}
-		if providerConfig.Type != catwalk.TypeOpenAI && providerConfig.Type != catwalk.TypeAnthropic {
+		if providerConfig.Type != catwalk.TypeOpenAI && providerConfig.Type != catwalk.TypeAnthropic && providerConfig.Type != TypeOllama {
 			slog.Warn("Skipping custom provider because the provider type is not supported", "provider", id, "type", providerConfig.Type)
 			c.Providers.Del(id)
 			continue
 		}
 
 		apiKey, err := resolver.ResolveValue(providerConfig.APIKey)
-		if apiKey == "" || err != nil {
+		if (apiKey == "" || err != nil) && providerConfig.Type != TypeOllama {
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
 		baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
@@ -311,6 +313,12 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 	if c.Options.ContextPaths == nil {
 		c.Options.ContextPaths = []string{}
 	}
+	if c.Options.Budget == nil {
+		c.Options.Budget = &Budget{}
+	}
+	if c.Options.Budget.WarnAt == 0 {
+		c.Options.Budget.WarnAt = defaultBudgetWarnAt
+	}
 	if dataDir != "" {
 		c.Options.DataDirectory = dataDir
 	} else if c.Options.DataDirectory == "" {
@@ -477,6 +485,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 			}
 			large.Think = largeModelSelected.Think
 		}
+		large.Fallbacks = c.configureFallbackModels(SelectedModelTypeLarge, largeModelSelected.Fallbacks)
 	}
 	smallModelSelected, smallModelConfigured := c.Models[SelectedModelTypeSmall]
 	if smallModelConfigured {
@@ -504,12 +513,32 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 			small.ReasoningEffort = smallModelSelected.ReasoningEffort
 			small.Think = smallModelSelected.Think
 		}
+		small.Fallbacks = c.configureFallbackModels(SelectedModelTypeSmall, smallModelSelected.Fallbacks)
 	}
 	c.Models[SelectedModelTypeLarge] = large
 	c.Models[SelectedModelTypeSmall] = small
 	return nil
 }
 
+// configureFallbackModels returns the fallback models whose provider and
+// model are configured, defaulting their max tokens like the selected models.
+func (c *Config) configureFallbackModels(modelType SelectedModelType, fallbacks []SelectedModel) []SelectedModel {
+	var configured []SelectedModel
+	for _, fallback := range fallbacks {
+		model := c.GetModel(fallback.Provider, fallback.Model)
+		if model == nil {
+			slog.Warn("Skipping fallback model not found in the providers", "type", modelType, "provider", fallback.Provider, "model", fallback.Model)
+			continue
+		}
+		if fallback.MaxTokens == 0 {
+			fallback.MaxTokens = model.DefaultMaxTokens
+		}
+		fallback.Fallbacks = nil
+		configured = append(configured, fallback)
+	}
+	return configured
+}
+
 func loadFromConfigPaths(configPaths []string) (*Config, error) {
 	var configs []io.Reader
 
@@ -609,3 +638,9 @@ func GlobalConfigData() string {
 
 	return filepath.Join(home.Dir(), ".local", "share", appName, fmt.Sprintf("%s.json", appName))
 }
+
+// GlobalDataDir returns the main data directory for the application, which
+// holds the data shared by all projects.
+func GlobalDataDir() string {
+	return filepath.Dir(GlobalConfigData())
+}
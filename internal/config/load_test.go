@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -668,6 +671,69 @@ func TestConfig_configureProvidersCustomProviderValidation(t *testing.T) {
 	})
 }
 
+func TestConfig_configureProvidersOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models": [{"name": "qwen3:30b"}, {"name": "llava:7b"}]}`)
+		case "/api/show":
+			fmt.Fprint(w, `{"model_info": {"general.architecture": "qwen3", "qwen3.context_length": 40960}, "capabilities": ["completion", "tools"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("installed models are discovered", func(t *testing.T) {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"local": {
+					Type:    TypeOllama,
+					BaseURL: server.URL,
+					Models: []catwalk.Model{{
+						ID:               "qwen3:30b",
+						Name:             "Qwen 3",
+						DefaultMaxTokens: 4000,
+					}},
+				},
+			}),
+		}
+		cfg.setDefaults("/tmp", "")
+
+		env := env.NewFromMap(map[string]string{})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+		require.NoError(t, err)
+
+		local, exists := cfg.Providers.Get("local")
+		require.True(t, exists)
+		require.Equal(t, []catwalk.Model{
+			{ID: "qwen3:30b", Name: "Qwen 3", ContextWindow: 40960, DefaultMaxTokens: 4000},
+			{ID: "llava:7b", Name: "llava:7b", ContextWindow: 40960, DefaultMaxTokens: 8192},
+		}, local.Models)
+	})
+
+	t.Run("unreachable server without configured models is removed", func(t *testing.T) {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"local": {
+					Type:    TypeOllama,
+					BaseURL: server.URL + "/missing",
+				},
+			}),
+		}
+		cfg.setDefaults("/tmp", "")
+
+		env := env.NewFromMap(map[string]string{})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+		require.NoError(t, err)
+
+		_, exists := cfg.Providers.Get("local")
+		require.False(t, exists)
+	})
+}
+
 func TestConfig_configureProvidersEnhancedCredentialValidation(t *testing.T) {
 	t.Run("VertexAI provider removed when credentials missing with existing config", func(t *testing.T) {
 		knownProviders := []catwalk.Provider{
@@ -1183,4 +1249,146 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, "openai", large.Provider)
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
+
+	t.Run("should keep the configured fallbacks", func(t *testing.T) {
+		knownProviders := []catwalk.Provider{
+			{
+				ID:                  "openai",
+				APIKey:              "abc",
+				DefaultLargeModelID: "large-model",
+				DefaultSmallModelID: "small-model",
+				Models: []catwalk.Model{
+					{
+						ID:               "large-model",
+						DefaultMaxTokens: 1000,
+					},
+					{
+						ID:               "small-model",
+						DefaultMaxTokens: 500,
+					},
+				},
+			},
+			{
+				ID:                  "anthropic",
+				APIKey:              "abc",
+				DefaultLargeModelID: "a-large-model",
+				DefaultSmallModelID: "a-small-model",
+				Models: []catwalk.Model{
+					{
+						ID:               "a-large-model",
+						DefaultMaxTokens: 2000,
+					},
+				},
+			},
+		}
+
+		cfg := &Config{
+			Models: map[SelectedModelType]SelectedModel{
+				"large": {
+					Model:    "large-model",
+					Provider: "openai",
+					Fallbacks: []SelectedModel{
+						{Model: "a-large-model", Provider: "anthropic", Think: true},
+						{Model: "missing-model", Provider: "anthropic"},
+						{Model: "large-model", Provider: "bedrock"},
+					},
+				},
+			},
+		}
+		cfg.setDefaults("/tmp", "")
+		env := env.NewFromMap(map[string]string{})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, knownProviders)
+		require.NoError(t, err)
+
+		err = cfg.configureSelectedModels(knownProviders)
+		require.NoError(t, err)
+		large := cfg.Models[SelectedModelTypeLarge]
+		require.Equal(t, []SelectedModel{
+			{Model: "a-large-model", Provider: "anthropic", MaxTokens: 2000, Think: true},
+		}, large.Fallbacks)
+	})
+}
+
+func TestConfig_SetupAgents(t *testing.T) {
+	data := strings.NewReader(`{
+		"agents": {
+			"reviewer": {
+				"name": "Reviewer",
+				"prompt": "reviewer.md",
+				"allowed_tools": ["view", "grep"],
+				"allowed_mcp": {"linear": null}
+			},
+			"docs": {
+				"model": "small",
+				"context_paths": ["docs/STYLE.md"]
+			},
+			"old": {"disabled": true}
+		}
+	}`)
+	cfg, err := loadFromReaders([]io.Reader{data})
+	require.NoError(t, err)
+	cfg.setDefaults("/tmp", "")
+	cfg.SetupAgents()
+
+	require.Contains(t, cfg.Agents, "coder")
+	require.Contains(t, cfg.Agents, "task")
+
+	reviewer := cfg.Agents["reviewer"]
+	require.Equal(t, "reviewer", reviewer.ID)
+	require.Equal(t, "Reviewer", reviewer.Name)
+	require.Equal(t, SelectedModelTypeLarge, reviewer.Model)
+	require.Equal(t, "reviewer.md", reviewer.Prompt)
+	require.Equal(t, []string{"view", "grep"}, reviewer.AllowedTools)
+	require.Equal(t, map[string][]string{"linear": nil}, reviewer.AllowedMCP)
+	require.Equal(t, cfg.Options.ContextPaths, reviewer.ContextPaths)
+
+	docs := cfg.Agents["docs"]
+	require.Equal(t, "docs", docs.Name)
+	require.Equal(t, SelectedModelTypeSmall, docs.Model)
+	require.Equal(t, []string{"docs/STYLE.md"}, docs.ContextPaths)
+
+	// Setting up the agents again keeps the user-defined ones.
+	cfg.SetupAgents()
+	require.Equal(t, reviewer, cfg.Agents["reviewer"])
+
+	var names []string
+	for _, agent := range cfg.PrimaryAgents() {
+		names = append(names, agent.Name)
+	}
+	require.Equal(t, []string{"Coder", "Reviewer", "docs"}, names)
+
+	require.Equal(t, "coder", cfg.PrimaryAgent().ID)
+	require.NoError(t, cfg.SetPrimaryAgent("reviewer"))
+	require.Equal(t, "reviewer", cfg.PrimaryAgent().ID)
+	require.Error(t, cfg.SetPrimaryAgent("old"))
+	require.Error(t, cfg.SetPrimaryAgent("missing"))
+	require.Equal(t, "reviewer", cfg.PrimaryAgent().ID)
+}
+
+func TestConfig_SubAgents(t *testing.T) {
+	data := strings.NewReader(`{
+		"agents": {
+			"test-runner": {"sub_agent": true, "allowed_tools": ["bash", "view"]},
+			"researcher": {"sub_agent": true, "allowed_tools": ["fetch"]},
+			"old": {"sub_agent": true, "disabled": true}
+		}
+	}`)
+	cfg, err := loadFromReaders([]io.Reader{data})
+	require.NoError(t, err)
+	cfg.setDefaults("/tmp", "")
+	cfg.SetupAgents()
+
+	var ids []string
+	for _, agent := range cfg.SubAgents("coder") {
+		ids = append(ids, agent.ID)
+	}
+	require.Equal(t, []string{"researcher", "task", "test-runner"}, ids)
+
+	var names []string
+	for _, agent := range cfg.PrimaryAgents() {
+		names = append(names, agent.Name)
+	}
+	require.Equal(t, []string{"Coder"}, names)
+	require.Error(t, cfg.SetPrimaryAgent("test-runner"))
 }
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/vikvang/zero/internal/env"
+	"github.com/vikvang/zero/internal/ollama"
+)
+
+// ollamaDiscoveryTimeout bounds the time spent listing the models of an
+// Ollama server on startup.
+const ollamaDiscoveryTimeout = 10 * time.Second
+
+// configureOllama defaults the base URL of an Ollama provider to OLLAMA_HOST
+// or the local server, and adds the models installed on the server to the
+// configured ones. Configured model settings take precedence over the
+// discovered ones.
+func configureOllama(providerConfig *ProviderConfig, env env.Env, resolver VariableResolver) {
+	if providerConfig.BaseURL == "" {
+		providerConfig.BaseURL = ollama.NormalizeBaseURL(env.Get("OLLAMA_HOST"))
+	}
+	baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
+	if err != nil {
+		slog.Warn("Failed to resolve Ollama base URL", "provider", providerConfig.ID, "error", err)
+		return
+	}
+	headers := make(map[string]string, len(providerConfig.ExtraHeaders))
+	for key, value := range providerConfig.ExtraHeaders {
+		if resolved, err := resolver.ResolveValue(value); err == nil {
+			headers[key] = resolved
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaDiscoveryTimeout)
+	defer cancel()
+	discovered, err := ollama.NewClient(baseURL, headers, nil).ListModels(ctx)
+	if err != nil {
+		slog.Warn("Failed to list Ollama models", "provider", providerConfig.ID, "base_url", baseURL, "error", err)
+		return
+	}
+	providerConfig.Models = mergeDiscoveredModels(providerConfig.Models, discovered)
+}
+
+// mergeDiscoveredModels completes the configured models with the discovered
+// ones and appends the discovered models that aren't configured.
+func mergeDiscoveredModels(configured, discovered []catwalk.Model) []catwalk.Model {
+	byID := make(map[string]catwalk.Model, len(discovered))
+	for _, model := range discovered {
+		byID[model.ID] = model
+	}
+
+	models := make([]catwalk.Model, 0, len(configured)+len(discovered))
+	seen := make(map[string]bool)
+	for _, model := range configured {
+		if seen[model.ID] {
+			continue
+		}
+		seen[model.ID] = true
+		if found, ok := byID[model.ID]; ok {
+			model.Name = cmp.Or(model.Name, found.Name)
+			model.ContextWindow = cmp.Or(model.ContextWindow, found.ContextWindow)
+			model.DefaultMaxTokens = cmp.Or(model.DefaultMaxTokens, found.DefaultMaxTokens)
+			model.CanReason = model.CanReason || found.CanReason
+			model.SupportsImages = model.SupportsImages || found.SupportsImages
+		}
+		models = append(models, model)
+	}
+	for _, model := range discovered {
+		if !seen[model.ID] {
+			models = append(models, model)
+		}
+	}
+	return models
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/vikvang/zero/internal/home"
+)
+
+// ShellConfig configures the environment and setup of the persistent shell
+// used by the bash tool.
+type ShellConfig struct {
+	Env        map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set in the shell,example={\"GOFLAGS\":\"-mod=mod\"}"`
+	PathPrefix []string          `json:"path_prefix,omitempty" jsonschema:"description=Directories to prepend to PATH relative to the working directory unless absolute or starting with ~,example=node_modules/.bin,example=.venv/bin"`
+	EnvFiles   []string          `json:"env_files,omitempty" jsonschema:"description=Dotenv files to load into the shell relative to the working directory unless absolute or starting with ~; missing files are ignored,example=.env,example=.env.local"`
+	InitScript string            `json:"init_script,omitempty" jsonschema:"description=Script run once when the shell starts,example=source .venv/bin/activate"`
+}
+
+// InitScript returns the script to run when the persistent shell starts.
+func (c *Config) InitScript() string {
+	if c.Shell == nil {
+		return ""
+	}
+	return c.Shell.InitScript
+}
+
+// ShellEnv returns the environment the persistent shell starts with: the
+// environment of the process, overridden by the variables of the env files
+// and then of the config, with the PATH prefix prepended to PATH.
+func (c *Config) ShellEnv() []string {
+	environ := os.Environ()
+	if c.Shell == nil {
+		return environ
+	}
+
+	for _, file := range c.Shell.EnvFiles {
+		vars, err := godotenv.Read(c.shellPath(file))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("Failed to load env file", "file", file, "error", err)
+			continue
+		}
+		for _, key := range slices.Sorted(maps.Keys(vars)) {
+			environ = setEnv(environ, key, vars[key])
+		}
+	}
+
+	// resolveEnvs resolves the values in place, so it gets a copy.
+	for _, kv := range resolveEnvs(maps.Clone(c.Shell.Env)) {
+		key, value, _ := strings.Cut(kv, "=")
+		environ = setEnv(environ, key, value)
+	}
+
+	if len(c.Shell.PathPrefix) > 0 {
+		var dirs []string
+		for _, dir := range c.Shell.PathPrefix {
+			dirs = append(dirs, c.shellPath(dir))
+		}
+		if path := getEnv(environ, "PATH"); path != "" {
+			dirs = append(dirs, path)
+		}
+		environ = setEnv(environ, "PATH", strings.Join(dirs, string(os.PathListSeparator)))
+	}
+	return environ
+}
+
+// shellPath returns the path relative to the working directory unless it is
+// absolute or starts with ~.
+func (c *Config) shellPath(path string) string {
+	path = home.Long(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.workingDir, path)
+}
+
+// setEnv sets the variable in the KEY=value list.
+func setEnv(environ []string, key, value string) []string {
+	prefix := key + "="
+	for i, kv := range environ {
+		if strings.HasPrefix(kv, prefix) {
+			environ[i] = prefix + value
+			return environ
+		}
+	}
+	return append(environ, prefix+value)
+}
+
+// getEnv returns the variable of the KEY=value list.
+func getEnv(environ []string, key string) string {
+	prefix := key + "="
+	for _, kv := range environ {
+		if value, ok := strings.CutPrefix(kv, prefix); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ShellEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("FROM_PROCESS", "process")
+	t.Setenv("OVERRIDDEN", "process")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("FROM_FILE=file\nOVERRIDDEN=file\nBOTH=file\n"), 0o644))
+	cfg := &Config{
+		workingDir: dir,
+		Shell: &ShellConfig{
+			Env:        map[string]string{"BOTH": "config", "GREETING": "hello $FROM_PROCESS"},
+			EnvFiles:   []string{".env", ".env.missing"},
+			PathPrefix: []string{"node_modules/.bin", "/opt/bin"},
+		},
+	}
+
+	env := cfg.ShellEnv()
+	require.Contains(t, env, "FROM_PROCESS=process")
+	require.Contains(t, env, "FROM_FILE=file")
+	require.Contains(t, env, "OVERRIDDEN=file", "env files override the process")
+	require.Contains(t, env, "BOTH=config", "the config overrides env files")
+	require.Contains(t, env, "GREETING=hello process")
+	require.Contains(t, env, "PATH="+filepath.Join(dir, "node_modules/.bin")+string(os.PathListSeparator)+"/opt/bin"+string(os.PathListSeparator)+"/usr/bin")
+	require.Equal(t, "hello $FROM_PROCESS", cfg.Shell.Env["GREETING"], "the config isn't changed")
+}
+
+func TestConfig_ShellEnv_NoConfig(t *testing.T) {
+	t.Setenv("FROM_PROCESS", "process")
+
+	cfg := &Config{}
+	require.Contains(t, cfg.ShellEnv(), "FROM_PROCESS=process")
+	require.Empty(t, cfg.InitScript())
+}
@@ -13,13 +13,17 @@ import (
 	"github.com/pressly/goose/v3"
 )
 
+// Path returns the path of the database in the data directory.
+func Path(dataDir string) string {
+	return filepath.Join(dataDir, "crush.db")
+}
+
 func Connect(ctx context.Context, dataDir string) (*sql.DB, error) {
 	if dataDir == "" {
 		return nil, fmt.Errorf("data.dir is not set")
 	}
-	dbPath := filepath.Join(dataDir, "crush.db")
 	// Open the SQLite database
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", Path(dataDir))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
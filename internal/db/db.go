@@ -24,12 +24,21 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addSessionCostStmt, err = db.PrepareContext(ctx, addSessionCost); err != nil {
+		return nil, fmt.Errorf("error preparing query AddSessionCost: %w", err)
+	}
+	if q.createCheckpointStmt, err = db.PrepareContext(ctx, createCheckpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCheckpoint: %w", err)
+	}
 	if q.createFileStmt, err = db.PrepareContext(ctx, createFile); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateFile: %w", err)
 	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createPermissionGrantStmt, err = db.PrepareContext(ctx, createPermissionGrant); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePermissionGrant: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
@@ -39,6 +48,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteMessageStmt, err = db.PrepareContext(ctx, deleteMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteMessage: %w", err)
 	}
+	if q.deletePermissionGrantStmt, err = db.PrepareContext(ctx, deletePermissionGrant); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePermissionGrant: %w", err)
+	}
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
@@ -48,6 +60,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionMessagesStmt, err = db.PrepareContext(ctx, deleteSessionMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionMessages: %w", err)
 	}
+	if q.getCheckpointStmt, err = db.PrepareContext(ctx, getCheckpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCheckpoint: %w", err)
+	}
+	if q.getCostSinceStmt, err = db.PrepareContext(ctx, getCostSince); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCostSince: %w", err)
+	}
 	if q.getFileStmt, err = db.PrepareContext(ctx, getFile); err != nil {
 		return nil, fmt.Errorf("error preparing query GetFile: %w", err)
 	}
@@ -60,6 +78,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.listCheckpointsBySessionStmt, err = db.PrepareContext(ctx, listCheckpointsBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCheckpointsBySession: %w", err)
+	}
+	if q.listChildSessionsStmt, err = db.PrepareContext(ctx, listChildSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChildSessions: %w", err)
+	}
 	if q.listFilesByPathStmt, err = db.PrepareContext(ctx, listFilesByPath); err != nil {
 		return nil, fmt.Errorf("error preparing query ListFilesByPath: %w", err)
 	}
@@ -69,12 +93,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listLatestSessionFilesStmt, err = db.PrepareContext(ctx, listLatestSessionFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListLatestSessionFiles: %w", err)
 	}
+	if q.listMessageUsageStmt, err = db.PrepareContext(ctx, listMessageUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMessageUsage: %w", err)
+	}
 	if q.listMessagesBySessionStmt, err = db.PrepareContext(ctx, listMessagesBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query ListMessagesBySession: %w", err)
 	}
 	if q.listNewFilesStmt, err = db.PrepareContext(ctx, listNewFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListNewFiles: %w", err)
 	}
+	if q.listPermissionGrantsStmt, err = db.PrepareContext(ctx, listPermissionGrants); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPermissionGrants: %w", err)
+	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
 	}
@@ -89,6 +119,16 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addSessionCostStmt != nil {
+		if cerr := q.addSessionCostStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addSessionCostStmt: %w", cerr)
+		}
+	}
+	if q.createCheckpointStmt != nil {
+		if cerr := q.createCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCheckpointStmt: %w", cerr)
+		}
+	}
 	if q.createFileStmt != nil {
 		if cerr := q.createFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createFileStmt: %w", cerr)
@@ -99,6 +139,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createPermissionGrantStmt != nil {
+		if cerr := q.createPermissionGrantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPermissionGrantStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
@@ -114,6 +159,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteMessageStmt: %w", cerr)
 		}
 	}
+	if q.deletePermissionGrantStmt != nil {
+		if cerr := q.deletePermissionGrantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePermissionGrantStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionStmt != nil {
 		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
@@ -129,6 +179,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionMessagesStmt: %w", cerr)
 		}
 	}
+	if q.getCheckpointStmt != nil {
+		if cerr := q.getCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCheckpointStmt: %w", cerr)
+		}
+	}
+	if q.getCostSinceStmt != nil {
+		if cerr := q.getCostSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCostSinceStmt: %w", cerr)
+		}
+	}
 	if q.getFileStmt != nil {
 		if cerr := q.getFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getFileStmt: %w", cerr)
@@ -149,6 +209,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.listCheckpointsBySessionStmt != nil {
+		if cerr := q.listCheckpointsBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCheckpointsBySessionStmt: %w", cerr)
+		}
+	}
+	if q.listChildSessionsStmt != nil {
+		if cerr := q.listChildSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChildSessionsStmt: %w", cerr)
+		}
+	}
 	if q.listFilesByPathStmt != nil {
 		if cerr := q.listFilesByPathStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listFilesByPathStmt: %w", cerr)
@@ -164,6 +234,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listLatestSessionFilesStmt: %w", cerr)
 		}
 	}
+	if q.listMessageUsageStmt != nil {
+		if cerr := q.listMessageUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMessageUsageStmt: %w", cerr)
+		}
+	}
 	if q.listMessagesBySessionStmt != nil {
 		if cerr := q.listMessagesBySessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listMessagesBySessionStmt: %w", cerr)
@@ -174,6 +249,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listNewFilesStmt: %w", cerr)
 		}
 	}
+	if q.listPermissionGrantsStmt != nil {
+		if cerr := q.listPermissionGrantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPermissionGrantsStmt: %w", cerr)
+		}
+	}
 	if q.listSessionsStmt != nil {
 		if cerr := q.listSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
@@ -226,53 +306,73 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                          DBTX
-	tx                          *sql.Tx
-	createFileStmt              *sql.Stmt
-	createMessageStmt           *sql.Stmt
-	createSessionStmt           *sql.Stmt
-	deleteFileStmt              *sql.Stmt
-	deleteMessageStmt           *sql.Stmt
-	deleteSessionStmt           *sql.Stmt
-	deleteSessionFilesStmt      *sql.Stmt
-	deleteSessionMessagesStmt   *sql.Stmt
-	getFileStmt                 *sql.Stmt
-	getFileByPathAndSessionStmt *sql.Stmt
-	getMessageStmt              *sql.Stmt
-	getSessionByIDStmt          *sql.Stmt
-	listFilesByPathStmt         *sql.Stmt
-	listFilesBySessionStmt      *sql.Stmt
-	listLatestSessionFilesStmt  *sql.Stmt
-	listMessagesBySessionStmt   *sql.Stmt
-	listNewFilesStmt            *sql.Stmt
-	listSessionsStmt            *sql.Stmt
-	updateMessageStmt           *sql.Stmt
-	updateSessionStmt           *sql.Stmt
+	db                           DBTX
+	tx                           *sql.Tx
+	addSessionCostStmt           *sql.Stmt
+	createCheckpointStmt         *sql.Stmt
+	createFileStmt               *sql.Stmt
+	createMessageStmt            *sql.Stmt
+	createPermissionGrantStmt    *sql.Stmt
+	createSessionStmt            *sql.Stmt
+	deleteFileStmt               *sql.Stmt
+	deleteMessageStmt            *sql.Stmt
+	deletePermissionGrantStmt    *sql.Stmt
+	deleteSessionStmt            *sql.Stmt
+	deleteSessionFilesStmt       *sql.Stmt
+	deleteSessionMessagesStmt    *sql.Stmt
+	getCheckpointStmt            *sql.Stmt
+	getCostSinceStmt             *sql.Stmt
+	getFileStmt                  *sql.Stmt
+	getFileByPathAndSessionStmt  *sql.Stmt
+	getMessageStmt               *sql.Stmt
+	getSessionByIDStmt           *sql.Stmt
+	listCheckpointsBySessionStmt *sql.Stmt
+	listChildSessionsStmt        *sql.Stmt
+	listFilesByPathStmt          *sql.Stmt
+	listFilesBySessionStmt       *sql.Stmt
+	listLatestSessionFilesStmt   *sql.Stmt
+	listMessageUsageStmt         *sql.Stmt
+	listMessagesBySessionStmt    *sql.Stmt
+	listNewFilesStmt             *sql.Stmt
+	listPermissionGrantsStmt     *sql.Stmt
+	listSessionsStmt             *sql.Stmt
+	updateMessageStmt            *sql.Stmt
+	updateSessionStmt            *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                          tx,
-		tx:                          tx,
-		createFileStmt:              q.createFileStmt,
-		createMessageStmt:           q.createMessageStmt,
-		createSessionStmt:           q.createSessionStmt,
-		deleteFileStmt:              q.deleteFileStmt,
-		deleteMessageStmt:           q.deleteMessageStmt,
-		deleteSessionStmt:           q.deleteSessionStmt,
-		deleteSessionFilesStmt:      q.deleteSessionFilesStmt,
-		deleteSessionMessagesStmt:   q.deleteSessionMessagesStmt,
-		getFileStmt:                 q.getFileStmt,
-		getFileByPathAndSessionStmt: q.getFileByPathAndSessionStmt,
-		getMessageStmt:              q.getMessageStmt,
-		getSessionByIDStmt:          q.getSessionByIDStmt,
-		listFilesByPathStmt:         q.listFilesByPathStmt,
-		listFilesBySessionStmt:      q.listFilesBySessionStmt,
-		listLatestSessionFilesStmt:  q.listLatestSessionFilesStmt,
-		listMessagesBySessionStmt:   q.listMessagesBySessionStmt,
-		listNewFilesStmt:            q.listNewFilesStmt,
-		listSessionsStmt:            q.listSessionsStmt,
-		updateMessageStmt:           q.updateMessageStmt,
-		updateSessionStmt:           q.updateSessionStmt,
+		db:                           tx,
+		tx:                           tx,
+		addSessionCostStmt:           q.addSessionCostStmt,
+		createCheckpointStmt:         q.createCheckpointStmt,
+		createFileStmt:               q.createFileStmt,
+		createMessageStmt:            q.createMessageStmt,
+		createPermissionGrantStmt:    q.createPermissionGrantStmt,
+		createSessionStmt:            q.createSessionStmt,
+		deleteFileStmt:               q.deleteFileStmt,
+		deleteMessageStmt:            q.deleteMessageStmt,
+		deletePermissionGrantStmt:    q.deletePermissionGrantStmt,
+		deleteSessionStmt:            q.deleteSessionStmt,
+		deleteSessionFilesStmt:       q.deleteSessionFilesStmt,
+		deleteSessionMessagesStmt:    q.deleteSessionMessagesStmt,
+		getCheckpointStmt:            q.getCheckpointStmt,
+		getCostSinceStmt:             q.getCostSinceStmt,
+		getFileStmt:                  q.getFileStmt,
+		getFileByPathAndSessionStmt:  q.getFileByPathAndSessionStmt,
+		getMessageStmt:               q.getMessageStmt,
+		getSessionByIDStmt:           q.getSessionByIDStmt,
+		listCheckpointsBySessionStmt: q.listCheckpointsBySessionStmt,
+		listChildSessionsStmt:        q.listChildSessionsStmt,
+		listFilesByPathStmt:          q.listFilesByPathStmt,
+		listFilesBySessionStmt:       q.listFilesBySessionStmt,
+		listLatestSessionFilesStmt:   q.listLatestSessionFilesStmt,
+		listMessageUsageStmt:         q.listMessageUsageStmt,
+		listMessagesBySessionStmt:    q.listMessagesBySessionStmt,
+		listNewFilesStmt:             q.listNewFilesStmt,
+		listPermissionGrantsStmt:     q.listPermissionGrantsStmt,
+		listSessionsStmt:             q.listSessionsStmt,
+		updateMessageStmt:            q.updateMessageStmt,
+		updateSessionStmt:            q.updateSessionStmt,
 	}
 }
@@ -18,21 +18,27 @@ INSERT INTO messages (
     parts,
     model,
     provider,
+    cost,
+    prompt_tokens,
+    completion_tokens,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
-RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider
+RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, cost, prompt_tokens, completion_tokens
 `
 
 type CreateMessageParams struct {
-	ID        string         `json:"id"`
-	SessionID string         `json:"session_id"`
-	Role      string         `json:"role"`
-	Parts     string         `json:"parts"`
-	Model     sql.NullString `json:"model"`
-	Provider  sql.NullString `json:"provider"`
+	ID               string         `json:"id"`
+	SessionID        string         `json:"session_id"`
+	Role             string         `json:"role"`
+	Parts            string         `json:"parts"`
+	Model            sql.NullString `json:"model"`
+	Provider         sql.NullString `json:"provider"`
+	Cost             float64        `json:"cost"`
+	PromptTokens     int64          `json:"prompt_tokens"`
+	CompletionTokens int64          `json:"completion_tokens"`
 }
 
 func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
@@ -43,6 +49,9 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		arg.Parts,
 		arg.Model,
 		arg.Provider,
+		arg.Cost,
+		arg.PromptTokens,
+		arg.CompletionTokens,
 	)
 	var i Message
 	err := row.Scan(
@@ -55,6 +64,9 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.UpdatedAt,
 		&i.FinishedAt,
 		&i.Provider,
+		&i.Cost,
+		&i.PromptTokens,
+		&i.CompletionTokens,
 	)
 	return i, err
 }
@@ -79,8 +91,21 @@ func (q *Queries) DeleteSessionMessages(ctx context.Context, sessionID string) e
 	return err
 }
 
+const getCostSince = `-- name: GetCostSince :one
+SELECT CAST(COALESCE(SUM(cost), 0.0) AS REAL) AS cost
+FROM messages
+WHERE created_at >= ?
+`
+
+func (q *Queries) GetCostSince(ctx context.Context, createdAt int64) (float64, error) {
+	row := q.queryRow(ctx, q.getCostSinceStmt, getCostSince, createdAt)
+	var cost float64
+	err := row.Scan(&cost)
+	return cost, err
+}
+
 const getMessage = `-- name: GetMessage :one
-SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, cost, prompt_tokens, completion_tokens
 FROM messages
 WHERE id = ? LIMIT 1
 `
@@ -98,12 +123,84 @@ func (q *Queries) GetMessage(ctx context.Context, id string) (Message, error) {
 		&i.UpdatedAt,
 		&i.FinishedAt,
 		&i.Provider,
+		&i.Cost,
+		&i.PromptTokens,
+		&i.CompletionTokens,
 	)
 	return i, err
 }
 
+const listMessageUsage = `-- name: ListMessageUsage :many
+SELECT
+    messages.id,
+    messages.session_id,
+    sessions.parent_session_id,
+    sessions.title,
+    parents.title AS parent_title,
+    messages.model,
+    messages.provider,
+    messages.prompt_tokens,
+    messages.completion_tokens,
+    messages.cost,
+    messages.created_at
+FROM messages
+JOIN sessions ON sessions.id = messages.session_id
+LEFT JOIN sessions AS parents ON parents.id = sessions.parent_session_id
+WHERE messages.role = 'assistant' AND messages.created_at >= ?
+ORDER BY messages.created_at ASC
+`
+
+type ListMessageUsageRow struct {
+	ID               string         `json:"id"`
+	SessionID        string         `json:"session_id"`
+	ParentSessionID  sql.NullString `json:"parent_session_id"`
+	Title            string         `json:"title"`
+	ParentTitle      sql.NullString `json:"parent_title"`
+	Model            sql.NullString `json:"model"`
+	Provider         sql.NullString `json:"provider"`
+	PromptTokens     int64          `json:"prompt_tokens"`
+	CompletionTokens int64          `json:"completion_tokens"`
+	Cost             float64        `json:"cost"`
+	CreatedAt        int64          `json:"created_at"`
+}
+
+func (q *Queries) ListMessageUsage(ctx context.Context, createdAt int64) ([]ListMessageUsageRow, error) {
+	rows, err := q.query(ctx, q.listMessageUsageStmt, listMessageUsage, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMessageUsageRow{}
+	for rows.Next() {
+		var i ListMessageUsageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.ParentSessionID,
+			&i.Title,
+			&i.ParentTitle,
+			&i.Model,
+			&i.Provider,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMessagesBySession = `-- name: ListMessagesBySession :many
-SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, cost, prompt_tokens, completion_tokens
 FROM messages
 WHERE session_id = ?
 ORDER BY created_at ASC
@@ -128,6 +225,9 @@ func (q *Queries) ListMessagesBySession(ctx context.Context, sessionID string) (
 			&i.UpdatedAt,
 			&i.FinishedAt,
 			&i.Provider,
+			&i.Cost,
+			&i.PromptTokens,
+			&i.CompletionTokens,
 		); err != nil {
 			return nil, err
 		}
@@ -147,17 +247,36 @@ UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    model = ?,
+    provider = ?,
+    cost = ?,
+    prompt_tokens = ?,
+    completion_tokens = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?
 `
 
 type UpdateMessageParams struct {
-	Parts      string        `json:"parts"`
-	FinishedAt sql.NullInt64 `json:"finished_at"`
-	ID         string        `json:"id"`
+	Parts            string         `json:"parts"`
+	FinishedAt       sql.NullInt64  `json:"finished_at"`
+	Model            sql.NullString `json:"model"`
+	Provider         sql.NullString `json:"provider"`
+	Cost             float64        `json:"cost"`
+	PromptTokens     int64          `json:"prompt_tokens"`
+	CompletionTokens int64          `json:"completion_tokens"`
+	ID               string         `json:"id"`
 }
 
 func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) error {
-	_, err := q.exec(ctx, q.updateMessageStmt, updateMessage, arg.Parts, arg.FinishedAt, arg.ID)
+	_, err := q.exec(ctx, q.updateMessageStmt, updateMessage,
+		arg.Parts,
+		arg.FinishedAt,
+		arg.Model,
+		arg.Provider,
+		arg.Cost,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+		arg.ID,
+	)
 	return err
 }
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Permission grants that outlive a single prompt
+CREATE TABLE IF NOT EXISTS permission_grants (
+    id TEXT PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('session', 'project', 'global')),
+    session_id TEXT,
+    tool_name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_permission_grants_session_id ON permission_grants (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_permission_grants_session_id;
+DROP TABLE IF EXISTS permission_grants;
+-- +goose StatementEnd
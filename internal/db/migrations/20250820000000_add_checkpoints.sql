@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Checkpoints record the state of the files of a session before each turn
+CREATE TABLE IF NOT EXISTS checkpoints (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    files TEXT NOT NULL DEFAULT '{}',  -- JSON object of path to file version ID
+    created_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkpoints_session_id ON checkpoints (session_id);
+CREATE INDEX IF NOT EXISTS idx_checkpoints_message_id ON checkpoints (message_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_checkpoints_message_id;
+DROP INDEX IF EXISTS idx_checkpoints_session_id;
+DROP TABLE IF EXISTS checkpoints;
+-- +goose StatementEnd
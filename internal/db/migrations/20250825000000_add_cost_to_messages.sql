@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Record the cost of the response of each message so it can be summed by day
+ALTER TABLE messages ADD COLUMN cost REAL NOT NULL DEFAULT 0.0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE messages DROP COLUMN cost;
+-- +goose StatementEnd
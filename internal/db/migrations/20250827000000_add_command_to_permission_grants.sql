@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Limit bash grants to the command they were granted for. Existing bash
+-- grants allowed every command, so they are dropped.
+ALTER TABLE permission_grants ADD COLUMN command TEXT NOT NULL DEFAULT '';
+DELETE FROM permission_grants WHERE tool_name = 'bash';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE permission_grants DROP COLUMN command;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Mark the initial versions of files that didn't exist before the session
+-- created them, so that rewinding deletes them but not existing empty files
+ALTER TABLE files ADD COLUMN is_new BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN is_new;
+-- +goose StatementEnd
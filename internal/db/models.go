@@ -8,6 +8,14 @@ import (
 	"database/sql"
 )
 
+type Checkpoint struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	Files     string `json:"files"`
+	CreatedAt int64  `json:"created_at"`
+}
+
 type File struct {
 	ID        string `json:"id"`
 	SessionID string `json:"session_id"`
@@ -16,18 +24,33 @@ type File struct {
 	Version   int64  `json:"version"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	IsNew     bool   `json:"is_new"`
 }
 
 type Message struct {
-	ID         string         `json:"id"`
-	SessionID  string         `json:"session_id"`
-	Role       string         `json:"role"`
-	Parts      string         `json:"parts"`
-	Model      sql.NullString `json:"model"`
-	CreatedAt  int64          `json:"created_at"`
-	UpdatedAt  int64          `json:"updated_at"`
-	FinishedAt sql.NullInt64  `json:"finished_at"`
-	Provider   sql.NullString `json:"provider"`
+	ID               string         `json:"id"`
+	SessionID        string         `json:"session_id"`
+	Role             string         `json:"role"`
+	Parts            string         `json:"parts"`
+	Model            sql.NullString `json:"model"`
+	CreatedAt        int64          `json:"created_at"`
+	UpdatedAt        int64          `json:"updated_at"`
+	FinishedAt       sql.NullInt64  `json:"finished_at"`
+	Provider         sql.NullString `json:"provider"`
+	Cost             float64        `json:"cost"`
+	PromptTokens     int64          `json:"prompt_tokens"`
+	CompletionTokens int64          `json:"completion_tokens"`
+}
+
+type PermissionGrant struct {
+	ID        string         `json:"id"`
+	Scope     string         `json:"scope"`
+	SessionID sql.NullString `json:"session_id"`
+	ToolName  string         `json:"tool_name"`
+	Action    string         `json:"action"`
+	Path      string         `json:"path"`
+	CreatedAt int64          `json:"created_at"`
+	Command   string         `json:"command"`
 }
 
 type Session struct {
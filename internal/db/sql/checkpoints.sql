@@ -0,0 +1,25 @@
+-- name: CreateCheckpoint :one
+INSERT INTO checkpoints (
+    id,
+    session_id,
+    message_id,
+    files,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+) RETURNING *;
+
+-- name: GetCheckpoint :one
+SELECT *
+FROM checkpoints
+WHERE id = ? LIMIT 1;
+
+-- name: ListCheckpointsBySession :many
+SELECT *
+FROM checkpoints
+WHERE session_id = ?
+ORDER BY created_at ASC, rowid ASC;
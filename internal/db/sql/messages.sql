@@ -9,6 +9,30 @@ FROM messages
 WHERE session_id = ?
 ORDER BY created_at ASC;
 
+-- name: GetCostSince :one
+SELECT CAST(COALESCE(SUM(cost), 0.0) AS REAL) AS cost
+FROM messages
+WHERE created_at >= ?;
+
+-- name: ListMessageUsage :many
+SELECT
+    messages.id,
+    messages.session_id,
+    sessions.parent_session_id,
+    sessions.title,
+    parents.title AS parent_title,
+    messages.model,
+    messages.provider,
+    messages.prompt_tokens,
+    messages.completion_tokens,
+    messages.cost,
+    messages.created_at
+FROM messages
+JOIN sessions ON sessions.id = messages.session_id
+LEFT JOIN sessions AS parents ON parents.id = sessions.parent_session_id
+WHERE messages.role = 'assistant' AND messages.created_at >= ?
+ORDER BY messages.created_at ASC;
+
 -- name: CreateMessage :one
 INSERT INTO messages (
     id,
@@ -17,10 +41,13 @@ INSERT INTO messages (
     parts,
     model,
     provider,
+    cost,
+    prompt_tokens,
+    completion_tokens,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
 RETURNING *;
 
@@ -29,6 +56,11 @@ UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    model = ?,
+    provider = ?,
+    cost = ?,
+    prompt_tokens = ?,
+    completion_tokens = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
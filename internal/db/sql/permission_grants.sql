@@ -0,0 +1,29 @@
+-- name: CreatePermissionGrant :one
+INSERT INTO permission_grants (
+    id,
+    scope,
+    session_id,
+    tool_name,
+    action,
+    path,
+    command,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+) RETURNING *;
+
+-- name: ListPermissionGrants :many
+SELECT *
+FROM permission_grants
+ORDER BY created_at ASC;
+
+-- name: DeletePermissionGrant :exec
+DELETE FROM permission_grants
+WHERE id = ?;
@@ -34,17 +34,28 @@ FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY created_at DESC;
 
+-- name: ListChildSessions :many
+SELECT *
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC;
+
 -- name: UpdateSession :one
 UPDATE sessions
 SET
     title = ?,
     prompt_tokens = ?,
     completion_tokens = ?,
-    summary_message_id = ?,
-    cost = ?
+    summary_message_id = ?
 WHERE id = ?
 RETURNING *;
 
+-- name: AddSessionCost :one
+UPDATE sessions
+SET
+    cost = cost + ?
+WHERE id = ?
+RETURNING *;
 
 -- name: DeleteSession :exec
 DELETE FROM sessions
@@ -0,0 +1,104 @@
+// Package grants stores permission grants in the database, so they survive
+// restarts. Session and project grants live in the project database, global
+// grants in the database of the global data directory.
+package grants
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/vikvang/zero/internal/db"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+// ErrNoGlobalStore is returned when saving a global grant without a global
+// database.
+var ErrNoGlobalStore = errors.New("global permission grants are not available")
+
+type store struct {
+	project db.Querier
+	global  db.Querier
+}
+
+// NewStore creates a grant store. global may be nil, in which case global
+// grants can't be saved.
+func NewStore(project, global db.Querier) permission.GrantStore {
+	return &store{project: project, global: global}
+}
+
+func (s *store) List(ctx context.Context) ([]permission.Grant, error) {
+	var grants []permission.Grant
+	for _, q := range s.queriers() {
+		dbGrants, err := q.ListPermissionGrants(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list permission grants: %w", err)
+		}
+		for _, g := range dbGrants {
+			grants = append(grants, fromDBItem(g))
+		}
+	}
+	slices.SortStableFunc(grants, func(a, b permission.Grant) int {
+		return cmp.Compare(a.CreatedAt, b.CreatedAt)
+	})
+	return grants, nil
+}
+
+func (s *store) Create(ctx context.Context, grant permission.Grant) (permission.Grant, error) {
+	q := s.project
+	if grant.Scope == permission.GrantScopeGlobal {
+		if s.global == nil {
+			return permission.Grant{}, ErrNoGlobalStore
+		}
+		q = s.global
+	}
+	dbGrant, err := q.CreatePermissionGrant(ctx, db.CreatePermissionGrantParams{
+		ID:    grant.ID,
+		Scope: string(grant.Scope),
+		SessionID: sql.NullString{
+			String: grant.SessionID,
+			Valid:  grant.SessionID != "",
+		},
+		ToolName: grant.ToolName,
+		Action:   grant.Action,
+		Path:     grant.Path,
+		Command:  grant.Command,
+	})
+	if err != nil {
+		return permission.Grant{}, fmt.Errorf("failed to save permission grant: %w", err)
+	}
+	return fromDBItem(dbGrant), nil
+}
+
+// Delete deletes the grant from whichever database has it.
+func (s *store) Delete(ctx context.Context, id string) error {
+	for _, q := range s.queriers() {
+		if err := q.DeletePermissionGrant(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete permission grant: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *store) queriers() []db.Querier {
+	if s.global == nil {
+		return []db.Querier{s.project}
+	}
+	return []db.Querier{s.project, s.global}
+}
+
+func fromDBItem(item db.PermissionGrant) permission.Grant {
+	return permission.Grant{
+		ID:        item.ID,
+		Scope:     permission.GrantScope(item.Scope),
+		SessionID: item.SessionID.String,
+		ToolName:  item.ToolName,
+		Action:    item.Action,
+		Path:      item.Path,
+		Command:   item.Command,
+		CreatedAt: item.CreatedAt,
+	}
+}
@@ -0,0 +1,242 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/vikvang/zero/internal/db"
+)
+
+// Checkpoint is the state of the files of a session before a turn, i.e.
+// before the agent responded to the user message MessageID.
+type Checkpoint struct {
+	ID        string
+	SessionID string
+	MessageID string
+	// Files maps the paths the session touched before the checkpoint to the
+	// ID of their latest version.
+	Files     map[string]string
+	CreatedAt int64
+}
+
+// FileChange is a change restoring a checkpoint makes to a file.
+type FileChange struct {
+	Path string
+	// Before is the current content of the file.
+	Before string
+	// After is the content of the file once the checkpoint is restored.
+	After string
+	// Deleted is set for files the session created after the checkpoint,
+	// which restoring removes.
+	Deleted bool
+}
+
+// CreateCheckpoint records the current state of the files of the session and
+// its sub-agent sessions.
+func (s *service) CreateCheckpoint(ctx context.Context, sessionID, messageID string) (Checkpoint, error) {
+	files, err := s.sessionFiles(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	latest := make(map[string]string)
+	for path, file := range latestVersions(files) {
+		latest[path] = file.ID
+	}
+	filesJSON, err := json.Marshal(latest)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to marshal checkpoint files: %w", err)
+	}
+	dbCheckpoint, err := s.q.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		MessageID: messageID,
+		Files:     string(filesJSON),
+	})
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	return s.checkpointFromDBItem(dbCheckpoint)
+}
+
+// ListCheckpoints returns the checkpoints of a session, oldest first.
+func (s *service) ListCheckpoints(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	dbCheckpoints, err := s.q.ListCheckpointsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]Checkpoint, len(dbCheckpoints))
+	for i, dbCheckpoint := range dbCheckpoints {
+		if checkpoints[i], err = s.checkpointFromDBItem(dbCheckpoint); err != nil {
+			return nil, err
+		}
+	}
+	return checkpoints, nil
+}
+
+// PreviewCheckpoint returns the changes restoring the checkpoint would make,
+// without making them.
+func (s *service) PreviewCheckpoint(ctx context.Context, checkpointID string) ([]FileChange, error) {
+	dbCheckpoint, err := s.q.GetCheckpoint(ctx, checkpointID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("checkpoint %s not found", checkpointID)
+		}
+		return nil, err
+	}
+	checkpoint, err := s.checkpointFromDBItem(dbCheckpoint)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.sessionFiles(ctx, checkpoint.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return checkpointChanges(checkpoint, files)
+}
+
+// checkpointChanges returns the changes restoring the checkpoint makes to the
+// files, given all the versions of the files of the session.
+func checkpointChanges(checkpoint Checkpoint, files []File) ([]FileChange, error) {
+	byID := make(map[string]File, len(files))
+	for _, file := range files {
+		byID[file.ID] = file
+	}
+	initial := initialVersions(files)
+
+	var changes []FileChange
+	for _, path := range slices.Sorted(maps.Keys(initial)) {
+		var change FileChange
+		if id, ok := checkpoint.Files[path]; ok {
+			file, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("version %s of %s not found", id, path)
+			}
+			change.After = file.Content
+		} else {
+			// Touched after the checkpoint, so the initial version is the
+			// content before the session changed it, or marks a file the
+			// session created.
+			change.After = initial[path].Content
+			change.Deleted = initial[path].IsNew
+		}
+
+		current, err := os.ReadFile(path)
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if change.Deleted && !exists {
+			continue
+		}
+		if !change.Deleted && exists && string(current) == change.After {
+			continue
+		}
+		change.Path = path
+		change.Before = string(current)
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// RestoreCheckpoint restores the files of the session to their state at the
+// checkpoint, and records the restored content as new versions.
+func (s *service) RestoreCheckpoint(ctx context.Context, checkpointID string) ([]FileChange, error) {
+	changes, err := s.PreviewCheckpoint(ctx, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	dbCheckpoint, err := s.q.GetCheckpoint(ctx, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Deleted {
+			if err := os.Remove(change.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to remove %s: %w", change.Path, err)
+			}
+		} else {
+			mode := fs.FileMode(0o644)
+			if info, err := os.Stat(change.Path); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.MkdirAll(filepath.Dir(change.Path), 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create directory for %s: %w", change.Path, err)
+			}
+			if err := os.WriteFile(change.Path, []byte(change.After), mode); err != nil {
+				return nil, fmt.Errorf("failed to restore %s: %w", change.Path, err)
+			}
+		}
+		if _, err := s.CreateVersion(ctx, dbCheckpoint.SessionID, change.Path, change.After); err != nil {
+			return nil, fmt.Errorf("failed to record restored version of %s: %w", change.Path, err)
+		}
+	}
+	return changes, nil
+}
+
+// sessionFiles returns all file versions of the session and its sub-agent
+// sessions.
+func (s *service) sessionFiles(ctx context.Context, sessionID string) ([]File, error) {
+	files, err := s.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	children, err := s.q.ListChildSessions(ctx, sql.NullString{String: sessionID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		childFiles, err := s.sessionFiles(ctx, child.ID)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, childFiles...)
+	}
+	return files, nil
+}
+
+// latestVersions returns the latest version of each path. Versions are
+// numbered per path across sessions, so the highest one is the latest.
+func latestVersions(files []File) map[string]File {
+	latest := make(map[string]File)
+	for _, file := range files {
+		if existing, ok := latest[file.Path]; !ok || file.Version > existing.Version {
+			latest[file.Path] = file
+		}
+	}
+	return latest
+}
+
+// initialVersions returns the first version of each path, i.e. its content
+// before it was first changed.
+func initialVersions(files []File) map[string]File {
+	initial := make(map[string]File)
+	for _, file := range files {
+		if existing, ok := initial[file.Path]; !ok || file.Version < existing.Version {
+			initial[file.Path] = file
+		}
+	}
+	return initial
+}
+
+func (s *service) checkpointFromDBItem(item db.Checkpoint) (Checkpoint, error) {
+	checkpoint := Checkpoint{
+		ID:        item.ID,
+		SessionID: item.SessionID,
+		MessageID: item.MessageID,
+		CreatedAt: item.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(item.Files), &checkpoint.Files); err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to unmarshal checkpoint files: %w", err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,51 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	created := filepath.Join(dir, "created.go")
+	empty := filepath.Join(dir, "empty.go")
+	edited := filepath.Join(dir, "edited.go")
+	unchanged := filepath.Join(dir, "unchanged.go")
+	for path, content := range map[string]string{
+		created:   "package created\n",
+		empty:     "package empty\n",
+		edited:    "package edited\n\nfunc f() {}\n",
+		unchanged: "package unchanged\n",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	files := []File{
+		{ID: "unchanged-0", Path: unchanged, Content: "package unchanged\n"},
+		{ID: "edited-0", Path: edited, Content: "package edited\n"},
+		{ID: "edited-1", Path: edited, Content: "package edited\n\nfunc f() {}\n", Version: 1},
+		{ID: "created-0", Path: created, IsNew: true},
+		{ID: "created-1", Path: created, Content: "package created\n", Version: 1},
+		{ID: "empty-0", Path: empty},
+		{ID: "empty-1", Path: empty, Content: "package empty\n", Version: 1},
+	}
+	// The checkpoint was taken after the first version of unchanged.go and
+	// edited.go, the other files were changed after it.
+	checkpoint := Checkpoint{Files: map[string]string{
+		unchanged: "unchanged-0",
+		edited:    "edited-0",
+	}}
+
+	changes, err := checkpointChanges(checkpoint, files)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: created, Before: "package created\n", Deleted: true},
+		{Path: edited, Before: "package edited\n\nfunc f() {}\n", After: "package edited\n"},
+		{Path: empty, Before: "package empty\n", After: ""},
+	}, changes, "files that existed empty are emptied, not deleted")
+}
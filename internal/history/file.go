@@ -23,11 +23,15 @@ type File struct {
 	Version   int64
 	CreatedAt int64
 	UpdatedAt int64
+	// IsNew is set on the initial version of a file that didn't exist
+	// before the session created it.
+	IsNew bool
 }
 
 type Service interface {
 	pubsub.Suscriber[File]
 	Create(ctx context.Context, sessionID, path, content string) (File, error)
+	CreateNew(ctx context.Context, sessionID, path string) (File, error)
 	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
 	Get(ctx context.Context, id string) (File, error)
 	GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error)
@@ -35,6 +39,11 @@ type Service interface {
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
+
+	CreateCheckpoint(ctx context.Context, sessionID, messageID string) (Checkpoint, error)
+	ListCheckpoints(ctx context.Context, sessionID string) ([]Checkpoint, error)
+	PreviewCheckpoint(ctx context.Context, checkpointID string) ([]FileChange, error)
+	RestoreCheckpoint(ctx context.Context, checkpointID string) ([]FileChange, error)
 }
 
 type service struct {
@@ -52,7 +61,13 @@ func NewService(q *db.Queries, db *sql.DB) Service {
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, false)
+}
+
+// CreateNew records the initial version of a file that didn't exist before
+// the session created it, so that rewinding past it deletes the file.
+func (s *service) CreateNew(ctx context.Context, sessionID, path string) (File, error) {
+	return s.createWithVersion(ctx, sessionID, path, "", InitialVersion, true)
 }
 
 func (s *service) CreateVersion(ctx context.Context, sessionID, path, content string) (File, error) {
@@ -71,10 +86,10 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 	latestFile := files[0] // Files are ordered by version DESC, created_at DESC
 	nextVersion := latestFile.Version + 1
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, nextVersion, false)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64, isNew bool) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
 	var file File
@@ -98,6 +113,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			IsNew:     isNew,
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -206,5 +222,6 @@ func (s *service) fromDBItem(item db.File) File {
 		Version:   item.Version,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		IsNew:     item.IsNew,
 	}
 }
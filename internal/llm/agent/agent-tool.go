@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/vikvang/zero/internal/config"
 	"github.com/vikvang/zero/internal/llm/tools"
 	"github.com/vikvang/zero/internal/message"
 	"github.com/vikvang/zero/internal/session"
 )
 
 type agentTool struct {
+	agentCfg config.Agent
 	agent    Service
 	sessions session.Service
 	messages message.Service
@@ -18,20 +22,73 @@ type agentTool struct {
 
 const (
 	AgentToolName = "agent"
+
+	// SubAgentToolPrefix prefixes the names of the tools delegating to the
+	// agents of the configuration, e.g. agent_researcher.
+	SubAgentToolPrefix = "agent_"
+
+	taskAgentDescription = "Launch a new agent that has access to the following tools: GlobTool, GrepTool, LS, View. When you are searching for a keyword or file and are not confident that you will find the right match on the first try, use the Agent tool to perform the search for you. For example:\n\n- If you are searching for a keyword like \"config\" or \"logger\", or for questions like \"which file does X?\", the Agent tool is strongly recommended\n- If you want to read a specific file path, use the View or GlobTool tool instead of the Agent tool, to find the match more quickly\n- If you are searching for a specific class definition like \"class Foo\", use the GlobTool tool instead, to find the match more quickly\n\nUsage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted\n5. IMPORTANT: The agent can not use Bash, Replace, Edit, so can not modify files. If you want to use these tools, use them directly instead of going through the agent."
+
+	subAgentUsageNotes = "Usage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted"
 )
 
+// concurrencySafeTools are the tools that don't change any state, so agents
+// that can only use them can run in parallel.
+var concurrencySafeTools = []string{
+	tools.DiagnosticsToolName,
+	tools.GlobToolName,
+	tools.GrepToolName,
+	tools.LSToolName,
+	tools.LSPCallHierarchyToolName,
+	tools.LSPDefinitionToolName,
+	tools.LSPHoverToolName,
+	tools.LSPReferencesToolName,
+	tools.LSPSymbolsToolName,
+	tools.SourcegraphToolName,
+	tools.ViewToolName,
+}
+
 type AgentParams struct {
 	Prompt string `json:"prompt"`
 }
 
+type AgentResponseMetadata struct {
+	AgentID string  `json:"agent_id"`
+	Cost    float64 `json:"cost"`
+}
+
+// SubAgentToolName returns the name of the tool delegating to the agent. The
+// task agent keeps the name of the original agent tool.
+func SubAgentToolName(agentID string) string {
+	if agentID == "task" {
+		return AgentToolName
+	}
+	return SubAgentToolPrefix + agentID
+}
+
+// SubAgentID returns the ID of the agent the tool delegates to, if it's an
+// agent tool.
+func SubAgentID(toolName string) (string, bool) {
+	if toolName == AgentToolName {
+		return "task", true
+	}
+	return strings.CutPrefix(toolName, SubAgentToolPrefix)
+}
+
+// IsAgentToolName reports whether the tool delegates to an agent.
+func IsAgentToolName(toolName string) bool {
+	_, ok := SubAgentID(toolName)
+	return ok
+}
+
 func (b *agentTool) Name() string {
-	return AgentToolName
+	return SubAgentToolName(b.agentCfg.ID)
 }
 
 func (b *agentTool) Info() tools.ToolInfo {
 	return tools.ToolInfo{
-		Name:        AgentToolName,
-		Description: "Launch a new agent that has access to the following tools: GlobTool, GrepTool, LS, View. When you are searching for a keyword or file and are not confident that you will find the right match on the first try, use the Agent tool to perform the search for you. For example:\n\n- If you are searching for a keyword like \"config\" or \"logger\", or for questions like \"which file does X?\", the Agent tool is strongly recommended\n- If you want to read a specific file path, use the View or GlobTool tool instead of the Agent tool, to find the match more quickly\n- If you are searching for a specific class definition like \"class Foo\", use the GlobTool tool instead, to find the match more quickly\n\nUsage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted\n5. IMPORTANT: The agent can not use Bash, Replace, Edit, so can not modify files. If you want to use these tools, use them directly instead of going through the agent.",
+		Name:        b.Name(),
+		Description: b.description(),
 		Parameters: map[string]any{
 			"prompt": map[string]any{
 				"type":        "string",
@@ -42,6 +99,42 @@ func (b *agentTool) Info() tools.ToolInfo {
 	}
 }
 
+func (b *agentTool) description() string {
+	if b.agentCfg.ID == "task" {
+		return taskAgentDescription
+	}
+	var description strings.Builder
+	fmt.Fprintf(&description, "Launch the %s agent", b.agentCfg.Name)
+	if b.agentCfg.Description != "" {
+		fmt.Fprintf(&description, ": %s", strings.TrimSuffix(b.agentCfg.Description, "."))
+	}
+	description.WriteString(".\n\n")
+	if b.agentCfg.AllowedTools != nil {
+		fmt.Fprintf(&description, "The agent has access to the following tools: %s.", strings.Join(b.agentCfg.AllowedTools, ", "))
+	} else {
+		description.WriteString("The agent has access to the same tools as you, except the agent tools.")
+	}
+	description.WriteString("\n\n")
+	description.WriteString(subAgentUsageNotes)
+	return description.String()
+}
+
+// IsConcurrencySafe reports whether the call can run in parallel, which is
+// the case when the agent can only use tools that don't change any state.
+// Agents that can use all the tools or MCP tools could e.g. run commands or
+// edit the same files at the same time.
+func (b *agentTool) IsConcurrencySafe(tools.ToolCall) bool {
+	if b.agentCfg.AllowedTools == nil || len(b.agentCfg.AllowedMCP) > 0 {
+		return false
+	}
+	for _, name := range b.agentCfg.AllowedTools {
+		if !slices.Contains(concurrencySafeTools, name) {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
 	var params AgentParams
 	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
@@ -56,7 +149,7 @@ func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolRes
 		return tools.ToolResponse{}, fmt.Errorf("session_id and message_id are required")
 	}
 
-	session, err := b.sessions.CreateTaskSession(ctx, call.ID, sessionID, "New Agent Session")
+	session, err := b.sessions.CreateTaskSession(ctx, call.ID, sessionID, fmt.Sprintf("New %s Agent Session", b.agentCfg.Name))
 	if err != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error creating session: %s", err)
 	}
@@ -79,26 +172,32 @@ func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolRes
 	if err != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error getting session: %s", err)
 	}
-	parentSession, err := b.sessions.Get(ctx, sessionID)
-	if err != nil {
-		return tools.ToolResponse{}, fmt.Errorf("error getting parent session: %s", err)
-	}
-
-	parentSession.Cost += updatedSession.Cost
-
-	_, err = b.sessions.Save(ctx, parentSession)
-	if err != nil {
+	// Several agents may run at the same time, so their cost is added to the
+	// parent session in a single update.
+	if _, err := b.sessions.AddCost(ctx, sessionID, updatedSession.Cost); err != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
 	}
-	return tools.NewTextResponse(response.Content().String()), nil
+	toolResponse := tools.NewTextResponse(response.Content().String())
+	if err := BudgetError(response); err != nil {
+		toolResponse = tools.NewTextErrorResponse(err.Error())
+	}
+	return tools.WithResponseMetadata(
+		toolResponse,
+		AgentResponseMetadata{
+			AgentID: b.agentCfg.ID,
+			Cost:    updatedSession.Cost,
+		},
+	), nil
 }
 
 func NewAgentTool(
+	agentCfg config.Agent,
 	agent Service,
 	sessions session.Service,
 	messages message.Service,
 ) tools.BaseTool {
 	return &agentTool{
+		agentCfg: agentCfg,
 		sessions: sessions,
 		messages: messages,
 		agent:    agent,
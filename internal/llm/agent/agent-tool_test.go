@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/session"
+)
+
+// fakeSessions keeps the sessions in memory. Save isn't implemented, so the
+// agent tool can only change the parent session with AddCost.
+type fakeSessions struct {
+	session.Service
+	mu       sync.Mutex
+	sessions map[string]session.Session
+}
+
+func (f *fakeSessions) CreateTaskSession(_ context.Context, toolCallID, parentSessionID, title string) (session.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := session.Session{ID: toolCallID, ParentSessionID: parentSessionID, Title: title}
+	f.sessions[s.ID] = s
+	return s, nil
+}
+
+func (f *fakeSessions) Get(_ context.Context, id string) (session.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sessions[id]
+	if !ok {
+		return session.Session{}, fmt.Errorf("session %s not found", id)
+	}
+	return s, nil
+}
+
+func (f *fakeSessions) AddCost(_ context.Context, id string, cost float64) (session.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.sessions[id]
+	s.Cost += cost
+	f.sessions[id] = s
+	return s, nil
+}
+
+// fakeSubAgent answers with the prompt, at the given cost.
+type fakeSubAgent struct {
+	Service
+	sessions *fakeSessions
+	cost     float64
+}
+
+func (f *fakeSubAgent) Run(ctx context.Context, sessionID string, content string, _ ...message.Attachment) (<-chan AgentEvent, error) {
+	if _, err := f.sessions.AddCost(ctx, sessionID, f.cost); err != nil {
+		return nil, err
+	}
+	done := make(chan AgentEvent, 1)
+	done <- AgentEvent{
+		Type: AgentEventTypeResponse,
+		Message: message.Message{
+			Role:  message.Assistant,
+			Parts: []message.ContentPart{message.TextContent{Text: "done: " + content}},
+		},
+	}
+	return done, nil
+}
+
+func TestAgentToolRollsUpCost(t *testing.T) {
+	t.Parallel()
+
+	sessions := &fakeSessions{sessions: map[string]session.Session{
+		"parent": {ID: "parent", Cost: 1},
+	}}
+	tool := NewAgentTool(
+		config.Agent{ID: "researcher", Name: "Researcher", AllowedTools: []string{"fetch"}},
+		&fakeSubAgent{sessions: sessions, cost: 0.25},
+		sessions,
+		nil,
+	)
+	require.Equal(t, "agent_researcher", tool.Name())
+
+	ctx := context.WithValue(context.Background(), tools.SessionIDContextKey, "parent")
+	ctx = context.WithValue(ctx, tools.MessageIDContextKey, "message")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := tool.Run(ctx, tools.ToolCall{
+				ID:    fmt.Sprintf("call-%d", i),
+				Name:  tool.Name(),
+				Input: fmt.Sprintf(`{"prompt": "task %d"}`, i),
+			})
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("done: task %d", i), response.Content)
+			require.JSONEq(t, `{"agent_id": "researcher", "cost": 0.25}`, response.Metadata)
+		}()
+	}
+	wg.Wait()
+
+	parent, err := sessions.Get(ctx, "parent")
+	require.NoError(t, err)
+	require.InDelta(t, 3, parent.Cost, 1e-9)
+
+	child, err := sessions.Get(ctx, "call-0")
+	require.NoError(t, err)
+	require.Equal(t, "parent", child.ParentSessionID)
+	require.Equal(t, "New Researcher Agent Session", child.Title)
+}
+
+func TestAgentToolIsConcurrencySafe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		agentCfg config.Agent
+		want     bool
+	}{
+		{"read-only tools", config.Agent{ID: "searcher", AllowedTools: []string{"glob", "grep", "view"}, AllowedMCP: map[string][]string{}}, true},
+		{"no tools", config.Agent{ID: "thinker", AllowedTools: []string{}}, true},
+		{"bash", config.Agent{ID: "runner", AllowedTools: []string{"view", "bash"}}, false},
+		{"fetch", config.Agent{ID: "researcher", AllowedTools: []string{"fetch"}}, false},
+		{"all tools", config.Agent{ID: "coder"}, false},
+		{"mcp tools", config.Agent{ID: "searcher", AllowedTools: []string{"grep"}, AllowedMCP: map[string][]string{"github": nil}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tool := NewAgentTool(tt.agentCfg, nil, nil, nil)
+			require.Equal(t, tt.want, tools.IsConcurrencySafe(tool, tools.ToolCall{}))
+		})
+	}
+}
+
+func TestSubAgentToolName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, AgentToolName, SubAgentToolName("task"))
+	require.Equal(t, "agent_test-runner", SubAgentToolName("test-runner"))
+
+	id, ok := SubAgentID("agent_test-runner")
+	require.True(t, ok)
+	require.Equal(t, "test-runner", id)
+
+	id, ok = SubAgentID(AgentToolName)
+	require.True(t, ok)
+	require.Equal(t, "task", id)
+
+	require.False(t, IsAgentToolName("bash"))
+}
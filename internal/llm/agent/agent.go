@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
@@ -25,10 +27,15 @@ import (
 	"github.com/vikvang/zero/internal/shell"
 )
 
+// maxConcurrentToolCalls limits how many concurrency-safe tool calls from a
+// single assistant turn can run at the same time.
+const maxConcurrentToolCalls = 8
+
 // Common errors
 var (
 	ErrRequestCancelled = errors.New("request canceled by user")
 	ErrSessionBusy      = errors.New("session is currently processing another request")
+	ErrBudgetExceeded   = errors.New("budget exceeded")
 )
 
 type AgentEventType string
@@ -37,6 +44,7 @@ const (
 	AgentEventTypeError     AgentEventType = "error"
 	AgentEventTypeResponse  AgentEventType = "response"
 	AgentEventTypeSummarize AgentEventType = "summarize"
+	AgentEventTypeWarning   AgentEventType = "warning"
 )
 
 type AgentEvent struct {
@@ -48,6 +56,9 @@ type AgentEvent struct {
 	SessionID string
 	Progress  string
 	Done      bool
+
+	// When falling back to another model or a budget is nearly used
+	Warning string
 }
 
 type Service interface {
@@ -69,12 +80,14 @@ type agent struct {
 	agentCfg config.Agent
 	sessions session.Service
 	messages message.Service
+	history  history.Service
 	mcpTools []McpTool
 
 	tools *csync.LazySlice[tools.BaseTool]
 
 	provider   provider.Provider
 	providerID string
+	fallbacks  []chainModel
 
 	titleProvider       provider.Provider
 	summarizeProvider   provider.Provider
@@ -90,6 +103,36 @@ var agentPromptMap = map[string]prompt.PromptID{
 	"task":  prompt.PromptTask,
 }
 
+// systemPrompt returns the system prompt of the agent: the one of its prompt
+// file, its built-in one, or the coder prompt for user-defined agents.
+func systemPrompt(agentCfg config.Agent, providerID string) (string, error) {
+	if agentCfg.Prompt != "" {
+		return prompt.CustomPrompt(agentCfg.Prompt, agentCfg.ContextPaths...)
+	}
+	promptID, ok := agentPromptMap[agentCfg.ID]
+	if !ok {
+		promptID = prompt.PromptCoder
+	}
+	return prompt.GetPrompt(promptID, providerID, agentCfg.ContextPaths...), nil
+}
+
+// allowedLSPClients returns the LSP clients the agent can use. The clients
+// started later are only available to the agents that can use all of them.
+func allowedLSPClients(agentCfg config.Agent, lspClients map[string]*lsp.Client) map[string]*lsp.Client {
+	if agentCfg.AllowedLSP == nil {
+		return lspClients
+	}
+	allowed := make(map[string]*lsp.Client, len(agentCfg.AllowedLSP))
+	for name, client := range lspClients {
+		if slices.Contains(agentCfg.AllowedLSP, name) {
+			allowed[name] = client
+		}
+	}
+	return allowed
+}
+
+// NewAgent creates the primary agent, which can delegate to the other
+// agents of the configuration through the agent tools.
 func NewAgent(
 	ctx context.Context,
 	agentCfg config.Agent,
@@ -100,21 +143,45 @@ func NewAgent(
 	history history.Service,
 	lspClients map[string]*lsp.Client,
 ) (Service, error) {
-	cfg := config.Get()
-
-	var agentTool tools.BaseTool
-	if agentCfg.ID == "coder" {
-		taskAgentCfg := config.Get().Agents["task"]
-		if taskAgentCfg.ID == "" {
-			return nil, fmt.Errorf("task agent not found in config")
+	var agentTools []tools.BaseTool
+	for _, subAgentCfg := range config.Get().SubAgents(agentCfg.ID) {
+		if agentCfg.AllowedTools != nil && !slices.Contains(agentCfg.AllowedTools, SubAgentToolName(subAgentCfg.ID)) {
+			continue
 		}
-		taskAgent, err := NewAgent(ctx, taskAgentCfg, permissions, sessions, messages, history, lspClients)
+		subAgent, err := NewSubAgent(ctx, subAgentCfg, permissions, sessions, messages, history, lspClients)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create task agent: %w", err)
+			return nil, fmt.Errorf("failed to create %s agent: %w", subAgentCfg.ID, err)
 		}
-
-		agentTool = NewAgentTool(taskAgent, sessions, messages)
+		agentTools = append(agentTools, NewAgentTool(subAgentCfg, subAgent, sessions, messages))
 	}
+	return newAgent(ctx, agentCfg, agentTools, permissions, sessions, messages, history, lspClients)
+}
+
+// NewSubAgent creates an agent that can't delegate to other agents, to run
+// the tasks of the primary agent.
+func NewSubAgent(
+	ctx context.Context,
+	agentCfg config.Agent,
+	permissions permission.Service,
+	sessions session.Service,
+	messages message.Service,
+	history history.Service,
+	lspClients map[string]*lsp.Client,
+) (Service, error) {
+	return newAgent(ctx, agentCfg, nil, permissions, sessions, messages, history, lspClients)
+}
+
+func newAgent(
+	ctx context.Context,
+	agentCfg config.Agent,
+	agentTools []tools.BaseTool,
+	permissions permission.Service,
+	sessions session.Service,
+	messages message.Service,
+	history history.Service,
+	lspClients map[string]*lsp.Client,
+) (Service, error) {
+	cfg := config.Get()
 
 	providerCfg := config.Get().GetProviderForModel(agentCfg.Model)
 	if providerCfg == nil {
@@ -126,18 +193,22 @@ func NewAgent(
 		return nil, fmt.Errorf("model not found for agent %s", agentCfg.Name)
 	}
 
-	promptID := agentPromptMap[agentCfg.ID]
-	if promptID == "" {
-		promptID = prompt.PromptDefault
+	systemMessage, err := systemPrompt(agentCfg, providerCfg.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create system prompt for agent %s: %w", agentCfg.Name, err)
 	}
 	opts := []provider.ProviderClientOption{
 		provider.WithModel(agentCfg.Model),
-		provider.WithSystemMessage(prompt.GetPrompt(promptID, providerCfg.ID, config.Get().Options.ContextPaths...)),
+		provider.WithSystemMessage(systemMessage),
 	}
 	agentProvider, err := provider.NewProvider(*providerCfg, opts...)
 	if err != nil {
 		return nil, err
 	}
+	fallbacks, err := newFallbackModels(agentCfg)
+	if err != nil {
+		return nil, err
+	}
 
 	smallModelCfg := cfg.Models[config.SelectedModelTypeSmall]
 	var smallModelProviderCfg *config.ProviderConfig
@@ -180,6 +251,7 @@ func NewAgent(
 		}()
 
 		cwd := cfg.WorkingDir()
+		lspClients := allowedLSPClients(agentCfg, lspClients)
 		allTools := []tools.BaseTool{
 			tools.NewBashTool(permissions, cwd),
 			tools.NewDownloadTool(permissions, cwd),
@@ -188,36 +260,33 @@ func NewAgent(
 			tools.NewFetchTool(permissions, cwd),
 			tools.NewGlobTool(cwd),
 			tools.NewGrepTool(cwd),
+			tools.NewJobKillTool(),
+			tools.NewJobOutputTool(),
 			tools.NewLsTool(permissions, cwd),
 			tools.NewSourcegraphTool(),
 			tools.NewViewTool(lspClients, permissions, cwd),
 			tools.NewWriteTool(lspClients, permissions, history, cwd),
 		}
 
+		// MCP tools are added on each request since they change as
+		// servers come and go, see allTools.
 		mcpToolsOnce.Do(func() {
-			mcpTools = doGetMCPTools(ctx, permissions, cfg)
+			startMCPClients(ctx, permissions, cfg)
 		})
-		allTools = append(allTools, mcpTools...)
 
 		if len(lspClients) > 0 {
-			allTools = append(allTools, tools.NewDiagnosticsTool(lspClients))
-		}
-
-		if agentTool != nil {
-			allTools = append(allTools, agentTool)
-		}
-
-		if agentCfg.AllowedTools == nil {
-			return allTools
+			allTools = append(allTools,
+				tools.NewDiagnosticsTool(lspClients),
+				tools.NewLSPDefinitionTool(lspClients, cwd),
+				tools.NewLSPReferencesTool(lspClients, cwd),
+				tools.NewLSPSymbolsTool(lspClients, cwd),
+				tools.NewLSPHoverTool(lspClients, cwd),
+				tools.NewLSPCallHierarchyTool(lspClients, cwd),
+				tools.NewLSPRefactorTool(lspClients, permissions, history, cwd),
+			)
 		}
 
-		var filteredTools []tools.BaseTool
-		for _, tool := range allTools {
-			if slices.Contains(agentCfg.AllowedTools, tool.Name()) {
-				filteredTools = append(filteredTools, tool)
-			}
-		}
-		return filteredTools
+		return append(allTools, agentTools...)
 	}
 
 	return &agent{
@@ -225,8 +294,10 @@ func NewAgent(
 		agentCfg:            agentCfg,
 		provider:            agentProvider,
 		providerID:          string(providerCfg.ID),
+		fallbacks:           fallbacks,
 		messages:            messages,
 		sessions:            sessions,
+		history:             history,
 		titleProvider:       titleProvider,
 		summarizeProvider:   summarizeProvider,
 		summarizeProviderID: string(providerCfg.ID),
@@ -341,7 +412,10 @@ func (a *agent) err(err error) AgentEvent {
 
 func (a *agent) Run(ctx context.Context, sessionID string, content string, attachments ...message.Attachment) (<-chan AgentEvent, error) {
 	if !a.Model().SupportsImages && attachments != nil {
-		attachments = nil
+		// Text attachments are sent as part of the prompt.
+		attachments = slices.DeleteFunc(attachments, func(attachment message.Attachment) bool {
+			return !attachment.IsText()
+		})
 	}
 	events := make(chan AgentEvent)
 	if a.IsSessionBusy(sessionID) {
@@ -423,9 +497,16 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	if err != nil {
 		return a.err(fmt.Errorf("failed to create user message: %w", err))
 	}
+	// Record the state of the files before the turn so it can be rewound.
+	if session.ParentSessionID == "" {
+		if _, err := a.history.CreateCheckpoint(ctx, sessionID, userMsg.ID); err != nil {
+			slog.Error("Failed to create checkpoint", "session_id", sessionID, "error", err)
+		}
+	}
 	// Append the new user message to the conversation history.
 	msgHistory := append(msgs, userMsg)
 
+	budgets := newBudget(*cfg.Options.Budget, session)
 	for {
 		// Check for cancellation before each iteration
 		select {
@@ -434,6 +515,13 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 		default:
 			// Continue processing
 		}
+		exceeded, err := a.checkBudget(ctx, sessionID, budgets)
+		if err != nil {
+			return a.err(fmt.Errorf("failed to check budget: %w", err))
+		}
+		if exceeded != "" {
+			return a.stopForBudget(ctx, sessionID, exceeded)
+		}
 		agentMessage, toolResults, err := a.streamAndHandleEvents(ctx, sessionID, msgHistory)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
@@ -518,143 +606,246 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 	}
 
 	// Now collect tools (which may block on MCP initialization)
-	eventChan := a.provider.StreamResponse(ctx, msgHistory, slices.Collect(a.tools.Seq()))
+	agentTools := a.allTools()
 
 	// Add the session and message ID into the context if needed by tools.
 	ctx = context.WithValue(ctx, tools.MessageIDContextKey, assistantMsg.ID)
 
-	// Process each event in the stream.
-	for event := range eventChan {
-		if processErr := a.processEvent(ctx, sessionID, &assistantMsg, event); processErr != nil {
-			if errors.Is(processErr, context.Canceled) {
-				a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
-			} else {
-				a.finishMessage(ctx, &assistantMsg, message.FinishReasonError, "API Error", processErr.Error())
-			}
-			return assistantMsg, nil, processErr
-		}
-		if ctx.Err() != nil {
+	if err := a.streamResponse(ctx, sessionID, &assistantMsg, a.modelChain(), msgHistory, agentTools); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
-			return assistantMsg, nil, ctx.Err()
+		} else {
+			a.finishMessage(ctx, &assistantMsg, message.FinishReasonError, "API Error", err.Error())
 		}
+		return assistantMsg, nil, err
 	}
 
-	toolResults := make([]message.ToolResult, len(assistantMsg.ToolCalls()))
 	toolCalls := assistantMsg.ToolCalls()
-	for i, toolCall := range toolCalls {
-		select {
-		case <-ctx.Done():
-			a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
-			// Make all future tool calls cancelled
-			for j := i; j < len(toolCalls); j++ {
-				toolResults[j] = message.ToolResult{
-					ToolCallID: toolCalls[j].ID,
+	toolResults, finishReason := a.executeToolCalls(ctx, toolCalls)
+	switch finishReason {
+	case message.FinishReasonCanceled:
+		a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
+	case message.FinishReasonPermissionDenied:
+		a.finishMessage(ctx, &assistantMsg, message.FinishReasonPermissionDenied, "Permission denied", "")
+	}
+
+	if len(toolResults) == 0 {
+		return assistantMsg, nil, nil
+	}
+	parts := make([]message.ContentPart, 0)
+	for _, tr := range toolResults {
+		parts = append(parts, tr)
+	}
+	msg, err := a.messages.Create(context.Background(), assistantMsg.SessionID, message.CreateMessageParams{
+		Role:     message.Tool,
+		Parts:    parts,
+		Provider: assistantMsg.Provider,
+	})
+	if err != nil {
+		return assistantMsg, nil, fmt.Errorf("failed to create cancelled tool message: %w", err)
+	}
+
+	return assistantMsg, &msg, err
+}
+
+// executeToolCalls runs the tool calls of an assistant message and returns
+// their results in the same order. Consecutive concurrency-safe tool calls are
+// batched together and run in parallel, everything else runs on its own.
+//
+// If the request is cancelled or a permission is denied, the remaining tool
+// calls are not started and the matching finish reason is returned.
+func (a *agent) executeToolCalls(ctx context.Context, toolCalls []message.ToolCall) ([]message.ToolResult, message.FinishReason) {
+	toolResults := make([]message.ToolResult, len(toolCalls))
+	for start := 0; start < len(toolCalls); {
+		end := start + 1
+		if a.isConcurrencySafe(toolCalls[start]) {
+			for end < len(toolCalls) && a.isConcurrencySafe(toolCalls[end]) {
+				end++
+			}
+		}
+
+		execResults, ok := a.runToolCalls(ctx, toolCalls[start:end])
+		if !ok {
+			// Make all remaining tool calls cancelled
+			cancelToolCalls(toolResults, toolCalls, start)
+			return toolResults, message.FinishReasonCanceled
+		}
+
+		denied := false
+		for j, result := range execResults {
+			i := start + j
+			toolCall := toolCalls[i]
+			switch {
+			case result.skipped:
+				toolResults[i] = message.ToolResult{
+					ToolCallID: toolCall.ID,
 					Content:    "Tool execution canceled by user",
 					IsError:    true,
 				}
-			}
-			goto out
-		default:
-			// Continue processing
-			var tool tools.BaseTool
-			for availableTool := range a.tools.Seq() {
-				if availableTool.Info().Name == toolCall.Name {
-					tool = availableTool
-					break
-				}
-			}
-
-			// Tool not found
-			if tool == nil {
+			case errors.Is(result.err, permission.ErrorPermissionDenied):
+				slog.Error("Tool execution error", "toolCall", toolCall.ID, "error", result.err)
 				toolResults[i] = message.ToolResult{
 					ToolCallID: toolCall.ID,
-					Content:    fmt.Sprintf("Tool not found: %s", toolCall.Name),
+					Content:    "Permission denied",
 					IsError:    true,
 				}
-				continue
-			}
-
-			// Run tool in goroutine to allow cancellation
-			type toolExecResult struct {
-				response tools.ToolResponse
-				err      error
+				denied = true
+			default:
+				if result.err != nil {
+					slog.Error("Tool execution error", "toolCall", toolCall.ID, "error", result.err)
+				}
+				toolResults[i] = message.ToolResult{
+					ToolCallID: toolCall.ID,
+					Content:    result.response.Content,
+					Metadata:   result.response.Metadata,
+					IsError:    result.response.IsError,
+				}
 			}
-			resultChan := make(chan toolExecResult, 1)
+		}
+		if denied {
+			cancelToolCalls(toolResults, toolCalls, end)
+			return toolResults, message.FinishReasonPermissionDenied
+		}
+		start = end
+	}
+	return toolResults, ""
+}
 
-			go func() {
-				response, err := tool.Run(ctx, tools.ToolCall{
-					ID:    toolCall.ID,
-					Name:  toolCall.Name,
-					Input: toolCall.Input,
-				})
-				resultChan <- toolExecResult{response: response, err: err}
-			}()
+type toolExecResult struct {
+	response tools.ToolResponse
+	err      error
+	// skipped is set when the tool call was never started because another
+	// call of the same batch was denied permission.
+	skipped bool
+}
 
-			var toolResponse tools.ToolResponse
-			var toolErr error
+// runToolCalls runs the given tool calls, concurrently if there is more than
+// one, and returns their results in the same order. It returns false if the
+// context is cancelled before all of them finish.
+func (a *agent) runToolCalls(ctx context.Context, toolCalls []message.ToolCall) ([]toolExecResult, bool) {
+	select {
+	case <-ctx.Done():
+		return nil, false
+	default:
+		// Continue processing
+	}
 
+	results := make([]toolExecResult, len(toolCalls))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var (
+			wg     sync.WaitGroup
+			denied atomic.Bool
+		)
+		sem := make(chan struct{}, maxConcurrentToolCalls)
+		for i, toolCall := range toolCalls {
 			select {
 			case <-ctx.Done():
-				a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
-				// Mark remaining tool calls as cancelled
+			case sem <- struct{}{}:
+			}
+			if ctx.Err() != nil || denied.Load() {
 				for j := i; j < len(toolCalls); j++ {
-					toolResults[j] = message.ToolResult{
-						ToolCallID: toolCalls[j].ID,
-						Content:    "Tool execution canceled by user",
-						IsError:    true,
-					}
+					results[j].skipped = true
 				}
-				goto out
-			case result := <-resultChan:
-				toolResponse = result.response
-				toolErr = result.err
+				break
 			}
-
-			if toolErr != nil {
-				slog.Error("Tool execution error", "toolCall", toolCall.ID, "error", toolErr)
-				if errors.Is(toolErr, permission.ErrorPermissionDenied) {
-					toolResults[i] = message.ToolResult{
-						ToolCallID: toolCall.ID,
-						Content:    "Permission denied",
-						IsError:    true,
-					}
-					for j := i + 1; j < len(toolCalls); j++ {
-						toolResults[j] = message.ToolResult{
-							ToolCallID: toolCalls[j].ID,
-							Content:    "Tool execution canceled by user",
-							IsError:    true,
-						}
-					}
-					a.finishMessage(ctx, &assistantMsg, message.FinishReasonPermissionDenied, "Permission denied", "")
-					break
+			wg.Go(func() {
+				defer func() { <-sem }()
+				result := a.runToolCall(ctx, toolCall)
+				if errors.Is(result.err, permission.ErrorPermissionDenied) {
+					denied.Store(true)
 				}
-			}
-			toolResults[i] = message.ToolResult{
-				ToolCallID: toolCall.ID,
-				Content:    toolResponse.Content,
-				Metadata:   toolResponse.Metadata,
-				IsError:    toolResponse.IsError,
-			}
+				results[i] = result
+			})
 		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case <-done:
+		return results, true
 	}
-out:
-	if len(toolResults) == 0 {
-		return assistantMsg, nil, nil
+}
+
+func (a *agent) runToolCall(ctx context.Context, toolCall message.ToolCall) toolExecResult {
+	tool := a.getTool(toolCall.Name)
+	if tool == nil {
+		return toolExecResult{
+			response: tools.NewTextErrorResponse(fmt.Sprintf("Tool not found: %s", toolCall.Name)),
+		}
 	}
-	parts := make([]message.ContentPart, 0)
-	for _, tr := range toolResults {
-		parts = append(parts, tr)
+	response, err := tool.Run(ctx, tools.ToolCall{
+		ID:    toolCall.ID,
+		Name:  toolCall.Name,
+		Input: toolCall.Input,
+	})
+	return toolExecResult{response: response, err: err}
+}
+
+// isConcurrencySafe reports whether the tool call may run in parallel with
+// other concurrency-safe tool calls.
+func (a *agent) isConcurrencySafe(toolCall message.ToolCall) bool {
+	tool := a.getTool(toolCall.Name)
+	if tool == nil {
+		return false
 	}
-	msg, err := a.messages.Create(context.Background(), assistantMsg.SessionID, message.CreateMessageParams{
-		Role:     message.Tool,
-		Parts:    parts,
-		Provider: a.providerID,
+	return tools.IsConcurrencySafe(tool, tools.ToolCall{
+		ID:    toolCall.ID,
+		Name:  toolCall.Name,
+		Input: toolCall.Input,
 	})
-	if err != nil {
-		return assistantMsg, nil, fmt.Errorf("failed to create cancelled tool message: %w", err)
+}
+
+func (a *agent) getTool(name string) tools.BaseTool {
+	for _, tool := range a.allTools() {
+		if tool.Info().Name == name {
+			return tool
+		}
 	}
+	return nil
+}
 
-	return assistantMsg, &msg, err
+// allTools returns the tools available to the agent, with the current tools
+// of the MCP servers, filtered by the allowed tools of the agent.
+func (a *agent) allTools() []tools.BaseTool {
+	return filterTools(a.agentCfg, append(slices.Collect(a.tools.Seq()), GetMCPTools()...))
+}
+
+// filterTools returns the tools the agent is allowed to use. The tools of
+// the MCP servers are allowed by the MCP servers of the agent when it has
+// any, and by its allowed tools otherwise.
+func filterTools(agentCfg config.Agent, allTools []tools.BaseTool) []tools.BaseTool {
+	if agentCfg.AllowedTools == nil && agentCfg.AllowedMCP == nil {
+		return allTools
+	}
+
+	var filteredTools []tools.BaseTool
+	for _, tool := range allTools {
+		if mcpTool, ok := tool.(*McpTool); ok && agentCfg.AllowedMCP != nil {
+			mcpToolNames, ok := agentCfg.AllowedMCP[mcpTool.mcpName]
+			if ok && (mcpToolNames == nil || slices.Contains(mcpToolNames, mcpTool.tool.Name)) {
+				filteredTools = append(filteredTools, tool)
+			}
+			continue
+		}
+		if agentCfg.AllowedTools == nil || slices.Contains(agentCfg.AllowedTools, tool.Name()) {
+			filteredTools = append(filteredTools, tool)
+		}
+	}
+	return filteredTools
+}
+
+func cancelToolCalls(toolResults []message.ToolResult, toolCalls []message.ToolCall, from int) {
+	for i := from; i < len(toolCalls); i++ {
+		toolResults[i] = message.ToolResult{
+			ToolCallID: toolCalls[i].ID,
+			Content:    "Tool execution canceled by user",
+			IsError:    true,
+		}
+	}
 }
 
 func (a *agent) finishMessage(ctx context.Context, msg *message.Message, finishReason message.FinishReason, message, details string) {
@@ -662,7 +853,7 @@ func (a *agent) finishMessage(ctx context.Context, msg *message.Message, finishR
 	_ = a.messages.Update(ctx, *msg)
 }
 
-func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg *message.Message, event provider.ProviderEvent) error {
+func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg *message.Message, model catwalk.Model, event provider.ProviderEvent) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -699,27 +890,31 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 		assistantMsg.FinishThinking()
 		assistantMsg.SetToolCalls(event.Response.ToolCalls)
 		assistantMsg.AddFinish(event.Response.FinishReason, "", "")
+		assistantMsg.Cost += usageCost(model, event.Response.Usage)
+		assistantMsg.PromptTokens += event.Response.Usage.InputTokens + event.Response.Usage.CacheCreationTokens
+		assistantMsg.CompletionTokens += event.Response.Usage.OutputTokens + event.Response.Usage.CacheReadTokens
 		if err := a.messages.Update(ctx, *assistantMsg); err != nil {
 			return fmt.Errorf("failed to update message: %w", err)
 		}
-		return a.TrackUsage(ctx, sessionID, a.Model(), event.Response.Usage)
+		return a.TrackUsage(ctx, sessionID, model, event.Response.Usage)
 	}
 
 	return nil
 }
 
-func (a *agent) TrackUsage(ctx context.Context, sessionID string, model catwalk.Model, usage provider.TokenUsage) error {
-	sess, err := a.sessions.Get(ctx, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
-	}
-
-	cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
+// usageCost returns the cost of the token usage of a request to the model.
+func usageCost(model catwalk.Model, usage provider.TokenUsage) float64 {
+	return model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
 		model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
 		model.CostPer1MIn/1e6*float64(usage.InputTokens) +
 		model.CostPer1MOut/1e6*float64(usage.OutputTokens)
+}
 
-	sess.Cost += cost
+func (a *agent) TrackUsage(ctx context.Context, sessionID string, model catwalk.Model, usage provider.TokenUsage) error {
+	sess, err := a.sessions.AddCost(ctx, sessionID, usageCost(model, usage))
+	if err != nil {
+		return fmt.Errorf("failed to save session cost: %w", err)
+	}
 	sess.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
 	sess.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
 
@@ -852,6 +1047,7 @@ func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 			a.Publish(pubsub.CreatedEvent, event)
 			return
 		}
+		cost := usageCost(a.summarizeProvider.Model(), finalResponse.Usage)
 		// Create a message in the new session with the summary
 		msg, err := a.messages.Create(summarizeCtx, oldSession.ID, message.CreateMessageParams{
 			Role: message.Assistant,
@@ -862,8 +1058,11 @@ func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 					Time:   time.Now().Unix(),
 				},
 			},
-			Model:    a.summarizeProvider.Model().ID,
-			Provider: a.summarizeProviderID,
+			Model:            a.summarizeProvider.Model().ID,
+			Provider:         a.summarizeProviderID,
+			Cost:             cost,
+			PromptTokens:     finalResponse.Usage.InputTokens + finalResponse.Usage.CacheCreationTokens,
+			CompletionTokens: finalResponse.Usage.OutputTokens + finalResponse.Usage.CacheReadTokens,
 		})
 		if err != nil {
 			event = AgentEvent{
@@ -878,14 +1077,10 @@ func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 		oldSession.SummaryMessageID = msg.ID
 		oldSession.CompletionTokens = finalResponse.Usage.OutputTokens
 		oldSession.PromptTokens = 0
-		model := a.summarizeProvider.Model()
-		usage := finalResponse.Usage
-		cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-			model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-			model.CostPer1MIn/1e6*float64(usage.InputTokens) +
-			model.CostPer1MOut/1e6*float64(usage.OutputTokens)
-		oldSession.Cost += cost
-		_, err = a.sessions.Save(summarizeCtx, oldSession)
+		_, err = a.sessions.AddCost(summarizeCtx, oldSession.ID, cost)
+		if err == nil {
+			_, err = a.sessions.Save(summarizeCtx, oldSession)
+		}
 		if err != nil {
 			event = AgentEvent{
 				Type:  AgentEventTypeError,
@@ -951,14 +1146,14 @@ func (a *agent) UpdateModel() error {
 			return fmt.Errorf("model not found for agent %s", a.agentCfg.Name)
 		}
 
-		promptID := agentPromptMap[a.agentCfg.ID]
-		if promptID == "" {
-			promptID = prompt.PromptDefault
+		systemMessage, err := systemPrompt(a.agentCfg, currentProviderCfg.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create system prompt: %w", err)
 		}
 
 		opts := []provider.ProviderClientOption{
 			provider.WithModel(a.agentCfg.Model),
-			provider.WithSystemMessage(prompt.GetPrompt(promptID, currentProviderCfg.ID, cfg.Options.ContextPaths...)),
+			provider.WithSystemMessage(systemMessage),
 		}
 
 		newProvider, err := provider.NewProvider(*currentProviderCfg, opts...)
@@ -971,6 +1166,12 @@ func (a *agent) UpdateModel() error {
 		a.providerID = string(currentProviderCfg.ID)
 	}
 
+	fallbacks, err := newFallbackModels(a.agentCfg)
+	if err != nil {
+		return err
+	}
+	a.fallbacks = fallbacks
+
 	// Check if providers have changed for title (small) and summarize (large)
 	smallModelCfg := cfg.Models[config.SelectedModelTypeSmall]
 	var smallModelProviderCfg config.ProviderConfig
@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/csync"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+type fakeTool struct {
+	name string
+	safe bool
+	run  func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error)
+}
+
+func (f *fakeTool) Info() tools.ToolInfo                  { return tools.ToolInfo{Name: f.name} }
+func (f *fakeTool) Name() string                          { return f.name }
+func (f *fakeTool) IsConcurrencySafe(tools.ToolCall) bool { return f.safe }
+
+func (f *fakeTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	return f.run(ctx, call)
+}
+
+// toolTracker records how many tool calls run at the same time.
+type toolTracker struct {
+	mu         sync.Mutex
+	running    int
+	maxRunning map[bool]int
+	started    []string
+}
+
+func (t *toolTracker) enter(safe bool, id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running++
+	t.maxRunning[safe] = max(t.maxRunning[safe], t.running)
+	t.started = append(t.started, id)
+}
+
+func (t *toolTracker) leave() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running--
+}
+
+func newTestAgent(tracker *toolTracker, safeStarted chan struct{}) *agent {
+	respond := func(safe bool) func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			tracker.enter(safe, call.ID)
+			defer tracker.leave()
+			if safe {
+				safeStarted <- struct{}{}
+				// Give the other calls of the batch a chance to start.
+				time.Sleep(10 * time.Millisecond)
+			}
+			return tools.NewTextResponse(call.ID), nil
+		}
+	}
+	fakeTools := []tools.BaseTool{
+		&fakeTool{name: "safe", safe: true, run: respond(true)},
+		&fakeTool{name: "unsafe", run: respond(false)},
+		&fakeTool{name: "denied", run: func(_ context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			tracker.enter(false, call.ID)
+			defer tracker.leave()
+			return tools.ToolResponse{}, permission.ErrorPermissionDenied
+		}},
+		&fakeTool{name: "blocking", safe: true, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			tracker.enter(true, call.ID)
+			defer tracker.leave()
+			safeStarted <- struct{}{}
+			<-ctx.Done()
+			return tools.ToolResponse{}, ctx.Err()
+		}},
+	}
+	return &agent{
+		tools: csync.NewLazySlice(func() []tools.BaseTool { return fakeTools }),
+	}
+}
+
+func TestExecuteToolCalls(t *testing.T) {
+	t.Parallel()
+
+	const canceled = "Tool execution canceled by user"
+
+	tests := []struct {
+		name         string
+		calls        []string
+		cancelAfter  int
+		wantContents []string
+		wantReason   message.FinishReason
+		wantStarted  []string
+		wantParallel bool
+	}{
+		{
+			name:         "keeps order and runs safe calls in parallel",
+			calls:        []string{"safe", "safe", "unsafe", "safe", "safe", "safe"},
+			wantContents: []string{"0", "1", "2", "3", "4", "5"},
+			wantStarted:  []string{"0", "1", "2", "3", "4", "5"},
+			wantParallel: true,
+		},
+		{
+			name:         "runs unsafe calls one at a time",
+			calls:        []string{"unsafe", "unsafe", "unsafe"},
+			wantContents: []string{"0", "1", "2"},
+			wantStarted:  []string{"0", "1", "2"},
+		},
+		{
+			name:         "stops after a denied permission",
+			calls:        []string{"unsafe", "denied", "safe", "unsafe"},
+			wantContents: []string{"0", "Permission denied", canceled, canceled},
+			wantReason:   message.FinishReasonPermissionDenied,
+			wantStarted:  []string{"0", "1"},
+		},
+		{
+			name:         "cancels in the middle of a batch",
+			calls:        []string{"unsafe", "blocking", "blocking", "unsafe"},
+			cancelAfter:  2,
+			wantContents: []string{"0", canceled, canceled, canceled},
+			wantReason:   message.FinishReasonCanceled,
+			wantStarted:  []string{"0", "1", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tracker := &toolTracker{maxRunning: map[bool]int{}}
+			safeStarted := make(chan struct{}, len(tt.calls))
+			a := newTestAgent(tracker, safeStarted)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			if tt.cancelAfter > 0 {
+				go func() {
+					for range tt.cancelAfter {
+						<-safeStarted
+					}
+					cancel()
+				}()
+			}
+
+			toolCalls := make([]message.ToolCall, len(tt.calls))
+			for i, name := range tt.calls {
+				toolCalls[i] = message.ToolCall{ID: string(rune('0' + i)), Name: name, Input: "{}"}
+			}
+
+			results, reason := a.executeToolCalls(ctx, toolCalls)
+			require.Equal(t, tt.wantReason, reason)
+			require.Len(t, results, len(tt.calls))
+			for i, result := range results {
+				require.Equal(t, toolCalls[i].ID, result.ToolCallID)
+				require.Equal(t, tt.wantContents[i], result.Content)
+			}
+
+			// Wait for calls that are still running after a cancellation.
+			require.Eventually(t, func() bool {
+				tracker.mu.Lock()
+				defer tracker.mu.Unlock()
+				return tracker.running == 0
+			}, time.Second, time.Millisecond)
+
+			tracker.mu.Lock()
+			defer tracker.mu.Unlock()
+			require.ElementsMatch(t, tt.wantStarted, tracker.started)
+			require.Equal(t, 1, max(tracker.maxRunning[false], 1), "unsafe calls must not overlap")
+			if tt.wantParallel {
+				require.Greater(t, tracker.maxRunning[true], 1, "safe calls should overlap")
+			}
+		})
+	}
+}
+
+func TestExecuteToolCallsSkipsAfterDenial(t *testing.T) {
+	t.Parallel()
+
+	var started atomic.Int32
+	denied := make(chan struct{})
+	fakeTools := []tools.BaseTool{
+		&fakeTool{name: "denied", safe: true, run: func(context.Context, tools.ToolCall) (tools.ToolResponse, error) {
+			started.Add(1)
+			defer close(denied)
+			return tools.ToolResponse{}, permission.ErrorPermissionDenied
+		}},
+		&fakeTool{name: "safe", safe: true, run: func(_ context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			started.Add(1)
+			// Only finish once the denial is in, so the free slots can't be
+			// used by the remaining calls.
+			<-denied
+			time.Sleep(5 * time.Millisecond)
+			return tools.NewTextResponse(call.ID), nil
+		}},
+	}
+	a := &agent{tools: csync.NewLazySlice(func() []tools.BaseTool { return fakeTools })}
+
+	// Fill the batch past the concurrency limit so some calls can only start
+	// after the denial.
+	toolCalls := []message.ToolCall{{ID: "denied", Name: "denied"}}
+	for i := range maxConcurrentToolCalls * 2 {
+		toolCalls = append(toolCalls, message.ToolCall{ID: string(rune('a' + i)), Name: "safe"})
+	}
+
+	results, reason := a.executeToolCalls(t.Context(), toolCalls)
+	require.Equal(t, message.FinishReasonPermissionDenied, reason)
+	require.Equal(t, "Permission denied", results[0].Content)
+
+	ran := 0
+	for _, result := range results[1:] {
+		if result.Content == result.ToolCallID {
+			ran++
+			continue
+		}
+		require.Equal(t, "Tool execution canceled by user", result.Content)
+	}
+	require.Equal(t, int(started.Load())-1, ran, "finished calls must keep their results")
+	require.Less(t, ran, len(toolCalls)-1, "calls after the denial must not start")
+}
+
+func TestFilterTools(t *testing.T) {
+	t.Parallel()
+
+	allTools := []tools.BaseTool{
+		&fakeTool{name: "view"},
+		&fakeTool{name: "edit"},
+		&McpTool{mcpName: "linear", tool: mcp.Tool{Name: "issues"}},
+		&McpTool{mcpName: "linear", tool: mcp.Tool{Name: "comment"}},
+		&McpTool{mcpName: "github", tool: mcp.Tool{Name: "issues"}},
+	}
+	names := func(agentCfg config.Agent) []string {
+		var names []string
+		for _, tool := range filterTools(agentCfg, allTools) {
+			names = append(names, tool.Name())
+		}
+		return names
+	}
+
+	require.Len(t, filterTools(config.Agent{}, allTools), 5)
+	require.Equal(t, []string{"view", "mcp_github_issues"}, names(config.Agent{
+		AllowedTools: []string{"view", "mcp_github_issues"},
+	}))
+	require.Equal(t, []string{"view", "mcp_linear_issues", "mcp_linear_comment"}, names(config.Agent{
+		AllowedTools: []string{"view"},
+		AllowedMCP:   map[string][]string{"linear": nil},
+	}))
+	require.Equal(t, []string{"view", "edit", "mcp_linear_issues"}, names(config.Agent{
+		AllowedMCP: map[string][]string{"linear": {"issues"}},
+	}))
+	require.Equal(t, []string{"view"}, names(config.Agent{
+		AllowedTools: []string{"view", "mcp_github_issues"},
+		AllowedMCP:   map[string][]string{},
+	}))
+}
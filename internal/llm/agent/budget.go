@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/pubsub"
+	"github.com/vikvang/zero/internal/session"
+)
+
+// budgetUsage is how much of one of the budgets has been used.
+type budgetUsage struct {
+	name  string
+	used  float64
+	limit float64
+	turns bool
+}
+
+func (u budgetUsage) String() string {
+	if u.turns {
+		return fmt.Sprintf("%s: used %d of %d turns", u.name, int(u.used), int(u.limit))
+	}
+	return fmt.Sprintf("%s: used $%.2f of $%.2f", u.name, u.used, u.limit)
+}
+
+// budget enforces the budgets of [config.Budget] over an agent loop. The run
+// budgets only apply to the loop of a top-level session, sub-agents roll
+// their cost up into it once they are done.
+type budget struct {
+	cfg       config.Budget
+	topLevel  bool
+	startCost float64
+	turns     int
+	warned    map[string]bool
+}
+
+func newBudget(cfg config.Budget, sess session.Session) *budget {
+	return &budget{
+		cfg:       cfg,
+		topLevel:  sess.ParentSessionID == "",
+		startCost: sess.Cost,
+		warned:    make(map[string]bool),
+	}
+}
+
+// budgetUsage returns the usage of the configured budgets of the session.
+func (a *agent) budgetUsage(ctx context.Context, sessionID string, b *budget) ([]budgetUsage, error) {
+	var usages []budgetUsage
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if b.cfg.MaxSessionCost > 0 {
+		usages = append(usages, budgetUsage{name: "Session budget", used: sess.Cost, limit: b.cfg.MaxSessionCost})
+	}
+	if b.cfg.MaxDailyCost > 0 {
+		year, month, day := time.Now().Date()
+		cost, err := a.messages.CostSince(ctx, time.Date(year, month, day, 0, 0, 0, 0, time.Local))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the cost of today: %w", err)
+		}
+		usages = append(usages, budgetUsage{name: "Daily budget", used: cost, limit: b.cfg.MaxDailyCost})
+	}
+	if b.topLevel && b.cfg.MaxRunCost > 0 {
+		usages = append(usages, budgetUsage{name: "Run budget", used: sess.Cost - b.startCost, limit: b.cfg.MaxRunCost})
+	}
+	if b.topLevel && b.cfg.MaxRunTurns > 0 {
+		usages = append(usages, budgetUsage{name: "Turn limit", used: float64(b.turns), limit: float64(b.cfg.MaxRunTurns), turns: true})
+	}
+	return usages, nil
+}
+
+// checkBudget is called before each request to the model. It warns once
+// about each budget that is nearly used and returns why the agent must stop
+// when one is used up, or an empty string.
+func (a *agent) checkBudget(ctx context.Context, sessionID string, b *budget) (string, error) {
+	usages, err := a.budgetUsage(ctx, sessionID, b)
+	if err != nil {
+		return "", err
+	}
+	for _, u := range usages {
+		if u.used >= u.limit {
+			return u.String(), nil
+		}
+	}
+	for _, u := range usages {
+		if u.used < b.cfg.WarnAt*u.limit || b.warned[u.name] {
+			continue
+		}
+		b.warned[u.name] = true
+		slog.Warn("Budget nearly used", "session_id", sessionID, "budget", u.name, "used", u.used, "limit", u.limit)
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
+			Type:      AgentEventTypeWarning,
+			SessionID: sessionID,
+			Warning:   u.String(),
+		})
+	}
+	b.turns++
+	return "", nil
+}
+
+// stopForBudget ends the agent loop with a response finished because a
+// budget is used up.
+func (a *agent) stopForBudget(ctx context.Context, sessionID, details string) AgentEvent {
+	slog.Warn("Budget exceeded, stopping the agent", "session_id", sessionID, "details", details)
+	msg, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.Finish{
+				Reason:  message.FinishReasonBudgetExceeded,
+				Time:    time.Now().Unix(),
+				Message: "Budget exceeded",
+				Details: details,
+			},
+		},
+		Model:    a.Model().ID,
+		Provider: a.providerID,
+	})
+	if err != nil {
+		return a.err(fmt.Errorf("failed to create budget message: %w", err))
+	}
+	return AgentEvent{
+		Type:    AgentEventTypeResponse,
+		Message: msg,
+		Done:    true,
+	}
+}
+
+// BudgetError returns an error wrapping [ErrBudgetExceeded] when the response
+// stopped because a budget is used up, nil otherwise.
+func BudgetError(msg message.Message) error {
+	if finish := msg.FinishPart(); finish != nil && finish.Reason == message.FinishReasonBudgetExceeded {
+		return fmt.Errorf("%w: %s", ErrBudgetExceeded, finish.Details)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/pubsub"
+	"github.com/vikvang/zero/internal/session"
+)
+
+func TestCheckBudget(t *testing.T) {
+	t.Parallel()
+
+	newTestAgent := func(sess session.Session, dailyCost float64) (*agent, *fakeSessions) {
+		sessions := &fakeSessions{sessions: map[string]session.Session{sess.ID: sess}}
+		return &agent{
+			Broker:   pubsub.NewBroker[AgentEvent](),
+			sessions: sessions,
+			messages: &fakeMessages{cost: dailyCost},
+		}, sessions
+	}
+
+	t.Run("warns once and stops at the session budget", func(t *testing.T) {
+		t.Parallel()
+
+		sess := session.Session{ID: "session", Cost: 0.85}
+		a, sessions := newTestAgent(sess, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := a.Subscribe(ctx)
+		b := newBudget(config.Budget{MaxSessionCost: 1, WarnAt: 0.8}, sess)
+
+		exceeded, err := a.checkBudget(ctx, "session", b)
+		require.NoError(t, err)
+		require.Empty(t, exceeded)
+		event := <-events
+		require.Equal(t, AgentEventTypeWarning, event.Payload.Type)
+		require.Equal(t, "Session budget: used $0.85 of $1.00", event.Payload.Warning)
+
+		exceeded, err = a.checkBudget(ctx, "session", b)
+		require.NoError(t, err)
+		require.Empty(t, exceeded)
+		require.Empty(t, events, "the warning should only be sent once")
+
+		_, err = sessions.AddCost(ctx, "session", 0.2)
+		require.NoError(t, err)
+		exceeded, err = a.checkBudget(ctx, "session", b)
+		require.NoError(t, err)
+		require.Equal(t, "Session budget: used $1.05 of $1.00", exceeded)
+	})
+
+	t.Run("stops at the daily budget", func(t *testing.T) {
+		t.Parallel()
+
+		sess := session.Session{ID: "session"}
+		a, _ := newTestAgent(sess, 20.5)
+		b := newBudget(config.Budget{MaxDailyCost: 20, WarnAt: 0.8}, sess)
+
+		exceeded, err := a.checkBudget(context.Background(), "session", b)
+		require.NoError(t, err)
+		require.Equal(t, "Daily budget: used $20.50 of $20.00", exceeded)
+	})
+
+	t.Run("stops at the run budgets", func(t *testing.T) {
+		t.Parallel()
+
+		sess := session.Session{ID: "session", Cost: 10}
+		a, sessions := newTestAgent(sess, 0)
+		ctx := context.Background()
+		b := newBudget(config.Budget{MaxRunCost: 1, MaxRunTurns: 2, WarnAt: 1}, sess)
+
+		for range 2 {
+			exceeded, err := a.checkBudget(ctx, "session", b)
+			require.NoError(t, err)
+			require.Empty(t, exceeded, "the cost before the run doesn't count")
+		}
+		exceeded, err := a.checkBudget(ctx, "session", b)
+		require.NoError(t, err)
+		require.Equal(t, "Turn limit: used 2 of 2 turns", exceeded)
+
+		b = newBudget(config.Budget{MaxRunCost: 1, WarnAt: 1}, sess)
+		_, err = sessions.AddCost(ctx, "session", 1.5)
+		require.NoError(t, err)
+		exceeded, err = a.checkBudget(ctx, "session", b)
+		require.NoError(t, err)
+		require.Equal(t, "Run budget: used $1.50 of $1.00", exceeded)
+	})
+
+	t.Run("sub-agents ignore the run budgets", func(t *testing.T) {
+		t.Parallel()
+
+		sess := session.Session{ID: "child", ParentSessionID: "session"}
+		a, _ := newTestAgent(sess, 0)
+		b := newBudget(config.Budget{MaxRunTurns: 1, WarnAt: 0.8}, sess)
+
+		for range 3 {
+			exceeded, err := a.checkBudget(context.Background(), "child", b)
+			require.NoError(t, err)
+			require.Empty(t, exceeded)
+		}
+	})
+}
+
+func TestBudgetError(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, BudgetError(message.Message{Parts: []message.ContentPart{message.Finish{Reason: message.FinishReasonEndTurn}}}))
+
+	err := BudgetError(message.Message{Parts: []message.ContentPart{
+		message.Finish{Reason: message.FinishReasonBudgetExceeded, Details: "Turn limit: used 2 of 2 turns"},
+	}})
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	require.EqualError(t, err, "budget exceeded: Turn limit: used 2 of 2 turns")
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/llm/provider"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/pubsub"
+)
+
+// chainModel is a model of the fallback chain of the agent, with the
+// provider to use it.
+type chainModel struct {
+	provider   provider.Provider
+	providerID string
+	model      catwalk.Model
+}
+
+// newFallbackModels creates the providers of the fallback models of the
+// agent's model, in order.
+func newFallbackModels(agentCfg config.Agent) ([]chainModel, error) {
+	cfg := config.Get()
+	var fallbacks []chainModel
+	for _, fallback := range cfg.Models[agentCfg.Model].Fallbacks {
+		providerCfg, ok := cfg.Providers.Get(fallback.Provider)
+		if !ok {
+			return nil, fmt.Errorf("provider %s of fallback model %s not found in config", fallback.Provider, fallback.Model)
+		}
+		model := cfg.GetModel(fallback.Provider, fallback.Model)
+		if model == nil {
+			return nil, fmt.Errorf("fallback model %s not found in provider %s", fallback.Model, fallback.Provider)
+		}
+		systemMessage, err := systemPrompt(agentCfg, providerCfg.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create system prompt for agent %s: %w", agentCfg.Name, err)
+		}
+		fallbackProvider, err := provider.NewProvider(
+			providerCfg,
+			provider.WithModel(agentCfg.Model),
+			provider.WithSelectedModel(fallback),
+			provider.WithSystemMessage(systemMessage),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider of fallback model %s: %w", fallback.Model, err)
+		}
+		fallbacks = append(fallbacks, chainModel{
+			provider:   fallbackProvider,
+			providerID: providerCfg.ID,
+			model:      *model,
+		})
+	}
+	return fallbacks, nil
+}
+
+// modelChain returns the model of the agent followed by its fallbacks.
+func (a *agent) modelChain() []chainModel {
+	return append([]chainModel{{
+		provider:   a.provider,
+		providerID: a.providerID,
+		model:      a.Model(),
+	}}, a.fallbacks...)
+}
+
+// streamResponse streams the response to the history into the assistant
+// message. When the provider of a model fails before responding because it's
+// overloaded or erroring, the request is sent to the next model of the
+// chain, which converts the history to its own format, and the assistant
+// message records the model that responded.
+func (a *agent) streamResponse(ctx context.Context, sessionID string, assistantMsg *message.Message, chain []chainModel, msgHistory []message.Message, agentTools []tools.BaseTool) error {
+	for i, current := range chain {
+		eventChan := current.provider.StreamResponse(ctx, msgHistory, agentTools)
+		err := a.processEvents(ctx, sessionID, assistantMsg, current.model, eventChan)
+		if err == nil || i == len(chain)-1 || len(assistantMsg.Parts) > 0 || !provider.ShouldFallback(err) {
+			return err
+		}
+
+		next := chain[i+1]
+		slog.Warn("Falling back to another model", "from", current.model.ID, "to", next.model.ID, "error", err)
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
+			Type:      AgentEventTypeWarning,
+			SessionID: sessionID,
+			Warning:   fmt.Sprintf("%s failed, falling back to %s: %s", current.model.Name, next.model.Name, err),
+		})
+		assistantMsg.Model = next.model.ID
+		assistantMsg.Provider = next.providerID
+		if err := a.messages.Update(ctx, *assistantMsg); err != nil {
+			return fmt.Errorf("failed to update message: %w", err)
+		}
+	}
+	return nil
+}
+
+// processEvents processes the events of the stream of the model.
+func (a *agent) processEvents(ctx context.Context, sessionID string, assistantMsg *message.Message, model catwalk.Model, eventChan <-chan provider.ProviderEvent) error {
+	for event := range eventChan {
+		if err := a.processEvent(ctx, sessionID, assistantMsg, model, event); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
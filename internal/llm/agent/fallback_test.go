@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/llm/provider"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/pubsub"
+	"github.com/vikvang/zero/internal/session"
+)
+
+// fakeProvider streams the given events and records the requests.
+type fakeProvider struct {
+	provider.Provider
+	events   []provider.ProviderEvent
+	requests int
+}
+
+func (f *fakeProvider) StreamResponse(context.Context, []message.Message, []tools.BaseTool) <-chan provider.ProviderEvent {
+	f.requests++
+	eventChan := make(chan provider.ProviderEvent, len(f.events))
+	for _, event := range f.events {
+		eventChan <- event
+	}
+	close(eventChan)
+	return eventChan
+}
+
+type fakeMessages struct {
+	message.Service
+	mu      sync.Mutex
+	updates []message.Message
+	cost    float64
+}
+
+func (f *fakeMessages) Update(_ context.Context, msg message.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, msg)
+	return nil
+}
+
+func (f *fakeMessages) CostSince(context.Context, time.Time) (float64, error) {
+	return f.cost, nil
+}
+
+// savingSessions also saves the token counts, as TrackUsage does.
+type savingSessions struct {
+	*fakeSessions
+}
+
+func (s savingSessions) Save(_ context.Context, sess session.Session) (session.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sess.ID] = sess
+	return sess, nil
+}
+
+func TestStreamResponseFallsBack(t *testing.T) {
+	t.Parallel()
+
+	overloaded := fmt.Errorf("%w for rate limit: 8 retries", provider.ErrMaxRetries)
+	complete := provider.ProviderEvent{
+		Type:     provider.EventComplete,
+		Response: &provider.ProviderResponse{FinishReason: message.FinishReasonEndTurn, Usage: provider.TokenUsage{InputTokens: 1_000_000}},
+	}
+
+	tests := []struct {
+		name          string
+		primary       []provider.ProviderEvent
+		wantErr       error
+		wantContent   string
+		wantProvider  string
+		wantFallbacks int
+		wantCost      float64
+	}{
+		{
+			name:         "primary responds",
+			primary:      []provider.ProviderEvent{{Type: provider.EventContentDelta, Content: "hi"}, complete},
+			wantContent:  "hi",
+			wantProvider: "anthropic",
+			wantCost:     3,
+		},
+		{
+			name:          "primary overloaded",
+			primary:       []provider.ProviderEvent{{Type: provider.EventError, Error: overloaded}},
+			wantContent:   "hello",
+			wantProvider:  "openai",
+			wantFallbacks: 1,
+			wantCost:      2,
+		},
+		{
+			name:         "request error",
+			primary:      []provider.ProviderEvent{{Type: provider.EventError, Error: errors.New("invalid request")}},
+			wantErr:      errors.New("invalid request"),
+			wantProvider: "anthropic",
+		},
+		{
+			name: "failure after responding",
+			primary: []provider.ProviderEvent{
+				{Type: provider.EventContentDelta, Content: "partial"},
+				{Type: provider.EventError, Error: overloaded},
+			},
+			wantErr:      overloaded,
+			wantContent:  "partial",
+			wantProvider: "anthropic",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			primary := &fakeProvider{events: tt.primary}
+			fallback := &fakeProvider{events: []provider.ProviderEvent{{Type: provider.EventContentDelta, Content: "hello"}, complete}}
+			chain := []chainModel{
+				{provider: primary, providerID: "anthropic", model: catwalk.Model{ID: "claude", Name: "Claude", CostPer1MIn: 3}},
+				{provider: fallback, providerID: "openai", model: catwalk.Model{ID: "gpt", Name: "GPT", CostPer1MIn: 2}},
+			}
+			messages := &fakeMessages{}
+			sessions := savingSessions{&fakeSessions{sessions: map[string]session.Session{"session": {ID: "session"}}}}
+			a := &agent{
+				Broker:   pubsub.NewBroker[AgentEvent](),
+				messages: messages,
+				sessions: sessions,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events := a.Subscribe(ctx)
+
+			assistantMsg := message.Message{ID: "message", Role: message.Assistant, Model: "claude", Provider: "anthropic"}
+			err := a.streamResponse(ctx, "session", &assistantMsg, chain, nil, nil)
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantContent, assistantMsg.Content().Text)
+			require.Equal(t, tt.wantProvider, assistantMsg.Provider)
+			require.Equal(t, tt.wantFallbacks, fallback.requests)
+
+			sess, err := sessions.Get(ctx, "session")
+			require.NoError(t, err)
+			require.InDelta(t, tt.wantCost, sess.Cost, 1e-9)
+
+			if tt.wantFallbacks == 0 {
+				return
+			}
+			require.Equal(t, "gpt", assistantMsg.Model)
+			require.Equal(t, "gpt", messages.updates[0].Model, "the message records the fallback model before streaming")
+			event := <-events
+			require.Equal(t, AgentEventTypeWarning, event.Payload.Type)
+			require.Contains(t, event.Payload.Warning, "Claude failed, falling back to GPT")
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/mcpoauth"
+)
+
+// mcpOAuthStore returns the store of the OAuth credentials of the server,
+// kept in the data directory.
+func mcpOAuthStore(name string, m config.MCPConfig) *mcpoauth.Store {
+	path := filepath.Join(config.Get().Options.DataDirectory, "mcp-oauth", name+".json")
+	return mcpoauth.NewStore(path, m.URL)
+}
+
+func mcpOAuthConfig(m config.MCPConfig) mcpoauth.Config {
+	oauth := m.ResolvedOAuth()
+	return mcpoauth.Config{
+		ServerURL:             m.URL,
+		ClientID:              oauth.ClientID,
+		ClientSecret:          oauth.ClientSecret,
+		Scopes:                oauth.Scopes,
+		AuthServerMetadataURL: oauth.MetadataURL,
+		RedirectPort:          oauth.RedirectPort,
+	}
+}
+
+// mcpAuthError replaces the error of a server that requires authorization
+// with one telling how to authorize it.
+func mcpAuthError(name string, err error) error {
+	if client.IsOAuthAuthorizationRequiredError(err) {
+		return fmt.Errorf("authorization required, run 'zero mcp login %s'", name)
+	}
+	return err
+}
+
+// AuthorizeMCP runs the OAuth authorization flow of the MCP server and
+// stores the token. openURL is called with the URL the user has to visit.
+// If the server is supervised, it's reconnected with the new token.
+func AuthorizeMCP(ctx context.Context, name string, openURL func(authURL string)) error {
+	m, err := oauthMCPConfig(name)
+	if err != nil {
+		return err
+	}
+	if err := mcpoauth.Authorize(ctx, mcpOAuthConfig(m), mcpOAuthStore(name, m), openURL); err != nil {
+		return fmt.Errorf("failed to authorize mcp '%s': %w", name, err)
+	}
+	if s, ok := mcpSupervisors.Get(name); ok {
+		s.signal(s.lost)
+	}
+	return nil
+}
+
+// LogoutMCP deletes the stored OAuth credentials of the MCP server.
+func LogoutMCP(name string) error {
+	m, err := oauthMCPConfig(name)
+	if err != nil {
+		return err
+	}
+	return mcpOAuthStore(name, m).Delete()
+}
+
+func oauthMCPConfig(name string) (config.MCPConfig, error) {
+	m, ok := config.Get().MCP[name]
+	if !ok {
+		return config.MCPConfig{}, fmt.Errorf("mcp '%s' not found in config", name)
+	}
+	if m.Auth != config.MCPAuthOAuth {
+		return config.MCPConfig{}, fmt.Errorf("mcp '%s' does not use the oauth auth mode", name)
+	}
+	return m, nil
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vikvang/zero/internal/csync"
+)
+
+// MCPPrompt is a prompt, optionally templated with arguments, exposed by an
+// MCP server.
+type MCPPrompt struct {
+	MCPName     string
+	Name        string
+	Description string
+	Arguments   []MCPPromptArgument
+}
+
+type MCPPromptArgument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+var mcpPrompts = csync.NewMap[string, []MCPPrompt]()
+
+// getPrompts lists the prompts of the server, if it has any.
+func getPrompts(ctx context.Context, name string, c *client.Client) []MCPPrompt {
+	if c.GetServerCapabilities().Prompts == nil {
+		mcpPrompts.Del(name)
+		return nil
+	}
+
+	result, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		slog.Error("error listing prompts", "error", err, "name", name)
+		mcpPrompts.Del(name)
+		return nil
+	}
+	prompts := make([]MCPPrompt, 0, len(result.Prompts))
+	for _, p := range result.Prompts {
+		prompt := MCPPrompt{
+			MCPName:     name,
+			Name:        p.Name,
+			Description: p.Description,
+		}
+		for _, arg := range p.Arguments {
+			prompt.Arguments = append(prompt.Arguments, MCPPromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
+		}
+		prompts = append(prompts, prompt)
+	}
+	mcpPrompts.Set(name, prompts)
+	return prompts
+}
+
+// GetMCPPrompts returns the prompts of all the connected MCP servers, sorted
+// by server and name.
+func GetMCPPrompts() []MCPPrompt {
+	var prompts []MCPPrompt
+	for list := range mcpPrompts.Seq() {
+		prompts = append(prompts, list...)
+	}
+	slices.SortFunc(prompts, func(a, b MCPPrompt) int {
+		return cmp.Or(
+			strings.Compare(a.MCPName, b.MCPName),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+	return prompts
+}
+
+// GetMCPPrompt gets a prompt from an MCP server with the given arguments and
+// renders its messages as text.
+func GetMCPPrompt(ctx context.Context, name, prompt string, args map[string]string) (string, error) {
+	c, err := getOrRenewClient(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	result, err := c.GetPrompt(ctx, mcp.GetPromptRequest{
+		Params: mcp.GetPromptParams{
+			Name:      prompt,
+			Arguments: args,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error getting prompt %s from mcp '%s': %w", prompt, name, err)
+	}
+	content := renderPromptMessages(result.Messages)
+	if content == "" {
+		return "", fmt.Errorf("prompt %s from mcp '%s' is empty", prompt, name)
+	}
+	return content, nil
+}
+
+// renderPromptMessages joins the text of the prompt messages. Embedded text
+// resources are included, other contents like images are left out.
+func renderPromptMessages(messages []mcp.PromptMessage) string {
+	parts := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		switch content := msg.Content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, content.Text)
+		case mcp.EmbeddedResource:
+			if resource, ok := content.Resource.(mcp.TextResourceContents); ok {
+				parts = append(parts, fmt.Sprintf("<resource uri=%q>\n%s\n</resource>", resource.URI, resource.Text))
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPromptMessages(t *testing.T) {
+	t.Parallel()
+
+	messages := []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Review the changes in ENG-12.")),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewImageContent("aGk=", "image/png")),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+			URI:  "tickets://ENG-12",
+			Text: "# Crash on startup",
+		})),
+	}
+
+	require.Equal(t,
+		"Review the changes in ENG-12.\n\n<resource uri=\"tickets://ENG-12\">\n# Crash on startup\n</resource>",
+		renderPromptMessages(messages))
+	require.Empty(t, renderPromptMessages(nil))
+}
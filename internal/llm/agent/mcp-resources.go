@@ -0,0 +1,325 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vikvang/zero/internal/csync"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+// MCPResource is a resource, or a resource template, exposed by an MCP
+// server.
+type MCPResource struct {
+	MCPName string
+	// URI is the URI of the resource, or the RFC 6570 URI template of a
+	// template.
+	URI         string
+	Name        string
+	Description string
+	MIMEType    string
+	Template    bool
+}
+
+// Mention returns the text referencing the resource in a prompt.
+func (r MCPResource) Mention() string {
+	return "@" + r.MCPName + ":" + r.URI
+}
+
+var mcpResources = csync.NewMap[string, []MCPResource]()
+
+// getResources lists the resources and resource templates of the server, if
+// it has any.
+func getResources(ctx context.Context, name string, c *client.Client) []MCPResource {
+	if c.GetServerCapabilities().Resources == nil {
+		mcpResources.Del(name)
+		return nil
+	}
+
+	var resources []MCPResource
+	result, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		slog.Error("error listing resources", "error", err, "name", name)
+	} else {
+		for _, r := range result.Resources {
+			resources = append(resources, MCPResource{
+				MCPName:     name,
+				URI:         r.URI,
+				Name:        r.Name,
+				Description: r.Description,
+				MIMEType:    r.MIMEType,
+			})
+		}
+	}
+
+	templates, err := c.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		slog.Error("error listing resource templates", "error", err, "name", name)
+	} else {
+		for _, t := range templates.ResourceTemplates {
+			if t.URITemplate == nil {
+				continue
+			}
+			resources = append(resources, MCPResource{
+				MCPName:     name,
+				URI:         t.URITemplate.Raw(),
+				Name:        t.Name,
+				Description: t.Description,
+				MIMEType:    t.MIMEType,
+				Template:    true,
+			})
+		}
+	}
+
+	mcpResources.Set(name, resources)
+	return resources
+}
+
+// GetMCPResources returns the resources and resource templates of all the
+// connected MCP servers, sorted by server and URI.
+func GetMCPResources() []MCPResource {
+	var resources []MCPResource
+	for list := range mcpResources.Seq() {
+		resources = append(resources, list...)
+	}
+	slices.SortFunc(resources, func(a, b MCPResource) int {
+		return cmp.Or(
+			strings.Compare(a.MCPName, b.MCPName),
+			strings.Compare(a.URI, b.URI),
+		)
+	})
+	return resources
+}
+
+// HasMCPResources reports whether the MCP server exposes resources.
+func HasMCPResources(name string) bool {
+	_, ok := mcpResources.Get(name)
+	return ok
+}
+
+var mcpResourceMentionRe = regexp.MustCompile(`(?:^|\s)@([\w.-]+):(\S+)`)
+
+// ParseMCPResourceMentions returns the resources mentioned in a prompt as
+// @server:uri, for servers exposing resources.
+func ParseMCPResourceMentions(prompt string) []MCPResource {
+	var mentions []MCPResource
+	for _, match := range mcpResourceMentionRe.FindAllStringSubmatch(prompt, -1) {
+		name, uri := match[1], strings.TrimRight(match[2], ".,;:!?)]}\"'")
+		if uri == "" || !HasMCPResources(name) {
+			continue
+		}
+		mention := MCPResource{MCPName: name, URI: uri}
+		if !slices.Contains(mentions, mention) {
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
+
+// ReadMCPResource reads a resource from an MCP server, returning its
+// contents as attachments.
+func ReadMCPResource(ctx context.Context, name, uri string) ([]message.Attachment, error) {
+	c, err := getOrRenewClient(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.ReadResource(ctx, mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource %s from mcp '%s': %w", uri, name, err)
+	}
+
+	attachments := make([]message.Attachment, 0, len(result.Contents))
+	for _, contents := range result.Contents {
+		switch contents := contents.(type) {
+		case mcp.TextResourceContents:
+			attachments = append(attachments, resourceAttachment(name, cmp.Or(contents.URI, uri), cmp.Or(contents.MIMEType, "text/plain"), []byte(contents.Text)))
+		case mcp.BlobResourceContents:
+			data, err := base64.StdEncoding.DecodeString(contents.Blob)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding resource %s from mcp '%s': %w", uri, name, err)
+			}
+			attachments = append(attachments, resourceAttachment(name, cmp.Or(contents.URI, uri), cmp.Or(contents.MIMEType, "application/octet-stream"), data))
+		}
+	}
+	return attachments, nil
+}
+
+func resourceAttachment(name, uri, mimeType string, content []byte) message.Attachment {
+	return message.Attachment{
+		FilePath: uri,
+		FileName: name + ":" + cmp.Or(path.Base(uri), uri),
+		MimeType: mimeType,
+		Content:  content,
+	}
+}
+
+type ReadMCPResourceParams struct {
+	MCPName string `json:"mcp_name,omitempty"`
+	URI     string `json:"uri,omitempty"`
+}
+
+type ReadMCPResourcePermissionsParams struct {
+	MCPName string `json:"mcp_name"`
+	URI     string `json:"uri"`
+}
+
+type readMCPResourceTool struct {
+	permissions permission.Service
+	workingDir  string
+}
+
+const (
+	ReadMCPResourceToolName    = "read_mcp_resource"
+	readMCPResourceDescription = `Lists and reads the resources exposed by the connected MCP servers, like documents, tickets or runbooks.
+WHEN TO USE THIS TOOL:
+- Use when you need context that an MCP server provides as resources instead of tools
+- Use without a URI to list the resources and resource templates available
+HOW TO USE:
+- Omit uri to list the resources, optionally only those of the MCP server named by mcp_name
+- Provide mcp_name and uri to read a resource
+- For resource templates, replace the {placeholders} of the URI template with values to build the URI
+FEATURES:
+- Text resources are returned as is
+- Binary resources are described by their type and size
+LIMITATIONS:
+- Only servers that expose resources can be used
+- Binary contents, like images, are not returned
+`
+)
+
+func NewReadMCPResourceTool(permissions permission.Service, workingDir string) tools.BaseTool {
+	return &readMCPResourceTool{
+		permissions: permissions,
+		workingDir:  workingDir,
+	}
+}
+
+func (t *readMCPResourceTool) Name() string {
+	return ReadMCPResourceToolName
+}
+
+// IsConcurrencySafe reports whether the call can run in parallel, which is
+// only the case for listing the resources as reading one needs a permission.
+func (t *readMCPResourceTool) IsConcurrencySafe(call tools.ToolCall) bool {
+	var params ReadMCPResourceParams
+	return json.Unmarshal([]byte(call.Input), &params) == nil && params.URI == ""
+}
+
+func (t *readMCPResourceTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{
+		Name:        ReadMCPResourceToolName,
+		Description: readMCPResourceDescription,
+		Parameters: map[string]any{
+			"mcp_name": map[string]any{
+				"type":        "string",
+				"description": "The name of the MCP server exposing the resource",
+			},
+			"uri": map[string]any{
+				"type":        "string",
+				"description": "The URI of the resource to read; omit it to list the resources",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *readMCPResourceTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	var params ReadMCPResourceParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return tools.NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.URI == "" {
+		return tools.NewTextResponse(formatMCPResources(GetMCPResources(), params.MCPName)), nil
+	}
+	if params.MCPName == "" {
+		return tools.NewTextErrorResponse("mcp_name is required to read a resource"), nil
+	}
+	if !HasMCPResources(params.MCPName) {
+		return tools.NewTextErrorResponse(fmt.Sprintf("mcp '%s' does not expose resources", params.MCPName)), nil
+	}
+
+	sessionID, messageID := tools.GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return tools.ToolResponse{}, fmt.Errorf("session ID and message ID are required for reading a resource")
+	}
+	p := t.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			ToolCallID:  call.ID,
+			Path:        t.workingDir,
+			ToolName:    ReadMCPResourceToolName,
+			Action:      "read",
+			Description: fmt.Sprintf("Read resource %s from %s", params.URI, params.MCPName),
+			Params: ReadMCPResourcePermissionsParams{
+				MCPName: params.MCPName,
+				URI:     params.URI,
+			},
+		},
+	)
+	if !p {
+		return tools.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	attachments, err := ReadMCPResource(ctx, params.MCPName, params.URI)
+	if err != nil {
+		return tools.NewTextErrorResponse(err.Error()), nil
+	}
+	if len(attachments) == 0 {
+		return tools.NewTextResponse("The resource is empty"), nil
+	}
+
+	output := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.IsText() {
+			output = append(output, string(attachment.Content))
+			continue
+		}
+		output = append(output, fmt.Sprintf("[%s: %s, %d bytes]", attachment.FilePath, attachment.MimeType, len(attachment.Content)))
+	}
+	return tools.NewTextResponse(strings.Join(output, "\n")), nil
+}
+
+// formatMCPResources lists the resources, only those of the named server
+// if name isn't empty.
+func formatMCPResources(resources []MCPResource, name string) string {
+	var output strings.Builder
+	for _, r := range resources {
+		if name != "" && r.MCPName != name {
+			continue
+		}
+		kind := "resource"
+		if r.Template {
+			kind = "template"
+		}
+		fmt.Fprintf(&output, "- %s %s (mcp_name: %s, uri: %s", kind, cmp.Or(r.Name, r.URI), r.MCPName, r.URI)
+		if r.MIMEType != "" {
+			fmt.Fprintf(&output, ", type: %s", r.MIMEType)
+		}
+		output.WriteString(")")
+		if r.Description != "" {
+			output.WriteString(": " + r.Description)
+		}
+		output.WriteString("\n")
+	}
+	if output.Len() == 0 {
+		if name != "" {
+			return fmt.Sprintf("No resources found for mcp '%s'", name)
+		}
+		return "No resources found"
+	}
+	return output.String()
+}
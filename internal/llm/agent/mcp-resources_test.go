@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMCPResourceMentions(t *testing.T) {
+	mcpResources.Set("tickets", []MCPResource{{MCPName: "tickets", URI: "tickets://ENG-12"}})
+	t.Cleanup(func() { mcpResources.Del("tickets") })
+
+	mentions := ParseMCPResourceMentions("Fix @tickets:tickets://ENG-12, see @tickets:tickets://ENG-13.\n@docs:runbook mail me@example.com:25 @tickets:tickets://ENG-12")
+	require.Equal(t, []MCPResource{
+		{MCPName: "tickets", URI: "tickets://ENG-12"},
+		{MCPName: "tickets", URI: "tickets://ENG-13"},
+	}, mentions)
+}
+
+func TestFormatMCPResources(t *testing.T) {
+	t.Parallel()
+
+	resources := []MCPResource{
+		{MCPName: "docs", URI: "docs://runbooks/deploy", Name: "Deploy runbook", MIMEType: "text/markdown"},
+		{MCPName: "tickets", URI: "tickets://{id}", Name: "Ticket", Description: "A ticket by ID", Template: true},
+	}
+
+	require.Equal(t,
+		"- resource Deploy runbook (mcp_name: docs, uri: docs://runbooks/deploy, type: text/markdown)\n"+
+			"- template Ticket (mcp_name: tickets, uri: tickets://{id}): A ticket by ID\n",
+		formatMCPResources(resources, ""))
+	require.Equal(t,
+		"- template Ticket (mcp_name: tickets, uri: tickets://{id}): A ticket by ID\n",
+		formatMCPResources(resources, "tickets"))
+	require.Equal(t, "No resources found for mcp 'github'", formatMCPResources(resources, "github"))
+}
+
+func TestResourceAttachment(t *testing.T) {
+	t.Parallel()
+
+	attachment := resourceAttachment("tickets", "tickets://ENG-12", "text/markdown", []byte("# Crash"))
+	require.Equal(t, "tickets:ENG-12", attachment.FileName)
+	require.Equal(t, "tickets://ENG-12", attachment.FilePath)
+	require.True(t, attachment.IsText())
+}
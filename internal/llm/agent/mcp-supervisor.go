@@ -0,0 +1,278 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/csync"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/permission"
+	"github.com/vikvang/zero/internal/pubsub"
+)
+
+const (
+	// mcpHealthInterval is the interval between the pings checking that a
+	// server is still reachable.
+	mcpHealthInterval = 30 * time.Second
+	// mcpMinBackoff and mcpMaxBackoff bound the delay between reconnection
+	// attempts, which doubles after each failure.
+	mcpMinBackoff = time.Second
+	mcpMaxBackoff = time.Minute
+)
+
+var (
+	mcpSupervisors  = csync.NewMap[string, *mcpSupervisor]()
+	mcpCancel       context.CancelFunc
+	mcpResourceTool tools.BaseTool
+)
+
+// mcpSupervisor keeps the connection to an MCP server alive. It checks the
+// server health, reconnects with backoff when it's lost, and lists the tools,
+// resources and prompts again when the server reports they changed.
+type mcpSupervisor struct {
+	// ctx lives until the supervisor is stopped. The clients are started
+	// with it since their transports keep listening to the server with it.
+	ctx         context.Context
+	name        string
+	cfg         config.MCPConfig
+	permissions permission.Service
+	workingDir  string
+
+	// mu serializes the reconnections, which can be started both by the
+	// supervisor and by a tool call.
+	mu sync.Mutex
+	// changed is signaled when the server reports a list change.
+	changed chan struct{}
+	// lost is signaled when the transport reports the connection was lost.
+	lost chan struct{}
+}
+
+func newMCPSupervisor(ctx context.Context, name string, cfg config.MCPConfig, permissions permission.Service, workingDir string) *mcpSupervisor {
+	return &mcpSupervisor{
+		ctx:         ctx,
+		name:        name,
+		cfg:         cfg,
+		permissions: permissions,
+		workingDir:  workingDir,
+		changed:     make(chan struct{}, 1),
+		lost:        make(chan struct{}, 1),
+	}
+}
+
+// run watches the server until the supervisor is stopped. The first
+// connection is expected to have been attempted already.
+func (s *mcpSupervisor) run() {
+	ctx := s.ctx
+	backoff := mcpMinBackoff
+	timer := time.NewTimer(mcpHealthInterval)
+	defer timer.Stop()
+	if _, ok := mcpClients.Get(s.name); !ok {
+		timer.Reset(backoff)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+			if c, ok := mcpClients.Get(s.name); ok {
+				s.reload(ctx, c)
+			}
+			continue
+		case <-s.lost:
+		case <-timer.C:
+		}
+
+		c, ok := mcpClients.Get(s.name)
+		if ok {
+			err := s.ping(ctx, c)
+			if err == nil {
+				backoff = mcpMinBackoff
+				timer.Reset(mcpHealthInterval)
+				continue
+			}
+			slog.Warn("Lost connection to mcp server", "name", s.name, "error", err)
+			updateMCPState(s.name, MCPStateError, err, nil, 0)
+		}
+
+		if _, err := s.connect(c); err != nil {
+			slog.Debug("Failed to reconnect to mcp server", "name", s.name, "error", err, "retry_in", backoff)
+			timer.Reset(backoff)
+			backoff = min(backoff*2, mcpMaxBackoff)
+			continue
+		}
+		backoff = mcpMinBackoff
+		timer.Reset(mcpHealthInterval)
+	}
+}
+
+func (s *mcpSupervisor) ping(ctx context.Context, c *client.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, mcpTimeout(s.cfg))
+	defer cancel()
+	return c.Ping(ctx)
+}
+
+// connect replaces the stale client of the server, which is nil if there's
+// none, with a new one. If the client was already replaced in the meantime,
+// the current one is returned.
+func (s *mcpSupervisor) connect(stale *client.Client) (*client.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := mcpClients.Get(s.name); ok && c != stale {
+		return c, nil
+	}
+	if stale != nil {
+		_ = stale.Close()
+		mcpClients.Del(s.name)
+		mcpResources.Del(s.name)
+		mcpPrompts.Del(s.name)
+	}
+
+	updateMCPState(s.name, MCPStateStarting, nil, nil, 0)
+	c, err := createAndInitializeClient(s.ctx, s.name, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, mcpTimeout(s.cfg))
+	defer cancel()
+	if err := s.load(ctx, c); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	mcpClients.Set(s.name, c)
+	return c, nil
+}
+
+// reload lists the tools, resources and prompts of the server again.
+func (s *mcpSupervisor) reload(ctx context.Context, c *client.Client) {
+	ctx, cancel := context.WithTimeout(ctx, mcpTimeout(s.cfg))
+	defer cancel()
+	if err := s.load(ctx, c); err != nil {
+		s.signal(s.lost)
+	}
+}
+
+// load lists the tools, resources and prompts of the server and makes them
+// available to the agents.
+func (s *mcpSupervisor) load(ctx context.Context, c *client.Client) error {
+	serverTools, err := getTools(ctx, s.name, s.permissions, c, s.workingDir)
+	if err != nil {
+		slog.Error("error listing tools", "error", err, "name", s.name)
+		updateMCPState(s.name, MCPStateError, err, nil, 0)
+		return err
+	}
+	getResources(ctx, s.name, c)
+	getPrompts(ctx, s.name, c)
+
+	previous, _ := mcpTools.Get(s.name)
+	mcpTools.Set(s.name, serverTools)
+	updateMCPState(s.name, MCPStateConnected, nil, c, len(serverTools))
+	if !sameToolNames(previous, serverTools) {
+		mcpBroker.Publish(pubsub.UpdatedEvent, MCPEvent{
+			Type:      MCPEventToolsChanged,
+			Name:      s.name,
+			State:     MCPStateConnected,
+			ToolCount: len(serverTools),
+		})
+	}
+	return nil
+}
+
+// handleNotification is registered on the clients of the server.
+func (s *mcpSupervisor) handleNotification(notification mcp.JSONRPCNotification) {
+	switch notification.Method {
+	case mcp.MethodNotificationToolsListChanged,
+		mcp.MethodNotificationResourcesListChanged,
+		mcp.MethodNotificationPromptsListChanged:
+		slog.Debug("mcp server list changed", "name", s.name, "method", notification.Method)
+		s.signal(s.changed)
+	}
+}
+
+// handleConnectionLost is registered on the clients of the server, for the
+// transports reporting it.
+func (s *mcpSupervisor) handleConnectionLost(err error) {
+	slog.Debug("mcp connection lost", "name", s.name, "error", err)
+	s.signal(s.lost)
+}
+
+func (s *mcpSupervisor) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func sameToolNames(a, b []tools.BaseTool) bool {
+	return slices.EqualFunc(a, b, func(x, y tools.BaseTool) bool {
+		return x.Name() == y.Name()
+	})
+}
+
+// GetMCPTools returns the tools of the connected MCP servers. They change as
+// servers connect, disconnect or update their tools.
+func GetMCPTools() []tools.BaseTool {
+	var names []string
+	for name := range mcpTools.Seq2() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var result []tools.BaseTool
+	for _, name := range names {
+		if _, ok := mcpClients.Get(name); !ok {
+			continue
+		}
+		serverTools, _ := mcpTools.Get(name)
+		result = append(result, serverTools...)
+	}
+	if mcpResourceTool != nil && mcpResources.Len() > 0 {
+		result = append(result, mcpResourceTool)
+	}
+	return result
+}
+
+// startMCPClients connects to the enabled MCP servers, waiting for the first
+// connection attempts, and keeps supervising them in the background until
+// [CloseMCPClients] is called.
+func startMCPClients(ctx context.Context, permissions permission.Service, cfg *config.Config) {
+	ctx, mcpCancel = context.WithCancel(ctx)
+	mcpResourceTool = NewReadMCPResourceTool(permissions, cfg.WorkingDir())
+
+	var wg sync.WaitGroup
+	for name, m := range cfg.MCP {
+		if m.Disabled {
+			updateMCPState(name, MCPStateDisabled, nil, nil, 0)
+			slog.Debug("skipping disabled mcp", "name", name)
+			continue
+		}
+
+		s := newMCPSupervisor(ctx, name, m, permissions, cfg.WorkingDir())
+		mcpSupervisors.Set(name, s)
+		updateMCPState(name, MCPStateStarting, nil, nil, 0)
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					err := fmt.Errorf("panic: %v", r)
+					updateMCPState(name, MCPStateError, err, nil, 0)
+					slog.Error("panic in mcp client supervisor", "error", err, "name", name)
+				}
+			}()
+			func() {
+				defer wg.Done()
+				_, _ = s.connect(nil)
+			}()
+			s.run()
+		}()
+	}
+	wg.Wait()
+}
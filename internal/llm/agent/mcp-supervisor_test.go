@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/config"
+)
+
+func echoHandler(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText(req.GetString("text", "")), nil
+}
+
+// startSupervisedServer serves an MCP server over HTTP and connects a
+// supervisor to it. The returned flag makes the server unavailable.
+func startSupervisedServer(t *testing.T, name string, srv *server.MCPServer) (*mcpSupervisor, *atomic.Bool) {
+	t.Helper()
+
+	var down atomic.Bool
+	handler := server.NewStreamableHTTPServer(srv)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newMCPSupervisor(ctx, name, config.MCPConfig{Type: config.MCPHttp, URL: ts.URL}, nil, t.TempDir())
+	mcpSupervisors.Set(name, s)
+	t.Cleanup(func() {
+		cancel()
+		if c, ok := mcpClients.Get(name); ok {
+			_ = c.Close()
+		}
+		ts.Close()
+		mcpSupervisors.Del(name)
+		mcpClients.Del(name)
+		mcpTools.Del(name)
+		mcpStates.Del(name)
+	})
+
+	_, err := s.connect(nil)
+	require.NoError(t, err)
+	go s.run()
+	return s, &down
+}
+
+func mcpToolNames(name string) []string {
+	var names []string
+	for _, tool := range GetMCPTools() {
+		if strings.HasPrefix(tool.Name(), "mcp_"+name+"_") {
+			names = append(names, tool.Name())
+		}
+	}
+	return names
+}
+
+func TestMCPSupervisorToolsChanged(t *testing.T) {
+	// The client listens for notifications once connected.
+	listening := make(chan struct{})
+	var once sync.Once
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(context.Context, server.ClientSession) {
+		once.Do(func() { close(listening) })
+	})
+
+	srv := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true), server.WithHooks(hooks))
+	srv.AddTool(mcp.NewTool("echo", mcp.WithString("text")), echoHandler)
+	startSupervisedServer(t, "changing", srv)
+	select {
+	case <-listening:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the client to listen")
+	}
+
+	require.Equal(t, []string{"mcp_changing_echo"}, mcpToolNames("changing"))
+	state, _ := GetMCPState("changing")
+	require.Equal(t, MCPStateConnected, state.State)
+	require.Equal(t, 1, state.ToolCount)
+
+	srv.AddTool(mcp.NewTool("shout", mcp.WithString("text")), echoHandler)
+	require.Eventually(t, func() bool {
+		return len(mcpToolNames("changing")) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	state, _ = GetMCPState("changing")
+	require.Equal(t, 2, state.ToolCount)
+}
+
+func TestMCPSupervisorReconnects(t *testing.T) {
+	srv := server.NewMCPServer("test", "1.0.0")
+	srv.AddTool(mcp.NewTool("echo", mcp.WithString("text")), echoHandler)
+	s, down := startSupervisedServer(t, "flaky", srv)
+	first, _ := mcpClients.Get("flaky")
+
+	down.Store(true)
+	_, err := getOrRenewClient(context.Background(), "flaky")
+	require.Error(t, err)
+	require.Empty(t, mcpToolNames("flaky"))
+	state, _ := GetMCPState("flaky")
+	require.Equal(t, MCPStateError, state.State)
+
+	down.Store(false)
+	s.handleConnectionLost(errors.New("connection reset"))
+	require.Eventually(t, func() bool {
+		state, _ := GetMCPState("flaky")
+		return state.State == MCPStateConnected
+	}, 5*time.Second, 10*time.Millisecond)
+
+	c, ok := mcpClients.Get("flaky")
+	require.True(t, ok)
+	require.NotSame(t, first, c)
+	require.Equal(t, []string{"mcp_flaky_echo"}, mcpToolNames("flaky"))
+}
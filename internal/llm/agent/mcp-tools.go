@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"log/slog"
 	"maps"
-	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +14,7 @@ import (
 	"github.com/vikvang/zero/internal/config"
 	"github.com/vikvang/zero/internal/csync"
 	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/mcpoauth"
 	"github.com/vikvang/zero/internal/permission"
 	"github.com/vikvang/zero/internal/pubsub"
 	"github.com/vikvang/zero/internal/version"
@@ -53,6 +53,7 @@ type MCPEventType string
 
 const (
 	MCPEventStateChanged MCPEventType = "state_changed"
+	MCPEventToolsChanged MCPEventType = "tools_changed"
 )
 
 // MCPEvent represents an event in the MCP system
@@ -76,7 +77,7 @@ type MCPClientInfo struct {
 
 var (
 	mcpToolsOnce sync.Once
-	mcpTools     []tools.BaseTool
+	mcpTools     = csync.NewMap[string, []tools.BaseTool]()
 	mcpClients   = csync.NewMap[string, *client.Client]()
 	mcpStates    = csync.NewMap[string, MCPClientInfo]()
 	mcpBroker    = pubsub.NewBroker[MCPEvent]()
@@ -142,30 +143,20 @@ func runTool(ctx context.Context, name, toolName string, input string) (tools.To
 }
 
 func getOrRenewClient(ctx context.Context, name string) (*client.Client, error) {
-	c, ok := mcpClients.Get(name)
+	s, ok := mcpSupervisors.Get(name)
 	if !ok {
 		return nil, fmt.Errorf("mcp '%s' not available", name)
 	}
 
-	m := config.Get().MCP[name]
-	state, _ := mcpStates.Get(name)
-
-	pingCtx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
-	defer cancel()
-	err := c.Ping(pingCtx)
-	if err == nil {
-		return c, nil
-	}
-	updateMCPState(name, MCPStateError, err, nil, state.ToolCount)
-
-	c, err = createAndInitializeClient(ctx, name, m)
-	if err != nil {
-		return nil, err
+	c, ok := mcpClients.Get(name)
+	if ok {
+		err := s.ping(ctx, c)
+		if err == nil {
+			return c, nil
+		}
+		updateMCPState(name, MCPStateError, err, nil, 0)
 	}
-
-	updateMCPState(name, MCPStateConnected, nil, c, state.ToolCount)
-	mcpClients.Set(name, c)
-	return c, nil
+	return s.connect(c)
 }
 
 func (b *McpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolResponse, error) {
@@ -192,14 +183,10 @@ func (b *McpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolRes
 	return runTool(ctx, b.mcpName, b.tool.Name, params.Input)
 }
 
-func getTools(ctx context.Context, name string, permissions permission.Service, c *client.Client, workingDir string) []tools.BaseTool {
+func getTools(ctx context.Context, name string, permissions permission.Service, c *client.Client, workingDir string) ([]tools.BaseTool, error) {
 	result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
 	if err != nil {
-		slog.Error("error listing tools", "error", err)
-		updateMCPState(name, MCPStateError, err, nil, 0)
-		c.Close()
-		mcpClients.Del(name)
-		return nil
+		return nil, err
 	}
 	mcpTools := make([]tools.BaseTool, 0, len(result.Tools))
 	for _, tool := range result.Tools {
@@ -210,7 +197,7 @@ func getTools(ctx context.Context, name string, permissions permission.Service,
 			workingDir:  workingDir,
 		})
 	}
-	return mcpTools
+	return mcpTools, nil
 }
 
 // SubscribeMCPEvents returns a channel for MCP events
@@ -252,8 +239,11 @@ func updateMCPState(name string, state MCPState, err error, client *client.Clien
 	})
 }
 
-// CloseMCPClients closes all MCP clients. This should be called during application shutdown.
+// CloseMCPClients stops the supervisors and closes all MCP clients. This should be called during application shutdown.
 func CloseMCPClients() {
+	if mcpCancel != nil {
+		mcpCancel()
+	}
 	for c := range mcpClients.Seq() {
 		_ = c.Close()
 	}
@@ -270,74 +260,33 @@ var mcpInitRequest = mcp.InitializeRequest{
 	},
 }
 
-func doGetMCPTools(ctx context.Context, permissions permission.Service, cfg *config.Config) []tools.BaseTool {
-	var wg sync.WaitGroup
-	result := csync.NewSlice[tools.BaseTool]()
-
-	// Initialize states for all configured MCPs
-	for name, m := range cfg.MCP {
-		if m.Disabled {
-			updateMCPState(name, MCPStateDisabled, nil, nil, 0)
-			slog.Debug("skipping disabled mcp", "name", name)
-			continue
-		}
-
-		// Set initial starting state
-		updateMCPState(name, MCPStateStarting, nil, nil, 0)
-
-		wg.Add(1)
-		go func(name string, m config.MCPConfig) {
-			defer func() {
-				wg.Done()
-				if r := recover(); r != nil {
-					var err error
-					switch v := r.(type) {
-					case error:
-						err = v
-					case string:
-						err = fmt.Errorf("panic: %s", v)
-					default:
-						err = fmt.Errorf("panic: %v", v)
-					}
-					updateMCPState(name, MCPStateError, err, nil, 0)
-					slog.Error("panic in mcp client initialization", "error", err, "name", name)
-				}
-			}()
-
-			ctx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
-			defer cancel()
-			c, err := createAndInitializeClient(ctx, name, m)
-			if err != nil {
-				return
-			}
-			mcpClients.Set(name, c)
-
-			tools := getTools(ctx, name, permissions, c, cfg.WorkingDir())
-			updateMCPState(name, MCPStateConnected, nil, c, len(tools))
-			result.Append(tools...)
-		}(name, m)
-	}
-	wg.Wait()
-	return slices.Collect(result.Seq())
-}
-
 func createAndInitializeClient(ctx context.Context, name string, m config.MCPConfig) (*client.Client, error) {
-	c, err := createMcpClient(m)
+	c, err := createMcpClient(name, m)
 	if err != nil {
 		updateMCPState(name, MCPStateError, err, nil, 0)
 		slog.Error("error creating mcp client", "error", err, "name", name)
 		return nil, err
 	}
-	// Only call Start() for non-stdio clients, as stdio clients auto-start
+	if s, ok := mcpSupervisors.Get(name); ok {
+		c.OnNotification(s.handleNotification)
+		c.OnConnectionLost(s.handleConnectionLost)
+	}
+	// Only call Start() for non-stdio clients, as stdio clients auto-start.
+	// The transports keep listening to the server with the context, so it
+	// must outlive the initialization.
 	if m.Type != config.MCPStdio {
 		if err := c.Start(ctx); err != nil {
+			err = mcpAuthError(name, err)
 			updateMCPState(name, MCPStateError, err, nil, 0)
 			slog.Error("error starting mcp client", "error", err, "name", name)
 			_ = c.Close()
 			return nil, err
 		}
 	}
-	if _, err := c.Initialize(ctx, mcpInitRequest); err != nil {
+	initCtx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
+	defer cancel()
+	if _, err := c.Initialize(initCtx, mcpInitRequest); err != nil {
+		err = mcpAuthError(name, err)
 		updateMCPState(name, MCPStateError, err, nil, 0)
 		slog.Error("error initializing mcp client", "error", err, "name", name)
 		_ = c.Close()
@@ -348,12 +297,15 @@ func createAndInitializeClient(ctx context.Context, name string, m config.MCPCon
 	return c, nil
 }
 
-func createMcpClient(m config.MCPConfig) (*client.Client, error) {
+func createMcpClient(name string, m config.MCPConfig) (*client.Client, error) {
 	switch m.Type {
 	case config.MCPStdio:
 		if strings.TrimSpace(m.Command) == "" {
 			return nil, fmt.Errorf("mcp stdio config requires a non-empty 'command' field")
 		}
+		if m.Auth != "" {
+			return nil, fmt.Errorf("mcp stdio config does not support the '%s' auth mode", m.Auth)
+		}
 		return client.NewStdioMCPClientWithOptions(
 			m.Command,
 			m.ResolvedEnv(),
@@ -364,20 +316,37 @@ func createMcpClient(m config.MCPConfig) (*client.Client, error) {
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
-		return client.NewStreamableHttpClient(
-			m.URL,
+		opts := []transport.StreamableHTTPCOption{
 			transport.WithHTTPHeaders(m.ResolvedHeaders()),
 			transport.WithHTTPLogger(mcpLogger{}),
-		)
+			// Listen for the notifications of the server, like tool list
+			// changes.
+			transport.WithContinuousListening(),
+		}
+		if m.Auth == config.MCPAuthOAuth {
+			oauthCfg, err := mcpoauth.ClientConfig(mcpOAuthConfig(m), mcpOAuthStore(name, m))
+			if err != nil {
+				return nil, err
+			}
+			return client.NewOAuthStreamableHttpClient(m.URL, oauthCfg, opts...)
+		}
+		return client.NewStreamableHttpClient(m.URL, opts...)
 	case config.MCPSse:
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
-		return client.NewSSEMCPClient(
-			m.URL,
+		opts := []transport.ClientOption{
 			client.WithHeaders(m.ResolvedHeaders()),
 			transport.WithSSELogger(mcpLogger{}),
-		)
+		}
+		if m.Auth == config.MCPAuthOAuth {
+			oauthCfg, err := mcpoauth.ClientConfig(mcpOAuthConfig(m), mcpOAuthStore(name, m))
+			if err != nil {
+				return nil, err
+			}
+			return client.NewOAuthSSEClient(m.URL, oauthCfg, opts...)
+		}
+		return client.NewSSEMCPClient(m.URL, opts...)
 	default:
 		return nil, fmt.Errorf("unsupported mcp type: %s", m.Type)
 	}
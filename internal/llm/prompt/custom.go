@@ -0,0 +1,32 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vikvang/zero/internal/config"
+)
+
+// CustomPrompt builds the system prompt of a user-defined agent from its
+// prompt file, followed by the environment information and the context
+// files, like the coder prompt.
+func CustomPrompt(path string, contextFiles ...string) (string, error) {
+	path = expandPath(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.Get().WorkingDir(), path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read agent prompt: %w", err)
+	}
+
+	basePrompt := fmt.Sprintf("%s\n\n%s\n%s", strings.TrimSpace(string(content)), getEnvironmentInfo(), lspInformation())
+
+	contextContent := getContextFromPaths(config.Get().WorkingDir(), contextFiles)
+	if contextContent != "" {
+		return fmt.Sprintf("%s\n\n# Project-Specific Context\n Make sure to follow the instructions in the context below\n%s", basePrompt, contextContent), nil
+	}
+	return basePrompt, nil
+}
@@ -0,0 +1,431 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/google/uuid"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/csync"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/log"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/ollama"
+)
+
+type ollamaClient struct {
+	providerOptions providerClientOptions
+	client          *ollama.Client
+	// noTools records the models the server refused to pass tools to, so
+	// they are not sent again.
+	noTools *csync.Map[string, bool]
+}
+
+type OllamaClient ProviderClient
+
+func newOllamaClient(opts providerClientOptions) OllamaClient {
+	return &ollamaClient{
+		providerOptions: opts,
+		client:          createOllamaClient(opts),
+		noTools:         csync.NewMap[string, bool](),
+	}
+}
+
+func createOllamaClient(opts providerClientOptions) *ollama.Client {
+	baseURL := opts.baseURL
+	if resolved, err := config.Get().Resolve(opts.baseURL); err == nil {
+		baseURL = resolved
+	}
+
+	headers := make(map[string]string, len(opts.extraHeaders)+1)
+	// Ollama doesn't need a key, but servers behind a proxy might.
+	if opts.apiKey != "" {
+		headers["Authorization"] = "Bearer " + opts.apiKey
+	}
+	maps.Copy(headers, opts.extraHeaders)
+
+	var httpClient *http.Client
+	if config.Get().Options.Debug {
+		httpClient = log.NewHTTPClient()
+	}
+	return ollama.NewClient(baseURL, headers, httpClient)
+}
+
+func (o *ollamaClient) convertMessages(messages []message.Message) []ollama.Message {
+	systemMessage := o.providerOptions.systemMessage
+	if o.providerOptions.systemPromptPrefix != "" {
+		systemMessage = o.providerOptions.systemPromptPrefix + "\n" + systemMessage
+	}
+	ollamaMessages := []ollama.Message{{Role: "system", Content: systemMessage}}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			userMsg := ollama.Message{Role: "user", Content: msg.Content().String()}
+			for _, binaryContent := range msg.BinaryContent() {
+				userMsg.Images = append(userMsg.Images, binaryContent.Data)
+			}
+			ollamaMessages = append(ollamaMessages, userMsg)
+
+		case message.Assistant:
+			assistantMsg := ollama.Message{Role: "assistant", Content: msg.Content().String()}
+			for _, call := range msg.ToolCalls() {
+				assistantMsg.ToolCalls = append(assistantMsg.ToolCalls, ollama.ToolCall{
+					Function: ollama.ToolCallFunction{
+						Name:      call.Name,
+						Arguments: json.RawMessage(normalizeToolArguments(json.RawMessage(call.Input))),
+					},
+				})
+			}
+			ollamaMessages = append(ollamaMessages, assistantMsg)
+
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				ollamaMessages = append(ollamaMessages, ollama.Message{
+					Role:     "tool",
+					Content:  result.Content,
+					ToolName: result.Name,
+				})
+			}
+		}
+	}
+	return ollamaMessages
+}
+
+func (o *ollamaClient) convertTools(tools []tools.BaseTool) []ollama.Tool {
+	ollamaTools := make([]ollama.Tool, len(tools))
+	for i, tool := range tools {
+		info := tool.Info()
+		ollamaTools[i] = ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        info.Name,
+				Description: info.Description,
+				Parameters: map[string]any{
+					"type":       "object",
+					"properties": info.Parameters,
+					"required":   info.Required,
+				},
+			},
+		}
+	}
+	return ollamaTools
+}
+
+func (o *ollamaClient) preparedRequest(messages []message.Message, tools []tools.BaseTool, stream bool) ollama.ChatRequest {
+	model := o.Model()
+	modelConfig := o.providerOptions.modelConfig()
+
+	maxTokens := model.DefaultMaxTokens
+	if modelConfig.MaxTokens > 0 {
+		maxTokens = modelConfig.MaxTokens
+	}
+	// Override max tokens if set in provider options
+	if o.providerOptions.maxTokens > 0 {
+		maxTokens = o.providerOptions.maxTokens
+	}
+
+	options := map[string]any{}
+	if extra, ok := o.providerOptions.extraBody["options"].(map[string]any); ok {
+		maps.Copy(options, extra)
+	}
+	if maxTokens > 0 {
+		options["num_predict"] = maxTokens
+	}
+	// Without num_ctx the server runs the model with its small default
+	// context and silently drops the start of long conversations.
+	if model.ContextWindow > 0 {
+		options["num_ctx"] = model.ContextWindow
+	}
+
+	req := ollama.ChatRequest{
+		Model:    model.ID,
+		Messages: o.convertMessages(messages),
+		Stream:   stream,
+		Options:  options,
+	}
+	if noTools, _ := o.noTools.Get(model.ID); !noTools {
+		req.Tools = o.convertTools(tools)
+	}
+	if model.CanReason {
+		think := modelConfig.Think
+		req.Think = &think
+	}
+	return req
+}
+
+// chat sends the request, retrying without tools if the model can't use
+// them and while the server is busy.
+func (o *ollamaClient) chat(ctx context.Context, req ollama.ChatRequest, fn func(ollama.ChatResponse) error) error {
+	attempts := 0
+	for {
+		attempts++
+		err := o.client.Chat(ctx, req, fn)
+		if err == nil {
+			return nil
+		}
+		if len(req.Tools) > 0 && ollama.IsToolsUnsupported(err) {
+			slog.Warn("Model does not support tools, continuing without them", "model", req.Model)
+			o.noTools.Set(req.Model, true)
+			req.Tools = nil
+			continue
+		}
+
+		var statusErr *ollama.StatusError
+		if !errors.As(err, &statusErr) ||
+			(statusErr.StatusCode != http.StatusTooManyRequests && statusErr.StatusCode != http.StatusServiceUnavailable) {
+			return err
+		}
+		if attempts > maxRetries {
+			return fmt.Errorf("%w for busy server: %d retries", ErrMaxRetries, maxRetries)
+		}
+		slog.Warn("Retrying due to busy Ollama server", "attempt", attempts, "max_retries", maxRetries, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(2000*(1<<(attempts-1))) * time.Millisecond):
+		}
+	}
+}
+
+func (o *ollamaClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	req := o.preparedRequest(messages, tools, false)
+	turn := newOllamaTurn(req.Tools, nil)
+	if err := o.chat(ctx, req, turn.add); err != nil {
+		return nil, err
+	}
+	return turn.finish(), nil
+}
+
+func (o *ollamaClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	req := o.preparedRequest(messages, tools, true)
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		turn := newOllamaTurn(req.Tools, func(event ProviderEvent) {
+			eventChan <- event
+		})
+		if err := o.chat(ctx, req, turn.add); err != nil {
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		eventChan <- ProviderEvent{Type: EventComplete, Response: turn.finish()}
+	}()
+
+	return eventChan
+}
+
+func (o *ollamaClient) Model() catwalk.Model {
+	return o.providerOptions.model(o.providerOptions.modelType)
+}
+
+// ollamaTurn accumulates the responses of a chat request.
+type ollamaTurn struct {
+	toolNames []string
+	emit      func(ProviderEvent)
+
+	content   strings.Builder
+	toolCalls []message.ToolCall
+	last      ollama.ChatResponse
+	// held is the start of the content, kept from the stream while it might
+	// be a tool call the model wrote as text.
+	held    strings.Builder
+	holding bool
+}
+
+func newOllamaTurn(tools []ollama.Tool, emit func(ProviderEvent)) *ollamaTurn {
+	turn := &ollamaTurn{
+		emit:    emit,
+		holding: emit != nil && len(tools) > 0,
+	}
+	for _, tool := range tools {
+		turn.toolNames = append(turn.toolNames, tool.Function.Name)
+	}
+	if turn.emit == nil {
+		turn.emit = func(ProviderEvent) {}
+	}
+	return turn
+}
+
+func (t *ollamaTurn) add(resp ollama.ChatResponse) error {
+	if resp.Message.Thinking != "" {
+		t.emit(ProviderEvent{Type: EventThinkingDelta, Thinking: resp.Message.Thinking})
+	}
+	if resp.Message.Content != "" {
+		t.content.WriteString(resp.Message.Content)
+		t.addContent(resp.Message.Content)
+	}
+	for _, call := range resp.Message.ToolCalls {
+		toolCall := message.ToolCall{
+			ID:       "call_" + uuid.NewString(),
+			Name:     call.Function.Name,
+			Input:    normalizeToolArguments(call.Function.Arguments),
+			Type:     "function",
+			Finished: true,
+		}
+		t.emit(ProviderEvent{
+			Type:     EventToolUseStart,
+			ToolCall: &message.ToolCall{ID: toolCall.ID, Name: toolCall.Name},
+		})
+		t.toolCalls = append(t.toolCalls, toolCall)
+	}
+	if resp.Done {
+		t.last = resp
+	}
+	return nil
+}
+
+func (t *ollamaTurn) addContent(delta string) {
+	if !t.holding {
+		t.emit(ProviderEvent{Type: EventContentDelta, Content: delta})
+		return
+	}
+	t.held.WriteString(delta)
+	if mightBeTextToolCall(t.held.String()) {
+		return
+	}
+	t.holding = false
+	t.emit(ProviderEvent{Type: EventContentDelta, Content: t.held.String()})
+	t.held.Reset()
+}
+
+func (t *ollamaTurn) finish() *ProviderResponse {
+	content := t.content.String()
+	// Some models write tool calls as text instead of using the tool call
+	// format of their template.
+	if len(t.toolCalls) == 0 && len(t.toolNames) > 0 {
+		if toolCalls, rest := parseTextToolCalls(content, t.toolNames); len(toolCalls) > 0 {
+			for _, toolCall := range toolCalls {
+				t.emit(ProviderEvent{
+					Type:     EventToolUseStart,
+					ToolCall: &message.ToolCall{ID: toolCall.ID, Name: toolCall.Name},
+				})
+			}
+			t.toolCalls = toolCalls
+			content = rest
+			t.held.Reset()
+			t.held.WriteString(rest)
+		}
+	}
+	if t.holding && strings.TrimSpace(t.held.String()) != "" {
+		t.emit(ProviderEvent{Type: EventContentDelta, Content: t.held.String()})
+	}
+
+	finishReason := message.FinishReasonUnknown
+	switch t.last.DoneReason {
+	case "stop", "":
+		finishReason = message.FinishReasonEndTurn
+	case "length":
+		finishReason = message.FinishReasonMaxTokens
+	}
+	if len(t.toolCalls) > 0 {
+		finishReason = message.FinishReasonToolUse
+	}
+
+	return &ProviderResponse{
+		Content:   content,
+		ToolCalls: t.toolCalls,
+		Usage: TokenUsage{
+			InputTokens:  t.last.PromptEvalCount,
+			OutputTokens: t.last.EvalCount,
+		},
+		FinishReason: finishReason,
+	}
+}
+
+// normalizeToolArguments returns the arguments of a tool call as a JSON
+// object, decoding arguments that were encoded as a string and replacing
+// missing or invalid ones with an empty object.
+func normalizeToolArguments(arguments json.RawMessage) string {
+	var encoded string
+	if json.Unmarshal(arguments, &encoded) == nil {
+		arguments = json.RawMessage(encoded)
+	}
+	var object map[string]any
+	if json.Unmarshal(arguments, &object) != nil || object == nil {
+		return "{}"
+	}
+	return string(arguments)
+}
+
+const toolCallTag = "<tool_call>"
+
+var toolCallTagRe = regexp.MustCompile(`(?s)<tool_call>(.*?)(?:</tool_call>|$)`)
+
+// mightBeTextToolCall reports whether content is, or is the start of, a tool
+// call written as text.
+func mightBeTextToolCall(content string) bool {
+	content = strings.TrimSpace(content)
+	return content == "" ||
+		strings.HasPrefix(content, "{") ||
+		strings.HasPrefix(content, "[") ||
+		strings.HasPrefix(content, toolCallTag) ||
+		strings.HasPrefix(toolCallTag, content)
+}
+
+// parseTextToolCalls extracts the tool calls written as text in content,
+// either in <tool_call> tags or as the whole content, returning them with the
+// rest of the content. Only calls of known tools are extracted.
+func parseTextToolCalls(content string, toolNames []string) ([]message.ToolCall, string) {
+	type textToolCall struct {
+		Name       string          `json:"name"`
+		Arguments  json.RawMessage `json:"arguments"`
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	parse := func(text string) []message.ToolCall {
+		text = strings.TrimSpace(text)
+		var calls []textToolCall
+		if err := json.Unmarshal([]byte(text), &calls); err != nil {
+			var call textToolCall
+			if err := json.Unmarshal([]byte(text), &call); err != nil {
+				return nil
+			}
+			calls = []textToolCall{call}
+		}
+		var toolCalls []message.ToolCall
+		for _, call := range calls {
+			if !slices.Contains(toolNames, call.Name) {
+				return nil
+			}
+			arguments := call.Arguments
+			if len(arguments) == 0 {
+				arguments = call.Parameters
+			}
+			toolCalls = append(toolCalls, message.ToolCall{
+				ID:       "call_" + uuid.NewString(),
+				Name:     call.Name,
+				Input:    normalizeToolArguments(arguments),
+				Type:     "function",
+				Finished: true,
+			})
+		}
+		return toolCalls
+	}
+
+	if strings.Contains(content, toolCallTag) {
+		var toolCalls []message.ToolCall
+		for _, match := range toolCallTagRe.FindAllStringSubmatch(content, -1) {
+			calls := parse(match[1])
+			if len(calls) == 0 {
+				return nil, content
+			}
+			toolCalls = append(toolCalls, calls...)
+		}
+		return toolCalls, strings.TrimSpace(toolCallTagRe.ReplaceAllString(content, ""))
+	}
+	if toolCalls := parse(content); len(toolCalls) > 0 {
+		return toolCalls, ""
+	}
+	return nil, content
+}
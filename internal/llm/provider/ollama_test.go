@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/config"
+	"github.com/vikvang/zero/internal/csync"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/message"
+	"github.com/vikvang/zero/internal/ollama"
+)
+
+type ollamaTestTool struct{ name string }
+
+func (t ollamaTestTool) Name() string { return t.name }
+
+func (t ollamaTestTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{Name: t.name, Parameters: map[string]any{}}
+}
+
+func (t ollamaTestTool) Run(context.Context, tools.ToolCall) (tools.ToolResponse, error) {
+	return tools.ToolResponse{}, nil
+}
+
+func newTestOllamaClient(url string, model catwalk.Model) *ollamaClient {
+	return &ollamaClient{
+		providerOptions: providerClientOptions{
+			modelType:     config.SelectedModelTypeLarge,
+			systemMessage: "test",
+			model: func(config.SelectedModelType) catwalk.Model {
+				return model
+			},
+		},
+		client:  ollama.NewClient(url, nil, nil),
+		noTools: csync.NewMap[string, bool](),
+	}
+}
+
+func collectEvents(t *testing.T, events <-chan ProviderEvent) []ProviderEvent {
+	t.Helper()
+	var collected []ProviderEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return collected
+			}
+			collected = append(collected, event)
+		case <-timeout:
+			t.Fatal("timed out waiting for events")
+		}
+	}
+}
+
+func TestOllamaClientStream(t *testing.T) {
+	var request ollama.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "Let me look."}, "done": false}`)
+		// Some models encode the arguments as a string.
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "view", "arguments": "{\"file_path\": \"main.go\"}"}}, {"function": {"name": "ls"}}]}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 20, "eval_count": 5}`)
+	}))
+	defer server.Close()
+
+	client := newTestOllamaClient(server.URL, catwalk.Model{ID: "qwen3:30b", ContextWindow: 40960, DefaultMaxTokens: 4096})
+	messages := []message.Message{{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "What is in main.go?"}},
+	}}
+	events := collectEvents(t, client.stream(context.Background(), messages, []tools.BaseTool{ollamaTestTool{"view"}, ollamaTestTool{"ls"}}))
+
+	require.Equal(t, "qwen3:30b", request.Model)
+	require.Equal(t, float64(40960), request.Options["num_ctx"])
+	require.Len(t, request.Tools, 2)
+	require.Equal(t, "system", request.Messages[0].Role)
+
+	require.Len(t, events, 4)
+	require.Equal(t, EventContentDelta, events[0].Type)
+	require.Equal(t, EventToolUseStart, events[1].Type)
+	require.Equal(t, EventToolUseStart, events[2].Type)
+	require.Equal(t, EventComplete, events[3].Type)
+
+	response := events[3].Response
+	require.Equal(t, "Let me look.", response.Content)
+	require.Equal(t, message.FinishReasonToolUse, response.FinishReason)
+	require.Equal(t, TokenUsage{InputTokens: 20, OutputTokens: 5}, response.Usage)
+	require.Len(t, response.ToolCalls, 2)
+	require.Equal(t, "view", response.ToolCalls[0].Name)
+	require.JSONEq(t, `{"file_path": "main.go"}`, response.ToolCalls[0].Input)
+	require.Equal(t, "{}", response.ToolCalls[1].Input)
+	require.NotEqual(t, response.ToolCalls[0].ID, response.ToolCalls[1].ID)
+}
+
+func TestOllamaClientToolsUnsupported(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var request ollama.ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if len(request.Tools) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "registry.ollama.ai/library/gemma:2b does not support tools"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "Hello"}, "done": true, "done_reason": "stop"}`)
+	}))
+	defer server.Close()
+
+	client := newTestOllamaClient(server.URL, catwalk.Model{ID: "gemma:2b"})
+	messages := []message.Message{{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "Hi"}},
+	}}
+	toolList := []tools.BaseTool{ollamaTestTool{"view"}}
+
+	response, err := client.send(context.Background(), messages, toolList)
+	require.NoError(t, err)
+	require.Equal(t, "Hello", response.Content)
+	require.Equal(t, int32(2), requests.Load())
+
+	// The tools are not sent again for the model.
+	_, err = client.send(context.Background(), messages, toolList)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), requests.Load())
+}
+
+func TestOllamaTextToolCalls(t *testing.T) {
+	t.Parallel()
+
+	turn := newOllamaTurn([]ollama.Tool{{Function: ollama.ToolFunction{Name: "bash"}}}, nil)
+	var events []ProviderEvent
+	turn.emit = func(event ProviderEvent) { events = append(events, event) }
+	turn.holding = true
+
+	for _, delta := range []string{"<tool", "_call>\n{\"name\": \"bash\", ", "\"arguments\": {\"command\": \"ls\"}}\n</tool_call>"} {
+		require.NoError(t, turn.add(ollama.ChatResponse{Message: ollama.Message{Content: delta}}))
+	}
+	require.Empty(t, events)
+	require.NoError(t, turn.add(ollama.ChatResponse{Done: true, DoneReason: "stop"}))
+
+	response := turn.finish()
+	require.Empty(t, response.Content)
+	require.Equal(t, message.FinishReasonToolUse, response.FinishReason)
+	require.Len(t, response.ToolCalls, 1)
+	require.Equal(t, "bash", response.ToolCalls[0].Name)
+	require.JSONEq(t, `{"command": "ls"}`, response.ToolCalls[0].Input)
+	require.Len(t, events, 1)
+	require.Equal(t, EventToolUseStart, events[0].Type)
+}
+
+func TestParseTextToolCalls(t *testing.T) {
+	t.Parallel()
+
+	toolNames := []string{"view", "bash"}
+	tests := []struct {
+		name    string
+		content string
+		calls   []string
+		rest    string
+	}{
+		{"bare object", `{"name": "view", "arguments": {"file_path": "a.go"}}`, []string{"view"}, ""},
+		{"parameters", `{"name": "bash", "parameters": {"command": "ls"}}`, []string{"bash"}, ""},
+		{"array", `[{"name": "view", "arguments": {}}, {"name": "bash", "arguments": {}}]`, []string{"view", "bash"}, ""},
+		{"tags", "Reading it.\n<tool_call>{\"name\": \"view\", \"arguments\": {}}</tool_call>", []string{"view"}, "Reading it."},
+		{"unknown tool", `{"name": "rm", "arguments": {}}`, nil, `{"name": "rm", "arguments": {}}`},
+		{"plain json answer", `{"result": 42}`, nil, `{"result": 42}`},
+		{"text", "All done.", nil, "All done."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls, rest := parseTextToolCalls(tt.content, toolNames)
+			var names []string
+			for _, call := range calls {
+				names = append(names, call.Name)
+			}
+			require.Equal(t, tt.calls, names)
+			require.Equal(t, tt.rest, rest)
+		})
+	}
+}
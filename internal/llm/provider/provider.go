@@ -2,9 +2,14 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/openai/openai-go"
+	"google.golang.org/genai"
 
 	"github.com/vikvang/zero/internal/config"
 	"github.com/vikvang/zero/internal/llm/tools"
@@ -15,6 +20,9 @@ type EventType string
 
 const maxRetries = 8
 
+// ErrMaxRetries is returned when a request still fails after maxRetries.
+var ErrMaxRetries = errors.New("maximum retry attempts reached")
+
 const (
 	EventContentStart   EventType = "content_start"
 	EventToolUseStart   EventType = "tool_use_start"
@@ -67,6 +75,7 @@ type providerClientOptions struct {
 	apiKey             string
 	modelType          config.SelectedModelType
 	model              func(config.SelectedModelType) catwalk.Model
+	selectedModel      *config.SelectedModel
 	disableCache       bool
 	systemMessage      string
 	systemPromptPrefix string
@@ -96,11 +105,81 @@ func (p *baseProvider[C]) cleanMessages(messages []message.Message) (cleaned []m
 		if len(msg.Parts) == 0 {
 			continue
 		}
-		cleaned = append(cleaned, msg)
+		cleaned = append(cleaned, inlineTextAttachments(msg))
 	}
 	return
 }
 
+// inlineTextAttachments moves the text attachments of a user message, like
+// MCP resources, into its text, as providers only take images as attachments.
+func inlineTextAttachments(msg message.Message) message.Message {
+	if msg.Role != message.User {
+		return msg
+	}
+	var attached strings.Builder
+	parts := make([]message.ContentPart, 0, len(msg.Parts))
+	for _, part := range msg.Parts {
+		if binary, ok := part.(message.BinaryContent); ok && binary.IsText() {
+			fmt.Fprintf(&attached, "\n\n<attachment path=%q mime_type=%q>\n%s\n</attachment>", binary.Path, binary.MIMEType, binary.Data)
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if attached.Len() == 0 {
+		return msg
+	}
+
+	text := -1
+	for i, part := range parts {
+		if _, ok := part.(message.TextContent); ok {
+			text = i
+			break
+		}
+	}
+	if text == -1 {
+		parts = append([]message.ContentPart{message.TextContent{}}, parts...)
+		text = 0
+	}
+	parts[text] = message.TextContent{Text: parts[text].(message.TextContent).Text + attached.String()}
+	msg.Parts = parts
+	return msg
+}
+
+// modelConfig returns the configuration of the model of the client, the one
+// selected for its model type unless another one was given.
+func (o providerClientOptions) modelConfig() config.SelectedModel {
+	if o.selectedModel != nil {
+		return *o.selectedModel
+	}
+	cfg := config.Get()
+	if o.modelType == config.SelectedModelTypeSmall {
+		return cfg.Models[config.SelectedModelTypeSmall]
+	}
+	return cfg.Models[config.SelectedModelTypeLarge]
+}
+
+// ShouldFallback reports whether the request failed because the provider is
+// overloaded or erroring, rather than because of the request itself, so that
+// another provider may succeed.
+func ShouldFallback(err error) bool {
+	if errors.Is(err, ErrMaxRetries) {
+		return true
+	}
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.StatusCode >= 500
+	}
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return openaiErr.StatusCode >= 500
+	}
+	var geminiErr genai.APIError
+	if errors.As(err, &geminiErr) {
+		return geminiErr.Code >= 500
+	}
+	return false
+}
+
 func (p *baseProvider[C]) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
 	messages = p.cleanMessages(messages)
 	return p.client.send(ctx, messages, tools)
@@ -139,6 +218,17 @@ func WithMaxTokens(maxTokens int64) ProviderClientOption {
 	}
 }
 
+// WithSelectedModel makes the client use the given model instead of the one
+// selected for its model type, e.g. for a fallback model.
+func WithSelectedModel(model config.SelectedModel) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.selectedModel = &model
+		options.model = func(config.SelectedModelType) catwalk.Model {
+			return *config.Get().GetModel(model.Provider, model.Model)
+		}
+	}
+}
+
 func NewProvider(cfg config.ProviderConfig, opts ...ProviderClientOption) (Provider, error) {
 	restore := config.PushPopCrushEnv()
 	defer restore()
@@ -203,6 +293,11 @@ func NewProvider(cfg config.ProviderConfig, opts ...ProviderClientOption) (Provi
 			options: clientOptions,
 			client:  newVertexAIClient(clientOptions),
 		}, nil
+	case config.TypeOllama:
+		return &baseProvider[OllamaClient]{
+			options: clientOptions,
+			client:  newOllamaClient(clientOptions),
+		}, nil
 	}
 	return nil, fmt.Errorf("provider not supported: %s", cfg.Type)
 }
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/message"
+	"google.golang.org/genai"
+)
+
+func TestInlineTextAttachments(t *testing.T) {
+	t.Parallel()
+
+	image := message.BinaryContent{Path: "screenshot.png", MIMEType: "image/png", Data: []byte{0x89}}
+	msg := message.Message{
+		Role: message.User,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: "Fix the bug of @tickets:tickets://ENG-12"},
+			message.BinaryContent{Path: "tickets://ENG-12", MIMEType: "text/markdown", Data: []byte("# Crash on start")},
+			image,
+		},
+	}
+
+	inlined := inlineTextAttachments(msg)
+	require.Equal(t, []message.ContentPart{
+		message.TextContent{Text: "Fix the bug of @tickets:tickets://ENG-12\n\n<attachment path=\"tickets://ENG-12\" mime_type=\"text/markdown\">\n# Crash on start\n</attachment>"},
+		image,
+	}, inlined.Parts)
+	require.Len(t, msg.Parts, 3, "the original message is left untouched")
+
+	assistant := message.Message{Role: message.Assistant, Parts: msg.Parts}
+	require.Equal(t, assistant, inlineTextAttachments(assistant))
+}
+
+func TestShouldFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"retries exhausted", fmt.Errorf("%w for rate limit: %d retries", ErrMaxRetries, maxRetries), true},
+		{"anthropic server error", &anthropic.Error{StatusCode: 500}, true},
+		{"anthropic bad request", &anthropic.Error{StatusCode: 400}, false},
+		{"openai bad gateway", fmt.Errorf("stream: %w", &openai.Error{StatusCode: 502}), true},
+		{"openai unauthorized", &openai.Error{StatusCode: 401}, false},
+		{"gemini unavailable", genai.APIError{Code: 503}, true},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("invalid tool call"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, ShouldFallback(tt.err))
+		})
+	}
+}
@@ -5,20 +5,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/vikvang/zero/internal/permission"
+	"github.com/vikvang/zero/internal/pubsub"
 	"github.com/vikvang/zero/internal/shell"
 )
 
 type BashParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background"`
 }
 
 type BashPermissionsParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background,omitempty"`
+	// Programs and Writes are what the command runs and the files it
+	// redirects output to, so that reviewers see everything it does.
+	Programs []string `json:"programs,omitempty"`
+	Writes   []string `json:"writes,omitempty"`
 }
 
 type BashResponseMetadata struct {
@@ -26,12 +34,100 @@ type BashResponseMetadata struct {
 	EndTime          int64  `json:"end_time"`
 	Output           string `json:"output"`
 	WorkingDirectory string `json:"working_directory"`
+	// JobID is the ID of the job of commands run in the background.
+	JobID string `json:"job_id,omitempty"`
 }
+
+// BashOutput is a chunk of the output of a running bash command.
+type BashOutput struct {
+	SessionID  string
+	ToolCallID string
+	Output     string
+}
+
 type bashTool struct {
 	permissions permission.Service
 	workingDir  string
 }
 
+// bashCall is the tool call of a running command, for asking about its
+// writes outside the sandbox.
+type bashCall struct {
+	id      string
+	command string
+}
+
+type bashCallContextKey struct{}
+
+var bashOutputBroker = pubsub.NewBroker[BashOutput]()
+
+// SubscribeBashOutput returns a channel for the output of running bash
+// commands, as it is produced.
+func SubscribeBashOutput(ctx context.Context) <-chan pubsub.Event[BashOutput] {
+	return bashOutputBroker.Subscribe(ctx)
+}
+
+const (
+	// bashOutputFlushInterval is how long the output of a running command is
+	// collected before it is published, so that commands writing a lot of
+	// small chunks don't flood the events of the app.
+	bashOutputFlushInterval = 100 * time.Millisecond
+	// bashOutputFlushSize is how much output is published right away.
+	bashOutputFlushSize = 16 * 1024
+)
+
+// bashOutputWriter publishes the output of the command of a tool call.
+// Output is buffered and published every bashOutputFlushInterval, or once
+// there is bashOutputFlushSize of it.
+type bashOutputWriter struct {
+	sessionID  string
+	toolCallID string
+
+	mu    sync.Mutex
+	buf   []byte
+	timer *time.Timer
+}
+
+func newBashOutputWriter(sessionID, toolCallID string) *bashOutputWriter {
+	return &bashOutputWriter{sessionID: sessionID, toolCallID: toolCallID}
+}
+
+func (w *bashOutputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	switch {
+	case len(w.buf) >= bashOutputFlushSize:
+		w.flushLocked()
+	case w.timer == nil:
+		w.timer = time.AfterFunc(bashOutputFlushInterval, w.Flush)
+	}
+	return len(p), nil
+}
+
+// Flush publishes the buffered output.
+func (w *bashOutputWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flushLocked()
+}
+
+func (w *bashOutputWriter) flushLocked() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.buf) == 0 {
+		return
+	}
+	bashOutputBroker.Publish(pubsub.CreatedEvent, BashOutput{
+		SessionID:  w.sessionID,
+		ToolCallID: w.toolCallID,
+		Output:     string(w.buf),
+	})
+	w.buf = w.buf[:0]
+}
+
 const (
 	BashToolName = "bash"
 
@@ -156,6 +252,7 @@ Usage notes:
 - When issuing multiple commands, use the ';' or '&&' operator to separate them. DO NOT use newlines (newlines are ok in quoted strings).
 - IMPORTANT: All commands share the same shell session. Shell state (environment variables, virtual environments, current directory, etc.) persist between commands. For example, if you set an environment variable as part of a command, the environment variable will persist for subsequent commands.
 - Try to maintain your current working directory throughout the session by using absolute paths and avoiding usage of 'cd'. You may use 'cd' if the User explicitly requests it.
+- Set run_in_background to start long-running commands, like dev servers and file watchers, without waiting for them to finish. You get a job ID right away: read the output of the job with the job_output tool, which can also wait for it to finish, and stop it with the job_kill tool when it's no longer needed. Background jobs start in the current directory and environment of the shell but don't change them, and they have no timeout.
 <good-example>
 pytest /foo/bar/tests
 </good-example>
@@ -305,14 +402,41 @@ func blockFuncs() []shell.BlockFunc {
 }
 
 func NewBashTool(permission permission.Service, workingDir string) BaseTool {
-	// Set up command blocking on the persistent shell
+	tool := &bashTool{
+		permissions: permission,
+		workingDir:  workingDir,
+	}
+
+	// Set up command blocking and the sandbox on the persistent shell
 	persistentShell := shell.GetPersistentShell(workingDir)
 	persistentShell.SetBlockFuncs(blockFuncs())
+	persistentShell.SetSandbox(permission.Sandbox(), tool.askWrite)
 
-	return &bashTool{
-		permissions: permission,
-		workingDir:  workingDir,
+	return tool
+}
+
+// askWrite asks the user whether the command of the tool call in the context
+// may write the file outside the sandbox.
+func (b *bashTool) askWrite(ctx context.Context, path string) bool {
+	sessionID, _ := GetContextValues(ctx)
+	call, ok := ctx.Value(bashCallContextKey{}).(bashCall)
+	if sessionID == "" || !ok {
+		return false
 	}
+	return b.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        path,
+			ToolCallID:  call.id,
+			ToolName:    BashToolName,
+			Action:      "write",
+			Description: fmt.Sprintf("Write %s outside the sandbox", path),
+			Params: BashPermissionsParams{
+				Command: call.command,
+				Writes:  []string{path},
+			},
+		},
+	)
 }
 
 func (b *bashTool) Name() string {
@@ -332,6 +456,10 @@ func (b *bashTool) Info() ToolInfo {
 				"type":        "number",
 				"description": "Optional timeout in milliseconds (max 600000)",
 			},
+			"run_in_background": map[string]any{
+				"type":        "boolean",
+				"description": "Run the command in the background and return a job ID without waiting for it to finish",
+			},
 		},
 		Required: []string{"command"},
 	}
@@ -353,39 +481,38 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("missing command"), nil
 	}
 
-	isSafeReadOnly := false
-	cmdLower := strings.ToLower(params.Command)
-
-	for _, safe := range safeCommands {
-		if strings.HasPrefix(cmdLower, safe) {
-			if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
-				isSafeReadOnly = true
-				break
-			}
-		}
-	}
-
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for executing shell command")
 	}
-	if !isSafeReadOnly {
-		p := b.permissions.Request(
-			permission.CreatePermissionRequest{
-				SessionID:   sessionID,
-				Path:        b.workingDir,
-				ToolCallID:  call.ID,
-				ToolName:    BashToolName,
-				Action:      "execute",
-				Description: fmt.Sprintf("Execute command: %s", params.Command),
-				Params: BashPermissionsParams{
-					Command: params.Command,
-				},
-			},
-		)
-		if !p {
-			return ToolResponse{}, permission.ErrorPermissionDenied
-		}
+	permissionParams := BashPermissionsParams{
+		Command:         params.Command,
+		RunInBackground: params.RunInBackground,
+	}
+	if parsed, err := permission.ParseCommand(params.Command); err == nil {
+		permissionParams.Programs = parsed.Texts()
+		permissionParams.Writes = parsed.Writes
+	}
+	description := fmt.Sprintf("Execute command: %s", params.Command)
+	if params.RunInBackground {
+		description = fmt.Sprintf("Execute command in the background: %s", params.Command)
+	}
+	p := b.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        b.workingDir,
+			ToolCallID:  call.ID,
+			ToolName:    BashToolName,
+			Action:      "execute",
+			Description: description,
+			Params:      permissionParams,
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+	if params.RunInBackground {
+		return b.runInBackground(sessionID, params.Command)
 	}
 	startTime := time.Now()
 	if params.Timeout > 0 {
@@ -394,8 +521,11 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		defer cancel()
 	}
 
+	ctx = context.WithValue(ctx, bashCallContextKey{}, bashCall{id: call.ID, command: params.Command})
 	persistentShell := shell.GetPersistentShell(b.workingDir)
-	stdout, stderr, err := persistentShell.Exec(ctx, params.Command)
+	output := newBashOutputWriter(sessionID, call.ID)
+	stdout, stderr, err := persistentShell.ExecStream(ctx, params.Command, output)
+	output.Flush()
 
 	// Get the current working directory after command execution
 	currentWorkingDir := persistentShell.GetWorkingDir()
@@ -448,6 +578,24 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	return WithResponseMetadata(NewTextResponse(stdout), metadata), nil
 }
 
+// runInBackground starts the command as a background job of the session.
+func (b *bashTool) runInBackground(sessionID, command string) (ToolResponse, error) {
+	startTime := time.Now()
+	persistentShell := shell.GetPersistentShell(b.workingDir)
+	job, err := persistentShell.StartJob(sessionID, command)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	metadata := BashResponseMetadata{
+		StartTime:        startTime.UnixMilli(),
+		EndTime:          time.Now().UnixMilli(),
+		WorkingDirectory: persistentShell.GetWorkingDir(),
+		JobID:            job.ID,
+	}
+	content := fmt.Sprintf("Started job %s in the background. Use the %s tool to read its output and the %s tool to stop it.", job.ID, JobOutputToolName, JobKillToolName)
+	return WithResponseMetadata(NewTextResponse(content), metadata), nil
+}
+
 func truncateOutput(content string) string {
 	if len(content) <= MaxOutputLength {
 		return content
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBashOutputWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := SubscribeBashOutput(ctx)
+
+	w := newBashOutputWriter("session", "call")
+	for range 1000 {
+		_, err := w.Write([]byte("x"))
+		require.NoError(t, err)
+	}
+
+	select {
+	case event := <-events:
+		require.Equal(t, "call", event.Payload.ToolCallID)
+		require.Equal(t, strings.Repeat("x", 1000), event.Payload.Output, "small writes are published together")
+	case <-time.After(time.Second):
+		t.Fatal("buffered output was not published")
+	}
+
+	_, err := w.Write([]byte(strings.Repeat("y", bashOutputFlushSize)))
+	require.NoError(t, err)
+	select {
+	case event := <-events:
+		require.Len(t, event.Payload.Output, bashOutputFlushSize, "large writes are published right away")
+	default:
+		t.Fatal("large output was not published right away")
+	}
+
+	w.Flush()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event with %q", event.Payload.Output)
+	case <-time.After(2 * bashOutputFlushInterval):
+	}
+}
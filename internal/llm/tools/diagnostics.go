@@ -55,6 +55,10 @@ func (b *diagnosticsTool) Name() string {
 	return DiagnosticsToolName
 }
 
+func (b *diagnosticsTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
 func (b *diagnosticsTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        DiagnosticsToolName,
@@ -131,6 +131,9 @@ func (t *downloadTool) Run(ctx context.Context, call ToolCall) (ToolResponse, er
 	} else {
 		filePath = filepath.Join(t.workingDir, params.FilePath)
 	}
+	if err := checkSandbox(t.permissions, filePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
@@ -156,6 +156,9 @@ func (e *editTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	if !filepath.IsAbs(params.FilePath) {
 		params.FilePath = filepath.Join(e.workingDir, params.FilePath)
 	}
+	if err := checkSandbox(e.permissions, params.FilePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	var response ToolResponse
 	var err error
@@ -202,11 +205,6 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string,
 		return ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
 	}
 
-	dir := filepath.Dir(filePath)
-	if err = os.MkdirAll(dir, 0o755); err != nil {
-		return ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
-	}
-
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
@@ -236,13 +234,18 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string,
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	dir := filepath.Dir(filePath)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
+	}
+
 	err = os.WriteFile(filePath, []byte(content), 0o644)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// File can't be in the history so we create a new file history
-	_, err = e.files.Create(ctx, sessionID, filePath, "")
+	_, err = e.files.CreateNew(ctx, sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
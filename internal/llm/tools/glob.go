@@ -81,6 +81,10 @@ func (g *globTool) Name() string {
 	return GlobToolName
 }
 
+func (g *globTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
 func (g *globTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        GlobToolName,
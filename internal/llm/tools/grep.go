@@ -152,6 +152,10 @@ func (g *grepTool) Name() string {
 	return GrepToolName
 }
 
+func (g *grepTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
 func (g *grepTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        GrepToolName,
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vikvang/zero/internal/shell"
+)
+
+type JobKillParams struct {
+	JobID string `json:"job_id"`
+}
+
+type jobKillTool struct{}
+
+const (
+	JobKillToolName    = "job_kill"
+	jobKillDescription = `Stop a command started in the background with the bash tool.
+WHEN TO USE THIS TOOL:
+- Use to stop a dev server, file watcher or other background job once it's no longer needed
+HOW TO USE:
+- Provide the job ID returned by the bash tool
+- The output of the job that wasn't read yet is returned
+`
+)
+
+func NewJobKillTool() BaseTool {
+	return &jobKillTool{}
+}
+
+func (t *jobKillTool) Name() string {
+	return JobKillToolName
+}
+
+func (t *jobKillTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        JobKillToolName,
+		Description: jobKillDescription,
+		Parameters: map[string]any{
+			"job_id": map[string]any{
+				"type":        "string",
+				"description": "The ID of the background job",
+			},
+		},
+		Required: []string{"job_id"},
+	}
+}
+
+func (t *jobKillTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params JobKillParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if params.JobID == "" {
+		return NewTextErrorResponse("missing job_id"), nil
+	}
+
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID is required for killing a job")
+	}
+	job, ok := shell.GetJob(sessionID, params.JobID)
+	if !ok {
+		return NewTextErrorResponse(fmt.Sprintf("job %s not found", params.JobID)), nil
+	}
+	job.Kill()
+	return jobResponse(job), nil
+}
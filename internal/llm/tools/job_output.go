@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vikvang/zero/internal/shell"
+)
+
+type JobOutputParams struct {
+	JobID string `json:"job_id"`
+	Wait  int    `json:"wait"`
+}
+
+type JobResponseMetadata struct {
+	JobID    string          `json:"job_id"`
+	Command  string          `json:"command"`
+	Status   shell.JobStatus `json:"status"`
+	ExitCode int             `json:"exit_code"`
+	Output   string          `json:"output"`
+}
+
+type jobOutputTool struct{}
+
+const (
+	JobOutputToolName    = "job_output"
+	jobOutputDescription = `Read the output of a command started in the background with the bash tool.
+WHEN TO USE THIS TOOL:
+- Use to check on a dev server, file watcher or other background job started with run_in_background
+- Use to wait for a background job to finish
+HOW TO USE:
+- Provide the job ID returned by the bash tool, or leave it empty to list the jobs of the session
+- Only the output produced since the last read is returned
+- Set wait to wait up to that many milliseconds for the job to finish (max 600000), e.g. to wait for a build started in the background
+LIMITATIONS:
+- Output that isn't read is kept up to 1MB per stream, older output is dropped
+`
+)
+
+func NewJobOutputTool() BaseTool {
+	return &jobOutputTool{}
+}
+
+func (t *jobOutputTool) Name() string {
+	return JobOutputToolName
+}
+
+func (t *jobOutputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        JobOutputToolName,
+		Description: jobOutputDescription,
+		Parameters: map[string]any{
+			"job_id": map[string]any{
+				"type":        "string",
+				"description": "The ID of the background job, leave empty to list the jobs",
+			},
+			"wait": map[string]any{
+				"type":        "number",
+				"description": "Optional time in milliseconds to wait for the job to finish (max 600000)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *jobOutputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params JobOutputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID is required for reading the output of a job")
+	}
+	if params.JobID == "" {
+		return NewTextResponse(listJobs(sessionID)), nil
+	}
+
+	job, ok := shell.GetJob(sessionID, params.JobID)
+	if !ok {
+		return NewTextErrorResponse(fmt.Sprintf("job %s not found", params.JobID)), nil
+	}
+	if params.Wait > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, time.Duration(min(params.Wait, MaxTimeout))*time.Millisecond)
+		defer cancel()
+		job.Wait(waitCtx)
+	}
+	return jobResponse(job), nil
+}
+
+// jobResponse returns the unread output and the status of the job. Finished
+// jobs are removed once all their output is reported.
+func jobResponse(job *shell.Job) ToolResponse {
+	finished := job.Finished()
+	stdout, stderr, dropped := job.ReadOutput()
+	info := job.Info()
+	if finished {
+		shell.RemoveJob(job.ID)
+	}
+
+	var parts []string
+	if dropped > 0 {
+		parts = append(parts, fmt.Sprintf("[%d bytes of older output were dropped]", dropped))
+	}
+	if stdout != "" {
+		parts = append(parts, truncateOutput(strings.TrimRight(stdout, "\n")))
+	}
+	if stderr != "" {
+		parts = append(parts, truncateOutput(strings.TrimRight(stderr, "\n")))
+	}
+	output := strings.Join(parts, "\n")
+
+	metadata := JobResponseMetadata{
+		JobID:    info.ID,
+		Command:  info.Command,
+		Status:   info.Status,
+		ExitCode: info.ExitCode,
+		Output:   output,
+	}
+	if output == "" {
+		output = "No new output."
+	}
+	content := output + "\n\n" + jobStatus(info)
+	return WithResponseMetadata(NewTextResponse(content), metadata)
+}
+
+func jobStatus(info shell.JobInfo) string {
+	switch info.Status {
+	case shell.JobExited:
+		return fmt.Sprintf("Job %s exited with code %d.", info.ID, info.ExitCode)
+	case shell.JobKilled:
+		return fmt.Sprintf("Job %s was killed.", info.ID)
+	default:
+		return fmt.Sprintf("Job %s is running, started %s ago.", info.ID, time.Since(info.StartedAt).Round(time.Second))
+	}
+}
+
+func listJobs(sessionID string) string {
+	jobs := shell.ListJobs(sessionID)
+	if len(jobs) == 0 {
+		return "No background jobs."
+	}
+	var sb strings.Builder
+	for _, job := range jobs {
+		info := job.Info()
+		fmt.Fprintf(&sb, "%s\t%s\t%s\n", info.ID, info.Status, info.Command)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -89,6 +89,28 @@ func (l *lsTool) Name() string {
 	return LSToolName
 }
 
+// IsConcurrencySafe reports whether the call can run in parallel, which is
+// only the case for directories inside the working directory as anything else
+// needs a permission.
+func (l *lsTool) IsConcurrencySafe(call ToolCall) bool {
+	var params LSParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return false
+	}
+	searchPath := params.Path
+	if searchPath == "" {
+		searchPath = l.workingDir
+	}
+	searchPath, err := fsext.Expand(searchPath)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(searchPath) {
+		searchPath = filepath.Join(l.workingDir, searchPath)
+	}
+	return isWithinDir(l.workingDir, searchPath)
+}
+
 func (l *lsTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        LSToolName,
@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+// maxLSPResults is the maximum number of results the LSP navigation tools
+// list.
+const maxLSPResults = 100
+
+// LSPPositionParams locates a symbol in a file for the LSP navigation tools.
+type LSPPositionParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Symbol   string `json:"symbol,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func lspPositionParameters() map[string]any {
+	return map[string]any{
+		"file_path": map[string]any{
+			"type":        "string",
+			"description": "The path to the file containing the symbol",
+		},
+		"line": map[string]any{
+			"type":        "integer",
+			"description": "The line number of the symbol (1-based, as shown by the view tool)",
+		},
+		"symbol": map[string]any{
+			"type":        "string",
+			"description": "The name of the symbol on the line, used to find its column",
+		},
+		"column": map[string]any{
+			"type":        "integer",
+			"description": "The column of the symbol on the line (1-based), if symbol is not given",
+		},
+	}
+}
+
+// resolve returns the LSP client handling the file and the position of the
+// symbol in it. The file is opened in the client so it can answer requests
+// about it.
+func (p LSPPositionParams) resolve(ctx context.Context, lspClients map[string]*lsp.Client, workingDir string) (*lsp.Client, protocol.TextDocumentPositionParams, error) {
+	if p.FilePath == "" {
+		return nil, protocol.TextDocumentPositionParams{}, fmt.Errorf("file_path is required")
+	}
+	if p.Line < 1 {
+		return nil, protocol.TextDocumentPositionParams{}, fmt.Errorf("line must be a 1-based line number")
+	}
+	filePath := p.FilePath
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(workingDir, filePath)
+	}
+
+	client, err := lspClientForFile(ctx, lspClients, filePath)
+	if err != nil {
+		return nil, protocol.TextDocumentPositionParams{}, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, protocol.TextDocumentPositionParams{}, fmt.Errorf("error reading file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if p.Line > len(lines) {
+		return nil, protocol.TextDocumentPositionParams{}, fmt.Errorf("line %d is out of range, the file has %d lines", p.Line, len(lines))
+	}
+	character, err := symbolCharacter(strings.TrimSuffix(lines[p.Line-1], "\r"), p.Symbol, p.Column)
+	if err != nil {
+		return nil, protocol.TextDocumentPositionParams{}, fmt.Errorf("line %d: %w", p.Line, err)
+	}
+	return client, protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+		Position: protocol.Position{
+			Line:      uint32(p.Line - 1),
+			Character: character,
+		},
+	}, nil
+}
+
+// lspClientForFile returns the first client, by name, that handles the file,
+// and opens the file in it.
+func lspClientForFile(ctx context.Context, lspClients map[string]*lsp.Client, filePath string) (*lsp.Client, error) {
+	for _, name := range slices.Sorted(maps.Keys(lspClients)) {
+		client := lspClients[name]
+		if client.GetServerState() == lsp.StateError || !client.HandlesFile(filePath) {
+			continue
+		}
+		if err := client.OpenFile(ctx, filePath); err != nil {
+			return nil, fmt.Errorf("error opening file in %s: %w", name, err)
+		}
+		return client, nil
+	}
+	return nil, fmt.Errorf("no LSP client handles %s", filePath)
+}
+
+// symbolCharacter returns the LSP character offset, in UTF-16 code units, of
+// the symbol on the line. Without a symbol, it uses the 1-based column, and
+// without either the first non-blank character of the line.
+func symbolCharacter(line, symbol string, column int) (uint32, error) {
+	var offset int
+	switch {
+	case symbol != "":
+		offset = symbolOffset(line, symbol)
+		if offset == -1 {
+			return 0, fmt.Errorf("symbol %q not found", symbol)
+		}
+	case column > 0:
+		if column-1 > utf8.RuneCountInString(line) {
+			return 0, fmt.Errorf("column %d is out of range", column)
+		}
+		offset = len(line)
+		for i := range line {
+			if column == 1 {
+				offset = i
+				break
+			}
+			column--
+		}
+	default:
+		offset = len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+	}
+	return uint32(len(utf16.Encode([]rune(line[:offset])))), nil
+}
+
+// symbolOffset returns the byte offset of the first whole-word occurrence of
+// the symbol in the line, falling back to the first occurrence at all.
+func symbolOffset(line, symbol string) int {
+	isIdent := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	for start := 0; start < len(line); {
+		idx := strings.Index(line[start:], symbol)
+		if idx == -1 {
+			break
+		}
+		idx += start
+		before, _ := utf8.DecodeLastRuneInString(line[:idx])
+		after, _ := utf8.DecodeRuneInString(line[idx+len(symbol):])
+		if (idx == 0 || !isIdent(before)) && (idx+len(symbol) == len(line) || !isIdent(after)) {
+			return idx
+		}
+		start = idx + 1
+	}
+	return strings.Index(line, symbol)
+}
+
+// locationFormatter formats LSP locations as compact, line-numbered results,
+// relative to the working directory and followed by the source line.
+type locationFormatter struct {
+	workingDir string
+	lines      map[string][]string
+}
+
+func newLocationFormatter(workingDir string) *locationFormatter {
+	return &locationFormatter{
+		workingDir: workingDir,
+		lines:      make(map[string][]string),
+	}
+}
+
+func (f *locationFormatter) path(uri protocol.DocumentURI) string {
+	path, err := uri.Path()
+	if err != nil {
+		return string(uri)
+	}
+	if rel, err := filepath.Rel(f.workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// format returns "path:line:column: source line" for the position.
+func (f *locationFormatter) format(uri protocol.DocumentURI, pos protocol.Position) string {
+	location := fmt.Sprintf("%s:%d:%d", f.path(uri), pos.Line+1, pos.Character+1)
+	if text := f.line(uri, pos.Line); text != "" {
+		return location + ": " + text
+	}
+	return location
+}
+
+func (f *locationFormatter) line(uri protocol.DocumentURI, line uint32) string {
+	path, err := uri.Path()
+	if err != nil {
+		return ""
+	}
+	lines, ok := f.lines[path]
+	if !ok {
+		if content, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		f.lines[path] = lines
+	}
+	if int(line) >= len(lines) {
+		return ""
+	}
+	text := strings.TrimSpace(lines[line])
+	if len(text) > MaxLineLength {
+		text = text[:MaxLineLength] + "..."
+	}
+	return text
+}
+
+// locationsFromResult flattens the results of definition-like requests,
+// which servers return as a location, a list of locations or a list of
+// location links.
+func locationsFromResult(result any) []protocol.Location {
+	switch v := result.(type) {
+	case protocol.Or_Definition:
+		return locationsFromResult(v.Value)
+	case protocol.Or_Declaration:
+		return locationsFromResult(v.Value)
+	case protocol.Location:
+		return []protocol.Location{v}
+	case []protocol.Location:
+		return v
+	case []protocol.LocationLink:
+		locations := make([]protocol.Location, len(v))
+		for i, link := range v {
+			locations[i] = protocol.Location{URI: link.TargetURI, Range: link.TargetSelectionRange}
+		}
+		return locations
+	default:
+		return nil
+	}
+}
+
+// formatLocations lists the locations, sorted and limited to maxLSPResults.
+func formatLocations(f *locationFormatter, locations []protocol.Location) string {
+	slices.SortFunc(locations, func(a, b protocol.Location) int {
+		if c := strings.Compare(string(a.URI), string(b.URI)); c != 0 {
+			return c
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return int(a.Range.Start.Line) - int(b.Range.Start.Line)
+		}
+		return int(a.Range.Start.Character) - int(b.Range.Start.Character)
+	})
+	locations = slices.CompactFunc(locations, func(a, b protocol.Location) bool {
+		return a.URI == b.URI && a.Range.Start == b.Range.Start
+	})
+
+	var output strings.Builder
+	for i, location := range locations {
+		if i == maxLSPResults {
+			fmt.Fprintf(&output, "... and %d more\n", len(locations)-maxLSPResults)
+			break
+		}
+		output.WriteString(f.format(location.URI, location.Range.Start))
+		output.WriteString("\n")
+	}
+	return output.String()
+}
+
+func symbolKindName(kind protocol.SymbolKind) string {
+	if name, ok := protocol.TableKindMap[kind]; ok {
+		return name
+	}
+	return "Symbol"
+}
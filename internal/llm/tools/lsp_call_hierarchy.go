@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+type LSPCallHierarchyParams struct {
+	LSPPositionParams
+	Direction string `json:"direction,omitempty"`
+}
+
+type lspCallHierarchyTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	LSPCallHierarchyToolName    = "lsp_call_hierarchy"
+	lspCallHierarchyDescription = `Find the callers or the callees of a function or method using the language server.
+WHEN TO USE THIS TOOL:
+- Use with direction "incoming" to find every function calling a function, including calls through interfaces the server resolves
+- Use with direction "outgoing" to find every function a function calls
+HOW TO USE:
+- Provide the file path and the 1-based line number of the function, as shown by the view tool
+- Provide the function name so its column can be found on the line
+- Optionally set direction to "incoming" (default) or "outgoing"
+FEATURES:
+- Incoming calls are listed as path:line:column of the call site, the source line and the calling function
+- Outgoing calls are listed as path:line:column of the called function and the lines calling it
+LIMITATIONS:
+- Only works for files handled by a configured LSP server that supports call hierarchy
+- Only direct calls are listed; call the tool again on a result to go further
+`
+)
+
+func NewLSPCallHierarchyTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &lspCallHierarchyTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (t *lspCallHierarchyTool) Name() string {
+	return LSPCallHierarchyToolName
+}
+
+func (t *lspCallHierarchyTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
+func (t *lspCallHierarchyTool) Info() ToolInfo {
+	parameters := lspPositionParameters()
+	parameters["direction"] = map[string]any{
+		"type":        "string",
+		"description": "Whether to list the callers (incoming, default) or the callees (outgoing)",
+		"enum":        []string{"incoming", "outgoing"},
+	}
+	return ToolInfo{
+		Name:        LSPCallHierarchyToolName,
+		Description: lspCallHierarchyDescription,
+		Parameters:  parameters,
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (t *lspCallHierarchyTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params LSPCallHierarchyParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Direction == "" {
+		params.Direction = "incoming"
+	}
+	if params.Direction != "incoming" && params.Direction != "outgoing" {
+		return NewTextErrorResponse(fmt.Sprintf("unknown direction %q", params.Direction)), nil
+	}
+
+	client, position, err := params.resolve(ctx, t.lspClients, t.workingDir)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	items, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{TextDocumentPositionParams: position})
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error preparing call hierarchy: %s", err)), nil
+	}
+	if len(items) == 0 {
+		return NewTextResponse("No function found at this position"), nil
+	}
+
+	f := newLocationFormatter(t.workingDir)
+	var output strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&output, "%s %s (%s)\n", symbolKindName(item.Kind), item.Name, f.format(item.URI, item.SelectionRange.Start))
+		var lines []string
+		if params.Direction == "incoming" {
+			lines, err = t.incomingCalls(ctx, client, f, item)
+		} else {
+			lines, err = t.outgoingCalls(ctx, client, f, item)
+		}
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("error finding %s calls: %s", params.Direction, err)), nil
+		}
+		if len(lines) == 0 {
+			fmt.Fprintf(&output, "No %s calls found\n", params.Direction)
+			continue
+		}
+		fmt.Fprintf(&output, "%s calls (%d):\n", strings.ToUpper(params.Direction[:1])+params.Direction[1:], len(lines))
+		for i, line := range lines {
+			if i == maxLSPResults {
+				fmt.Fprintf(&output, "... and %d more\n", len(lines)-maxLSPResults)
+				break
+			}
+			output.WriteString(line)
+			output.WriteString("\n")
+		}
+	}
+	return NewTextResponse(output.String()), nil
+}
+
+// incomingCalls lists the call sites of the item, with the function making
+// each call.
+func (t *lspCallHierarchyTool) incomingCalls(ctx context.Context, client *lsp.Client, f *locationFormatter, item protocol.CallHierarchyItem) ([]string, error) {
+	calls, err := client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{Item: item})
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, call := range calls {
+		caller := fmt.Sprintf("(in %s %s)", symbolKindName(call.From.Kind), call.From.Name)
+		if len(call.FromRanges) == 0 {
+			lines = append(lines, f.format(call.From.URI, call.From.SelectionRange.Start)+" "+caller)
+			continue
+		}
+		for _, rng := range call.FromRanges {
+			lines = append(lines, f.format(call.From.URI, rng.Start)+" "+caller)
+		}
+	}
+	return lines, nil
+}
+
+// outgoingCalls lists the functions the item calls, with the lines of the
+// item calling them.
+func (t *lspCallHierarchyTool) outgoingCalls(ctx context.Context, client *lsp.Client, f *locationFormatter, item protocol.CallHierarchyItem) ([]string, error) {
+	calls, err := client.OutgoingCalls(ctx, protocol.CallHierarchyOutgoingCallsParams{Item: item})
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(calls))
+	for _, call := range calls {
+		pos := call.To.SelectionRange.Start
+		line := fmt.Sprintf("%s:%d:%d: %s %s", f.path(call.To.URI), pos.Line+1, pos.Character+1, symbolKindName(call.To.Kind), call.To.Name)
+		if len(call.FromRanges) > 0 {
+			callLines := make([]string, len(call.FromRanges))
+			for i, rng := range call.FromRanges {
+				callLines[i] = fmt.Sprint(rng.Start.Line + 1)
+			}
+			line += " (called at line " + strings.Join(callLines, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
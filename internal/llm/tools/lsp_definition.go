@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+type LSPDefinitionParams struct {
+	LSPPositionParams
+	Kind string `json:"kind,omitempty"`
+}
+
+type lspDefinitionTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	LSPDefinitionToolName    = "lsp_definition"
+	lspDefinitionDescription = `Find where a symbol is defined using the language server.
+WHEN TO USE THIS TOOL:
+- Use to jump from a usage of a function, type, method or variable to its definition
+- Use with kind "implementation" to find the types implementing an interface or the implementations of an interface method, which grep misses
+- Use with kind "type_definition" to find the type of a variable
+HOW TO USE:
+- Provide the file path and the 1-based line number of the symbol, as shown by the view tool
+- Provide the symbol name so its column can be found on the line
+- Optionally set kind to "definition" (default), "declaration", "type_definition" or "implementation"
+FEATURES:
+- Returns one location per line as path:line:column followed by the source line
+- Paths are relative to the working directory when possible
+LIMITATIONS:
+- Only works for files handled by a configured LSP server
+- Results depend on the language server and may be empty while it is still indexing
+`
+)
+
+func NewLSPDefinitionTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &lspDefinitionTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (t *lspDefinitionTool) Name() string {
+	return LSPDefinitionToolName
+}
+
+func (t *lspDefinitionTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
+func (t *lspDefinitionTool) Info() ToolInfo {
+	parameters := lspPositionParameters()
+	parameters["kind"] = map[string]any{
+		"type":        "string",
+		"description": "What to look for: definition (default), declaration, type_definition or implementation",
+		"enum":        []string{"definition", "declaration", "type_definition", "implementation"},
+	}
+	return ToolInfo{
+		Name:        LSPDefinitionToolName,
+		Description: lspDefinitionDescription,
+		Parameters:  parameters,
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (t *lspDefinitionTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params LSPDefinitionParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	if params.Kind == "" {
+		params.Kind = "definition"
+	}
+	client, position, err := params.resolve(ctx, t.lspClients, t.workingDir)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var result any
+	switch params.Kind {
+	case "definition":
+		var res protocol.Or_Result_textDocument_definition
+		res, err = client.Definition(ctx, protocol.DefinitionParams{TextDocumentPositionParams: position})
+		result = res.Value
+	case "declaration":
+		var res protocol.Or_Result_textDocument_declaration
+		res, err = client.Declaration(ctx, protocol.DeclarationParams{TextDocumentPositionParams: position})
+		result = res.Value
+	case "type_definition":
+		var res protocol.Or_Result_textDocument_typeDefinition
+		res, err = client.TypeDefinition(ctx, protocol.TypeDefinitionParams{TextDocumentPositionParams: position})
+		result = res.Value
+	case "implementation":
+		var res protocol.Or_Result_textDocument_implementation
+		res, err = client.Implementation(ctx, protocol.ImplementationParams{TextDocumentPositionParams: position})
+		result = res.Value
+	default:
+		return NewTextErrorResponse(fmt.Sprintf("unknown kind %q", params.Kind)), nil
+	}
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error finding %s: %s", params.Kind, err)), nil
+	}
+
+	locations := locationsFromResult(result)
+	if len(locations) == 0 {
+		return NewTextResponse(fmt.Sprintf("No %s found", params.Kind)), nil
+	}
+	return NewTextResponse(formatLocations(newLocationFormatter(t.workingDir), locations)), nil
+}
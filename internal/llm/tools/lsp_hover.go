@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+type lspHoverTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	LSPHoverToolName    = "lsp_hover"
+	lspHoverDescription = `Show the type signature and documentation of a symbol using the language server.
+WHEN TO USE THIS TOOL:
+- Use to learn the type of a variable or the signature and doc comment of a function without opening its definition
+- Useful for symbols from dependencies outside the working directory
+HOW TO USE:
+- Provide the file path and the 1-based line number of the symbol, as shown by the view tool
+- Provide the symbol name so its column can be found on the line
+LIMITATIONS:
+- Only works for files handled by a configured LSP server
+- The content of the result depends on the language server
+`
+)
+
+func NewLSPHoverTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &lspHoverTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (t *lspHoverTool) Name() string {
+	return LSPHoverToolName
+}
+
+func (t *lspHoverTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
+func (t *lspHoverTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        LSPHoverToolName,
+		Description: lspHoverDescription,
+		Parameters:  lspPositionParameters(),
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (t *lspHoverTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params LSPPositionParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	client, position, err := params.resolve(ctx, t.lspClients, t.workingDir)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	hover, err := client.Hover(ctx, protocol.HoverParams{TextDocumentPositionParams: position})
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error getting hover information: %s", err)), nil
+	}
+	contents := strings.TrimSpace(hover.Contents.Value)
+	if contents == "" {
+		return NewTextResponse("No information available for this position"), nil
+	}
+	return NewTextResponse(contents), nil
+}
@@ -0,0 +1,380 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/vikvang/zero/internal/diff"
+	"github.com/vikvang/zero/internal/fsext"
+	"github.com/vikvang/zero/internal/history"
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+	"github.com/vikvang/zero/internal/lsp/util"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+type LSPRefactorParams struct {
+	LSPPositionParams
+	NewName string `json:"new_name,omitempty"`
+	Action  string `json:"action,omitempty"`
+	EndLine int    `json:"end_line,omitempty"`
+}
+
+// LSPRefactorFileChange is the change a refactoring makes to a file.
+type LSPRefactorFileChange struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content"`
+	NewContent string `json:"new_content"`
+}
+
+type LSPRefactorPermissionsParams struct {
+	Description string                  `json:"description"`
+	Files       []LSPRefactorFileChange `json:"files"`
+}
+
+type LSPRefactorResponseMetadata struct {
+	Files     []LSPRefactorFileChange `json:"files"`
+	Additions int                     `json:"additions"`
+	Removals  int                     `json:"removals"`
+}
+
+type lspRefactorTool struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+	workingDir  string
+}
+
+const (
+	LSPRefactorToolName    = "lsp_refactor"
+	lspRefactorDescription = `Rename a symbol or apply a code action across all affected files using the language server.
+WHEN TO USE THIS TOOL:
+- Use to rename a function, type, method, field or variable everywhere it is referenced instead of editing each file by hand
+- Use to apply a code action such as organizing imports, filling a struct, extracting a function or applying a quick fix for a diagnostic
+HOW TO USE:
+- Provide the file path and the 1-based line number of the symbol, as shown by the view tool
+- Provide the symbol name so its column can be found on the line
+- To rename, provide new_name
+- To apply a code action, provide action: either a code action kind (e.g. "source.organizeImports", "refactor.extract") or part of its title (e.g. "Fill", "Extract function")
+- For actions on a block of code, like extracting a function, set end_line to the last line of the block
+FEATURES:
+- Changes every affected file in one step, as the language server computes them
+- Asks for permission with a combined diff of all changed files
+- Returns the changed files and the diagnostics after the change
+LIMITATIONS:
+- Only works for files handled by a configured LSP server supporting rename or code actions
+- Code actions that only run a server command or that create, rename or delete files are not supported
+- When no code action matches, the available actions are listed
+`
+)
+
+func NewLSPRefactorTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service, workingDir string) BaseTool {
+	return &lspRefactorTool{
+		lspClients:  lspClients,
+		permissions: permissions,
+		files:       files,
+		workingDir:  workingDir,
+	}
+}
+
+func (t *lspRefactorTool) Name() string {
+	return LSPRefactorToolName
+}
+
+func (t *lspRefactorTool) Info() ToolInfo {
+	parameters := lspPositionParameters()
+	parameters["new_name"] = map[string]any{
+		"type":        "string",
+		"description": "The new name of the symbol, to rename it",
+	}
+	parameters["action"] = map[string]any{
+		"type":        "string",
+		"description": "The kind or part of the title of the code action to apply, if not renaming",
+	}
+	parameters["end_line"] = map[string]any{
+		"type":        "integer",
+		"description": "The last line (1-based) of the code the action applies to, for actions on a block of code",
+	}
+	return ToolInfo{
+		Name:        LSPRefactorToolName,
+		Description: lspRefactorDescription,
+		Parameters:  parameters,
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (t *lspRefactorTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params LSPRefactorParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if (params.NewName == "") == (params.Action == "") {
+		return NewTextErrorResponse("either new_name or action is required"), nil
+	}
+
+	client, position, err := params.resolve(ctx, t.lspClients, t.workingDir)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var (
+		edit        protocol.WorkspaceEdit
+		description string
+	)
+	if params.NewName != "" {
+		edit, err = t.rename(ctx, client, position, params.NewName)
+		description = fmt.Sprintf("Rename %s to %s", cmp.Or(params.Symbol, "symbol"), params.NewName)
+	} else {
+		var title string
+		edit, title, err = t.codeAction(ctx, client, position, params.Action, params.EndLine)
+		description = fmt.Sprintf("Apply code action %q", title)
+	}
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	contents, err := util.PreviewWorkspaceEdit(edit)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("cannot apply the edit: %s", err)), nil
+	}
+	// The edit can change files anywhere, so every file is checked against
+	// the sandbox, and the permission is asked for the first file outside
+	// of it, if any.
+	filePath, _ := position.TextDocument.URI.Path()
+	requestPath := fsext.PathOrPrefix(filePath, t.workingDir)
+	outside := false
+	var changes []LSPRefactorFileChange
+	for _, path := range slices.Sorted(maps.Keys(contents)) {
+		if err := checkSandbox(t.permissions, path); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		if !outside && t.permissions.Sandbox().Check(path) != nil {
+			requestPath, outside = path, true
+		}
+		oldContent, err := os.ReadFile(path)
+		if err != nil {
+			return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		if string(oldContent) == contents[path] {
+			continue
+		}
+		changes = append(changes, LSPRefactorFileChange{
+			FilePath:   path,
+			OldContent: string(oldContent),
+			NewContent: contents[path],
+		})
+	}
+	if len(changes) == 0 {
+		return NewTextErrorResponse("no changes made - the edit results in identical content"), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for editing files")
+	}
+
+	p := t.permissions.Request(permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        requestPath,
+		ToolCallID:  call.ID,
+		ToolName:    LSPRefactorToolName,
+		Action:      "write",
+		Description: fmt.Sprintf("%s in %d file(s)", description, len(changes)),
+		Params: LSPRefactorPermissionsParams{
+			Description: description,
+			Files:       changes,
+		},
+	})
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := util.ApplyWorkspaceEdit(edit); err != nil {
+		return ToolResponse{}, fmt.Errorf("failed to apply the edit: %w", err)
+	}
+
+	var (
+		output              strings.Builder
+		additions, removals int
+	)
+	fmt.Fprintf(&output, "%s in %d file(s):\n", description, len(changes))
+	f := newLocationFormatter(t.workingDir)
+	for _, change := range changes {
+		if err := t.recordHistory(ctx, sessionID, change); err != nil {
+			return ToolResponse{}, err
+		}
+		recordFileWrite(change.FilePath)
+		recordFileRead(change.FilePath)
+
+		_, fileAdditions, fileRemovals := diff.GenerateDiff(change.OldContent, change.NewContent, strings.TrimPrefix(change.FilePath, t.workingDir))
+		additions += fileAdditions
+		removals += fileRemovals
+		fmt.Fprintf(&output, "%s (+%d -%d)\n", f.path(protocol.URIFromPath(change.FilePath)), fileAdditions, fileRemovals)
+
+		// Let the servers know about the other changed files, the
+		// diagnostics wait below notifies them about the file itself.
+		if change.FilePath != filePath {
+			for _, client := range t.lspClients {
+				if client.IsFileOpen(change.FilePath) {
+					_ = client.NotifyChange(ctx, change.FilePath)
+				}
+			}
+		}
+	}
+
+	waitForLspDiagnostics(ctx, filePath, t.lspClients)
+	text := fmt.Sprintf("<result>\n%s</result>\n", output.String())
+	text += getDiagnostics(filePath, t.lspClients)
+
+	return WithResponseMetadata(
+		NewTextResponse(text),
+		LSPRefactorResponseMetadata{
+			Files:     changes,
+			Additions: additions,
+			Removals:  removals,
+		},
+	), nil
+}
+
+func (t *lspRefactorTool) rename(ctx context.Context, client *lsp.Client, position protocol.TextDocumentPositionParams, newName string) (protocol.WorkspaceEdit, error) {
+	// Not all servers support preparing a rename, in which case the rename
+	// itself reports whether the symbol can be renamed.
+	prepared, err := client.PrepareRename(ctx, protocol.PrepareRenameParams{TextDocumentPositionParams: position})
+	if err == nil && prepared.Value == nil {
+		return protocol.WorkspaceEdit{}, fmt.Errorf("the symbol at this position cannot be renamed")
+	}
+
+	edit, err := client.Rename(ctx, protocol.RenameParams{
+		TextDocument: position.TextDocument,
+		Position:     position.Position,
+		NewName:      newName,
+	})
+	if err != nil {
+		return protocol.WorkspaceEdit{}, fmt.Errorf("error renaming symbol: %w", err)
+	}
+	return edit, nil
+}
+
+// codeAction returns the edit and the title of the code action matching the
+// action at the position, or at the lines from the position to endLine.
+func (t *lspRefactorTool) codeAction(ctx context.Context, client *lsp.Client, position protocol.TextDocumentPositionParams, action string, endLine int) (protocol.WorkspaceEdit, string, error) {
+	rng := protocol.Range{Start: position.Position, End: position.Position}
+	if endLine > 0 {
+		if endLine <= int(position.Position.Line) {
+			return protocol.WorkspaceEdit{}, "", fmt.Errorf("end_line must be after line")
+		}
+		filePath, _ := position.TextDocument.URI.Path()
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return protocol.WorkspaceEdit{}, "", fmt.Errorf("error reading file: %w", err)
+		}
+		lines := strings.Split(string(content), "\n")
+		if endLine > len(lines) {
+			return protocol.WorkspaceEdit{}, "", fmt.Errorf("end_line %d is out of range, the file has %d lines", endLine, len(lines))
+		}
+		rng.Start.Character = 0
+		rng.End = protocol.Position{
+			Line:      uint32(endLine - 1),
+			Character: uint32(len(utf16.Encode([]rune(strings.TrimSuffix(lines[endLine-1], "\r"))))),
+		}
+	}
+
+	// Include the diagnostics of the range, which quick fixes are computed
+	// for.
+	var diagnostics []protocol.Diagnostic
+	for _, diagnostic := range client.GetFileDiagnostics(position.TextDocument.URI) {
+		if diagnostic.Range.Start.Line <= rng.End.Line && diagnostic.Range.End.Line >= rng.Start.Line {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	actionContext := protocol.CodeActionContext{Diagnostics: diagnostics}
+	if isCodeActionKind(action) {
+		actionContext.Only = []protocol.CodeActionKind{protocol.CodeActionKind(action)}
+	}
+
+	results, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: position.TextDocument,
+		Range:        rng,
+		Context:      actionContext,
+	})
+	if err != nil {
+		return protocol.WorkspaceEdit{}, "", fmt.Errorf("error listing code actions: %w", err)
+	}
+
+	var (
+		available []string
+		matched   *protocol.CodeAction
+	)
+	for _, result := range results {
+		switch item := result.Value.(type) {
+		case protocol.CodeAction:
+			if item.Disabled != nil {
+				continue
+			}
+			available = append(available, fmt.Sprintf("%q (%s)", item.Title, item.Kind))
+			if matched == nil && codeActionMatches(item, action) {
+				matched = &item
+			}
+		case protocol.Command:
+			available = append(available, fmt.Sprintf("%q (command, not supported)", item.Title))
+		}
+	}
+	if matched == nil {
+		if len(available) == 0 {
+			return protocol.WorkspaceEdit{}, "", fmt.Errorf("no code actions available at this position")
+		}
+		return protocol.WorkspaceEdit{}, "", fmt.Errorf("no code action matches %q, available actions:\n%s", action, strings.Join(available, "\n"))
+	}
+
+	if matched.Edit == nil {
+		resolved, err := client.ResolveCodeAction(ctx, *matched)
+		if err != nil {
+			return protocol.WorkspaceEdit{}, "", fmt.Errorf("error resolving code action %q: %w", matched.Title, err)
+		}
+		matched = &resolved
+	}
+	if matched.Edit == nil {
+		return protocol.WorkspaceEdit{}, "", fmt.Errorf("code action %q runs a server command, which is not supported", matched.Title)
+	}
+	return *matched.Edit, matched.Title, nil
+}
+
+// isCodeActionKind reports whether the action names a code action kind, such
+// as "source.organizeImports", rather than part of a title.
+func isCodeActionKind(action string) bool {
+	return strings.Contains(action, ".") && !strings.ContainsAny(action, " \t")
+}
+
+func codeActionMatches(item protocol.CodeAction, action string) bool {
+	kind := string(item.Kind)
+	if kind != "" && (kind == action || strings.HasPrefix(kind, action+".")) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(item.Title), strings.ToLower(action))
+}
+
+func (t *lspRefactorTool) recordHistory(ctx context.Context, sessionID string, change LSPRefactorFileChange) error {
+	file, err := t.files.GetByPathAndSession(ctx, change.FilePath, sessionID)
+	if err != nil {
+		if _, err := t.files.Create(ctx, sessionID, change.FilePath, change.OldContent); err != nil {
+			return fmt.Errorf("error creating file history: %w", err)
+		}
+	} else if file.Content != change.OldContent {
+		// User manually changed the content, store an intermediate version
+		if _, err := t.files.CreateVersion(ctx, sessionID, change.FilePath, change.OldContent); err != nil {
+			slog.Debug("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := t.files.CreateVersion(ctx, sessionID, change.FilePath, change.NewContent); err != nil {
+		slog.Debug("Error creating file history version", "error", err)
+	}
+	return nil
+}
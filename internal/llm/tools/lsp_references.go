@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+type LSPReferencesParams struct {
+	LSPPositionParams
+	IncludeDeclaration bool `json:"include_declaration,omitempty"`
+}
+
+type lspReferencesTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	LSPReferencesToolName    = "lsp_references"
+	lspReferencesDescription = `Find all references to a symbol using the language server.
+WHEN TO USE THIS TOOL:
+- Use to find every usage of a function, type, method, field or variable before changing it
+- Prefer over grep for symbols with common names, as only real references are returned
+HOW TO USE:
+- Provide the file path and the 1-based line number of the symbol, as shown by the view tool
+- Provide the symbol name so its column can be found on the line
+- Set include_declaration to also list the declaration of the symbol
+FEATURES:
+- Returns one reference per line as path:line:column followed by the source line
+- Paths are relative to the working directory when possible
+LIMITATIONS:
+- Only works for files handled by a configured LSP server
+- Results are limited to 100 references
+`
+)
+
+func NewLSPReferencesTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &lspReferencesTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (t *lspReferencesTool) Name() string {
+	return LSPReferencesToolName
+}
+
+func (t *lspReferencesTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
+func (t *lspReferencesTool) Info() ToolInfo {
+	parameters := lspPositionParameters()
+	parameters["include_declaration"] = map[string]any{
+		"type":        "boolean",
+		"description": "Whether to include the declaration of the symbol (default false)",
+	}
+	return ToolInfo{
+		Name:        LSPReferencesToolName,
+		Description: lspReferencesDescription,
+		Parameters:  parameters,
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (t *lspReferencesTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params LSPReferencesParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	client, position, err := params.resolve(ctx, t.lspClients, t.workingDir)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	locations, err := client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: position,
+		Context:                    protocol.ReferenceContext{IncludeDeclaration: params.IncludeDeclaration},
+	})
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error finding references: %s", err)), nil
+	}
+	if len(locations) == 0 {
+		return NewTextResponse("No references found"), nil
+	}
+
+	output := fmt.Sprintf("%d reference(s):\n%s", len(locations), formatLocations(newLocationFormatter(t.workingDir), locations))
+	return NewTextResponse(output), nil
+}
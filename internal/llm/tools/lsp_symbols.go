@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vikvang/zero/internal/lsp"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+type LSPSymbolsParams struct {
+	FilePath string `json:"file_path,omitempty"`
+	Query    string `json:"query,omitempty"`
+}
+
+type lspSymbolsTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	LSPSymbolsToolName    = "lsp_symbols"
+	lspSymbolsDescription = `List the symbols of a file or search the symbols of the workspace using the language server.
+WHEN TO USE THIS TOOL:
+- Use with a file path to get an outline of a file: its types, functions, methods and fields with their line numbers
+- Use with a query to find where a type, function or method is declared anywhere in the workspace
+HOW TO USE:
+- Provide file_path to list the symbols of that file
+- Provide query to search the workspace by symbol name, optionally with file_path to pick the language server
+FEATURES:
+- File outlines are nested by symbol and show the 1-based line of each symbol
+- Workspace results are listed as path:line:column followed by the kind and name of the symbol
+LIMITATIONS:
+- Only works for files handled by a configured LSP server
+- Workspace search is fuzzy and results are limited to 100 symbols
+`
+)
+
+func NewLSPSymbolsTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &lspSymbolsTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (t *lspSymbolsTool) Name() string {
+	return LSPSymbolsToolName
+}
+
+func (t *lspSymbolsTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
+func (t *lspSymbolsTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        LSPSymbolsToolName,
+		Description: lspSymbolsDescription,
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the file to list the symbols of",
+			},
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The symbol name to search the workspace for",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *lspSymbolsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params LSPSymbolsParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.FilePath == "" && params.Query == "" {
+		return NewTextErrorResponse("file_path or query is required"), nil
+	}
+
+	filePath := params.FilePath
+	if filePath != "" && !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(t.workingDir, filePath)
+	}
+	if params.Query != "" {
+		return t.workspaceSymbols(ctx, params.Query, filePath)
+	}
+	return t.documentSymbols(ctx, filePath)
+}
+
+func (t *lspSymbolsTool) documentSymbols(ctx context.Context, filePath string) (ToolResponse, error) {
+	client, err := lspClientForFile(ctx, t.lspClients, filePath)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+	})
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error listing symbols: %s", err)), nil
+	}
+
+	var output strings.Builder
+	switch symbols := result.Value.(type) {
+	case []protocol.DocumentSymbol:
+		writeDocumentSymbols(&output, symbols, 0)
+	case []protocol.SymbolInformation:
+		for _, symbol := range symbols {
+			fmt.Fprintf(&output, "%d: %s %s\n", symbol.Location.Range.Start.Line+1, symbolKindName(symbol.Kind), symbol.Name)
+		}
+	}
+	if output.Len() == 0 {
+		return NewTextResponse("No symbols found"), nil
+	}
+	return NewTextResponse(output.String()), nil
+}
+
+func writeDocumentSymbols(output *strings.Builder, symbols []protocol.DocumentSymbol, depth int) {
+	for _, symbol := range symbols {
+		fmt.Fprintf(output, "%s%d: %s %s", strings.Repeat("  ", depth), symbol.SelectionRange.Start.Line+1, symbolKindName(symbol.Kind), symbol.Name)
+		if symbol.Detail != "" {
+			fmt.Fprintf(output, " %s", symbol.Detail)
+		}
+		output.WriteString("\n")
+		writeDocumentSymbols(output, symbol.Children, depth+1)
+	}
+}
+
+func (t *lspSymbolsTool) workspaceSymbols(ctx context.Context, query, filePath string) (ToolResponse, error) {
+	var clients []*lsp.Client
+	if filePath != "" {
+		client, err := lspClientForFile(ctx, t.lspClients, filePath)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		clients = append(clients, client)
+	} else {
+		for _, name := range slices.Sorted(maps.Keys(t.lspClients)) {
+			if client := t.lspClients[name]; client.GetServerState() != lsp.StateError {
+				clients = append(clients, client)
+			}
+		}
+	}
+
+	f := newLocationFormatter(t.workingDir)
+	var results []string
+	for _, client := range clients {
+		result, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("error searching symbols in %s: %s", client.GetName(), err)), nil
+		}
+		switch symbols := result.Value.(type) {
+		case []protocol.SymbolInformation:
+			for _, symbol := range symbols {
+				results = append(results, formatWorkspaceSymbol(f, symbol.Location, symbol.Kind, symbol.Name, symbol.ContainerName))
+			}
+		case []protocol.WorkspaceSymbol:
+			for _, symbol := range symbols {
+				switch location := symbol.Location.Value.(type) {
+				case protocol.Location:
+					results = append(results, formatWorkspaceSymbol(f, location, symbol.Kind, symbol.Name, symbol.ContainerName))
+				case protocol.LocationUriOnly:
+					results = append(results, formatWorkspaceSymbol(f, protocol.Location{URI: location.URI}, symbol.Kind, symbol.Name, symbol.ContainerName))
+				}
+			}
+		}
+	}
+	if len(results) == 0 {
+		return NewTextResponse("No symbols found"), nil
+	}
+
+	var output strings.Builder
+	for i, result := range results {
+		if i == maxLSPResults {
+			fmt.Fprintf(&output, "... and %d more\n", len(results)-maxLSPResults)
+			break
+		}
+		output.WriteString(result)
+		output.WriteString("\n")
+	}
+	return NewTextResponse(output.String()), nil
+}
+
+func formatWorkspaceSymbol(f *locationFormatter, location protocol.Location, kind protocol.SymbolKind, name, container string) string {
+	pos := location.Range.Start
+	result := fmt.Sprintf("%s:%d:%d: %s %s", f.path(location.URI), pos.Line+1, pos.Character+1, symbolKindName(kind), name)
+	if container != "" {
+		result += " in " + container
+	}
+	return result
+}
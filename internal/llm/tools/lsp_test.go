@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+func TestSymbolCharacter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		line    string
+		symbol  string
+		column  int
+		want    uint32
+		wantErr bool
+	}{
+		{"symbol", "func (s *Server) Serve() error {", "Serve", 0, 17, false},
+		{"whole word over substring", "\tserver := NewServer(serve)", "serve", 0, 21, false},
+		{"substring fallback", "\tnewServer()", "Server", 0, 4, false},
+		{"utf-16 offset", "x := \"😀\" + name", "name", 0, 12, false},
+		{"missing symbol", "return nil", "Serve", 0, 0, true},
+		{"column", "\treturn s.handler", "", 10, 9, false},
+		{"column out of range", "nil", "", 10, 0, true},
+		{"first non-blank character", "\t\tdefer wg.Done()", "", 0, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := symbolCharacter(tt.line, tt.symbol, tt.column)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatLocations(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\trun()\n}\n"), 0o644))
+	uri := protocol.URIFromPath(path)
+
+	at := func(line, character uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line, Character: character}}
+	}
+	result := protocol.Or_Result_textDocument_definition{Value: []protocol.LocationLink{
+		{TargetURI: uri, TargetSelectionRange: at(3, 1)},
+		{TargetURI: uri, TargetSelectionRange: at(2, 5)},
+		{TargetURI: uri, TargetSelectionRange: at(3, 1)},
+		{TargetURI: protocol.URIFromPath("/elsewhere/lib.go"), TargetSelectionRange: at(9, 0)},
+	}}
+
+	output := formatLocations(newLocationFormatter(dir), locationsFromResult(result.Value))
+	require.Equal(t, "/elsewhere/lib.go:10:1\nmain.go:3:6: func main() {\nmain.go:4:2: run()\n", output)
+}
+
+func TestLocationsFromResult(t *testing.T) {
+	t.Parallel()
+
+	location := protocol.Location{URI: "file:///a.go"}
+	require.Equal(t, []protocol.Location{location}, locationsFromResult(protocol.Or_Definition{Value: location}))
+	require.Equal(t, []protocol.Location{location}, locationsFromResult(protocol.Or_Definition{Value: []protocol.Location{location}}))
+	require.Empty(t, locationsFromResult(nil))
+}
+
+func TestCodeActionMatches(t *testing.T) {
+	t.Parallel()
+
+	organize := protocol.CodeAction{Title: "Organize Imports", Kind: "source.organizeImports"}
+	extract := protocol.CodeAction{Title: "Extract function", Kind: "refactor.extract.function"}
+
+	require.True(t, isCodeActionKind("source.organizeImports"))
+	require.False(t, isCodeActionKind("Extract function"))
+
+	require.True(t, codeActionMatches(organize, "source.organizeImports"))
+	require.True(t, codeActionMatches(organize, "organize imports"))
+	require.True(t, codeActionMatches(extract, "refactor.extract"))
+	require.False(t, codeActionMatches(extract, "refactor.ex"))
+	require.False(t, codeActionMatches(organize, "Fill struct"))
+}
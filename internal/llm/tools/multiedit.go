@@ -164,6 +164,9 @@ func (m *multiEditTool) Run(ctx context.Context, call ToolCall) (ToolResponse, e
 	if !filepath.IsAbs(params.FilePath) {
 		params.FilePath = filepath.Join(m.workingDir, params.FilePath)
 	}
+	if err := checkSandbox(m.permissions, params.FilePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	// Validate all edits before applying any
 	if err := m.validateEdits(params.Edits); err != nil {
@@ -223,12 +226,6 @@ func (m *multiEditTool) processMultiEditWithCreation(ctx context.Context, params
 		return ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
 	}
 
-	// Create parent directories
-	dir := filepath.Dir(params.FilePath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
-	}
-
 	// Start with the content from the first edit
 	currentContent := firstEdit.NewString
 
@@ -268,6 +265,12 @@ func (m *multiEditTool) processMultiEditWithCreation(ctx context.Context, params
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	// Create parent directories
+	dir := filepath.Dir(params.FilePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
+	}
+
 	// Write the file
 	err := os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
@@ -275,7 +278,7 @@ func (m *multiEditTool) processMultiEditWithCreation(ctx context.Context, params
 	}
 
 	// Update file history
-	_, err = m.files.Create(ctx, sessionID, params.FilePath, "")
+	_, err = m.files.CreateNew(ctx, sessionID, params.FilePath)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 	}
@@ -0,0 +1,16 @@
+package tools
+
+import (
+	"github.com/vikvang/zero/internal/permission"
+)
+
+// checkSandbox returns the error to give the model when the sandbox denies
+// writing the file. Writes outside the sandbox that are asked for instead
+// are handled by the permission service.
+func checkSandbox(permissions permission.Service, path string) error {
+	sb := permissions.Sandbox()
+	if err := sb.Check(path); err != nil && !sb.Ask() {
+		return err
+	}
+	return nil
+}
@@ -142,6 +142,10 @@ func (t *sourcegraphTool) Name() string {
 	return SourcegraphToolName
 }
 
+func (t *sourcegraphTool) IsConcurrencySafe(ToolCall) bool {
+	return true
+}
+
 func (t *sourcegraphTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        SourcegraphToolName,
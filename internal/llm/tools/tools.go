@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
+	"strings"
 )
 
 type ToolInfo struct {
@@ -72,6 +74,36 @@ type BaseTool interface {
 	Run(ctx context.Context, params ToolCall) (ToolResponse, error)
 }
 
+// ConcurrencySafeTool is implemented by tools that don't modify any state and
+// can therefore run in parallel with other concurrency-safe tool calls from
+// the same assistant turn. Calls that may ask the user for permission must
+// not be reported as concurrency-safe.
+type ConcurrencySafeTool interface {
+	BaseTool
+	IsConcurrencySafe(call ToolCall) bool
+}
+
+// IsConcurrencySafe reports whether the given tool call may run in parallel
+// with other concurrency-safe tool calls.
+func IsConcurrencySafe(tool BaseTool, call ToolCall) bool {
+	t, ok := tool.(ConcurrencySafeTool)
+	return ok && t.IsConcurrencySafe(call)
+}
+
+// isWithinDir reports whether path is dir or one of its descendants.
+func isWithinDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(absDir, absPath)
+	return err == nil && !strings.HasPrefix(relPath, "..")
+}
+
 func GetContextValues(ctx context.Context) (string, string) {
 	sessionID := ctx.Value(SessionIDContextKey)
 	messageID := ctx.Value(MessageIDContextKey)
@@ -93,6 +93,21 @@ func (v *viewTool) Name() string {
 	return ViewToolName
 }
 
+// IsConcurrencySafe reports whether the call can run in parallel, which is
+// only the case for files inside the working directory as anything else needs
+// a permission.
+func (v *viewTool) IsConcurrencySafe(call ToolCall) bool {
+	var params ViewParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil || params.FilePath == "" {
+		return false
+	}
+	filePath := params.FilePath
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(v.workingDir, filePath)
+	}
+	return isWithinDir(v.workingDir, filePath)
+}
+
 func (v *viewTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        ViewToolName,
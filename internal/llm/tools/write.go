@@ -127,6 +127,9 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	if !filepath.IsAbs(filePath) {
 		filePath = filepath.Join(w.workingDir, filePath)
 	}
+	if err := checkSandbox(w.permissions, filePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	fileInfo, err := os.Stat(filePath)
 	if err == nil {
@@ -149,11 +152,6 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, fmt.Errorf("error checking file: %w", err)
 	}
 
-	dir := filepath.Dir(filePath)
-	if err = os.MkdirAll(dir, 0o755); err != nil {
-		return ToolResponse{}, fmt.Errorf("error creating directory: %w", err)
-	}
-
 	oldContent := ""
 	if fileInfo != nil && !fileInfo.IsDir() {
 		oldBytes, readErr := os.ReadFile(filePath)
@@ -192,6 +190,11 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	dir := filepath.Dir(filePath)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return ToolResponse{}, fmt.Errorf("error creating directory: %w", err)
+	}
+
 	err = os.WriteFile(filePath, []byte(params.Content), 0o644)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error writing file: %w", err)
@@ -200,7 +203,11 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	// Check if file exists in history
 	file, err := w.files.GetByPathAndSession(ctx, filePath, sessionID)
 	if err != nil {
-		_, err = w.files.Create(ctx, sessionID, filePath, oldContent)
+		if fileInfo == nil {
+			_, err = w.files.CreateNew(ctx, sessionID, filePath)
+		} else {
+			_, err = w.files.Create(ctx, sessionID, filePath, oldContent)
+		}
 		if err != nil {
 			// Log error but don't fail the operation
 			return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
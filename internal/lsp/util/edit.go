@@ -1,7 +1,6 @@
 package util
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"sort"
@@ -22,25 +21,39 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := applyTextEditsToContent(string(content), edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// applyTextEditsToContent returns the content with the edits applied.
+func applyTextEditsToContent(content string, edits []protocol.TextEdit) (string, error) {
 	// Detect line ending style
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := len(content) > 0 && strings.HasSuffix(content, lineEnding)
 
 	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// Check for overlapping edits
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return "", fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -59,7 +72,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -78,11 +91,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return newContent.String(), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -247,6 +256,61 @@ func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	return nil
 }
 
+// PreviewWorkspaceEdit returns the content each file changed by the given
+// WorkspaceEdit has once the edit is applied, keyed by path, without changing
+// the filesystem. Edits creating, renaming or deleting files are not
+// supported.
+func PreviewWorkspaceEdit(edit protocol.WorkspaceEdit) (map[string]string, error) {
+	contents := make(map[string]string)
+	apply := func(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
+		path, err := uri.Path()
+		if err != nil {
+			return fmt.Errorf("invalid URI: %w", err)
+		}
+		content, ok := contents[path]
+		if !ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			content = string(data)
+		}
+		newContent, err := applyTextEditsToContent(content, edits)
+		if err != nil {
+			return err
+		}
+		contents[path] = newContent
+		return nil
+	}
+
+	// Handle Changes field
+	for uri, textEdits := range edit.Changes {
+		if err := apply(uri, textEdits); err != nil {
+			return nil, fmt.Errorf("failed to apply text edits: %w", err)
+		}
+	}
+
+	// Handle DocumentChanges field
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			return nil, fmt.Errorf("file operations are not supported")
+		}
+		textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+		for i, edit := range change.TextDocumentEdit.Edits {
+			var err error
+			textEdits[i], err = edit.AsTextEdit()
+			if err != nil {
+				return nil, fmt.Errorf("invalid edit type: %w", err)
+			}
+		}
+		if err := apply(change.TextDocumentEdit.TextDocument.URI, textEdits); err != nil {
+			return nil, fmt.Errorf("failed to apply document change: %w", err)
+		}
+	}
+
+	return contents, nil
+}
+
 func rangesOverlap(r1, r2 protocol.Range) bool {
 	if r1.Start.Line > r2.End.Line || r2.Start.Line > r1.End.Line {
 		return false
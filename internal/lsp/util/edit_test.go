@@ -0,0 +1,66 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/lsp/protocol"
+)
+
+func TestPreviewWorkspaceEdit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("package p\n\nfunc old() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("package p\r\n\r\nvar _ = old\r\n"), 0o644))
+
+	rename := func(line, character uint32) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: character},
+				End:   protocol.Position{Line: line, Character: character + 3},
+			},
+			NewText: "renamed",
+		}
+	}
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(a): {rename(2, 5)},
+			protocol.URIFromPath(b): {rename(2, 8)},
+		},
+	}
+
+	contents, err := PreviewWorkspaceEdit(edit)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		a: "package p\n\nfunc renamed() {}\n",
+		b: "package p\r\n\r\nvar _ = renamed\r\n",
+	}, contents)
+
+	// The files are left untouched until the edit is applied.
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "package p\n\nfunc old() {}\n", string(content))
+
+	require.NoError(t, ApplyWorkspaceEdit(edit))
+	for path, want := range contents {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, want, string(content))
+	}
+}
+
+func TestPreviewWorkspaceEdit_FileOperations(t *testing.T) {
+	t.Parallel()
+
+	_, err := PreviewWorkspaceEdit(protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{CreateFile: &protocol.CreateFile{URI: protocol.URIFromPath(filepath.Join(t.TempDir(), "new.go"))}},
+		},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,207 @@
+// Package mcpoauth implements the OAuth 2.1 authorization of remote MCP
+// servers: authorization server discovery, dynamic client registration, and
+// the authorization code flow with PKCE and a loopback redirect.
+package mcpoauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+const (
+	// clientName is the name the client registers with.
+	clientName = "zero"
+	// callbackPath is the path of the loopback redirect URI.
+	callbackPath = "/callback"
+)
+
+// Config configures the authorization of an MCP server.
+type Config struct {
+	// ServerURL is the URL of the MCP server.
+	ServerURL string
+	// ClientID and ClientSecret are a pre-registered client. When empty, the
+	// client is registered dynamically.
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// AuthServerMetadataURL is the URL of the authorization server metadata.
+	// When empty, it's discovered from the MCP server.
+	AuthServerMetadataURL string
+	// RedirectPort is the port of the loopback redirect URI, random when 0.
+	RedirectPort int
+}
+
+// ClientConfig returns the OAuth configuration of the MCP client transports,
+// which add the stored token to the requests and refresh it when it expires.
+func ClientConfig(cfg Config, store *Store) (transport.OAuthConfig, error) {
+	creds, err := store.Credentials()
+	if err != nil {
+		return transport.OAuthConfig{}, err
+	}
+	oauthCfg := transport.OAuthConfig{
+		ClientID:              cfg.ClientID,
+		ClientSecret:          cfg.ClientSecret,
+		RedirectURI:           creds.RedirectURI,
+		Scopes:                cfg.Scopes,
+		TokenStore:            store,
+		AuthServerMetadataURL: cfg.AuthServerMetadataURL,
+		PKCEEnabled:           true,
+	}
+	if oauthCfg.ClientID == "" {
+		oauthCfg.ClientID = creds.ClientID
+		oauthCfg.ClientSecret = creds.ClientSecret
+	}
+	return oauthCfg, nil
+}
+
+type callbackResult struct {
+	code  string
+	state string
+	err   error
+}
+
+// Authorize obtains a token for the MCP server and saves it in the store. It
+// discovers the authorization server, registers the client if needed, calls
+// openURL with the URL the user has to visit, and waits for the browser to
+// be redirected to a loopback server.
+func Authorize(ctx context.Context, cfg Config, store *Store, openURL func(authURL string)) error {
+	baseURL, err := serverBaseURL(cfg.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.RedirectPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for the oauth redirect: %w", err)
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d%s", listener.Addr().(*net.TCPAddr).Port, callbackPath)
+
+	creds, err := store.Credentials()
+	if err != nil {
+		return err
+	}
+	clientID, clientSecret := cfg.ClientID, cfg.ClientSecret
+	// A dynamically registered client can only be reused with the redirect
+	// URI it was registered with.
+	if clientID == "" && creds.RedirectURI == redirectURI {
+		clientID, clientSecret = creds.ClientID, creds.ClientSecret
+	}
+
+	handler := transport.NewOAuthHandler(transport.OAuthConfig{
+		ClientID:              clientID,
+		ClientSecret:          clientSecret,
+		RedirectURI:           redirectURI,
+		Scopes:                cfg.Scopes,
+		TokenStore:            store,
+		AuthServerMetadataURL: cfg.AuthServerMetadataURL,
+		PKCEEnabled:           true,
+	})
+	handler.SetBaseURL(baseURL)
+
+	if clientID == "" {
+		if err := handler.RegisterClient(ctx, clientName); err != nil {
+			return fmt.Errorf("failed to register the oauth client: %w", err)
+		}
+		if err := store.SaveCredentials(Credentials{
+			ClientID:     handler.GetClientID(),
+			ClientSecret: handler.GetClientSecret(),
+			RedirectURI:  redirectURI,
+		}); err != nil {
+			return err
+		}
+	}
+
+	verifier, err := transport.GenerateCodeVerifier()
+	if err != nil {
+		return fmt.Errorf("failed to generate the code verifier: %w", err)
+	}
+	state, err := transport.GenerateState()
+	if err != nil {
+		return fmt.Errorf("failed to generate the state: %w", err)
+	}
+	authURL, err := handler.GetAuthorizationURL(ctx, state, transport.GenerateCodeChallenge(verifier))
+	if err != nil {
+		return fmt.Errorf("failed to build the authorization url: %w", err)
+	}
+
+	results := make(chan callbackResult, 1)
+	srv := &http.Server{
+		Handler:           callbackHandler(results),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	defer srv.Close()
+
+	openURL(authURL)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-results:
+		if result.err != nil {
+			return result.err
+		}
+		if err := handler.ProcessAuthorizationResponse(ctx, result.code, result.state, verifier); err != nil {
+			return fmt.Errorf("failed to exchange the authorization code: %w", err)
+		}
+		return nil
+	}
+}
+
+// callbackHandler handles the redirect of the browser once the user
+// authorized, or refused, the access.
+func callbackHandler(results chan<- callbackResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var result callbackResult
+		switch {
+		case query.Get("error") != "":
+			result.err = transport.OAuthError{
+				ErrorCode:        query.Get("error"),
+				ErrorDescription: query.Get("error_description"),
+			}
+		case query.Get("code") == "":
+			result.err = errors.New("authorization response has no code")
+		default:
+			result.code = query.Get("code")
+			result.state = query.Get("state")
+		}
+
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Authorization failed: %s\n", result.err)
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you can close this window and return to zero.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	return mux
+}
+
+// serverBaseURL returns the URL the protected resource metadata is
+// discovered from, like the MCP client transports do.
+func serverBaseURL(serverURL string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid server url %q", serverURL)
+	}
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host), nil
+}
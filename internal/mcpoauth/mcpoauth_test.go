@@ -0,0 +1,271 @@
+package mcpoauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAuthServer is a minimal OAuth authorization server protecting an MCP
+// server. Its authorization endpoint approves the requests right away.
+type stubAuthServer struct {
+	url string
+
+	mu         sync.Mutex
+	clients    map[string]string // client ID to redirect URI
+	challenges map[string]string // code to PKCE challenge
+	tokens     map[string]bool
+	refreshes  int
+}
+
+func newStubAuthServer(t *testing.T) *stubAuthServer {
+	t.Helper()
+
+	s := &stubAuthServer{
+		clients:    make(map[string]string),
+		challenges: make(map[string]string),
+		tokens:     make(map[string]bool),
+	}
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("echo"), func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo"), nil
+	})
+	mcpHandler := server.NewStreamableHTTPServer(mcpServer)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, transport.OAuthProtectedResource{
+			Resource:             s.url + "/mcp",
+			AuthorizationServers: []string{s.url + "/auth"},
+		})
+	})
+	mux.HandleFunc("/auth/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, transport.AuthServerMetadata{
+			Issuer:                s.url + "/auth",
+			AuthorizationEndpoint: s.url + "/auth/authorize",
+			TokenEndpoint:         s.url + "/auth/token",
+			RegistrationEndpoint:  s.url + "/auth/register",
+		})
+	})
+	mux.HandleFunc("/auth/register", s.register)
+	mux.HandleFunc("/auth/authorize", s.authorize)
+	mux.HandleFunc("/auth/token", s.token)
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mcpHandler.ServeHTTP(w, r)
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	s.url = ts.URL
+	return s
+}
+
+func (s *stubAuthServer) register(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RedirectURIs []string `json:"redirect_uris"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.RedirectURIs) != 1 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	clientID := fmt.Sprintf("client-%d", len(s.clients)+1)
+	s.clients[clientID] = req.RedirectURIs[0]
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, map[string]string{"client_id": clientID})
+}
+
+func (s *stubAuthServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	redirectURI, ok := s.clients[query.Get("client_id")]
+	if !ok || redirectURI != query.Get("redirect_uri") || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	code := fmt.Sprintf("code-%d", len(s.challenges)+1)
+	s.challenges[code] = query.Get("code_challenge")
+
+	redirect, _ := url.Parse(redirectURI)
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *stubAuthServer) token(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		challenge, ok := s.challenges[r.FormValue("code")]
+		if !ok || transport.GenerateCodeChallenge(r.FormValue("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, transport.OAuthError{ErrorCode: "invalid_grant"})
+			return
+		}
+		delete(s.challenges, r.FormValue("code"))
+	case "refresh_token":
+		if r.FormValue("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, transport.OAuthError{ErrorCode: "invalid_grant"})
+			return
+		}
+		s.refreshes++
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, transport.OAuthError{ErrorCode: "unsupported_grant_type"})
+		return
+	}
+
+	accessToken := fmt.Sprintf("token-%d", len(s.tokens)+1)
+	s.tokens[accessToken] = true
+	writeJSON(w, transport.Token{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		RefreshToken: "refresh",
+		ExpiresIn:    3600,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// visit follows the authorization URL like a browser would.
+func visit(t *testing.T) func(string) {
+	return func(authURL string) {
+		resp, err := http.Get(authURL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func connect(t *testing.T, cfg Config, store *Store) (*client.Client, error) {
+	t.Helper()
+
+	oauthCfg, err := ClientConfig(cfg, store)
+	require.NoError(t, err)
+	c, err := client.NewOAuthStreamableHttpClient(cfg.ServerURL, oauthCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	return c, err
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	auth := newStubAuthServer(t)
+	cfg := Config{ServerURL: auth.url + "/mcp"}
+	store := NewStore(filepath.Join(t.TempDir(), "server.json"), cfg.ServerURL)
+
+	_, err := connect(t, cfg, store)
+	require.True(t, client.IsOAuthAuthorizationRequiredError(err))
+
+	require.NoError(t, Authorize(t.Context(), cfg, store, visit(t)))
+	creds, err := store.Credentials()
+	require.NoError(t, err)
+	require.Equal(t, "client-1", creds.ClientID)
+	require.True(t, strings.HasPrefix(creds.RedirectURI, "http://127.0.0.1:"))
+	require.NotNil(t, creds.Token)
+	require.Equal(t, "token-1", creds.Token.AccessToken)
+
+	c, err := connect(t, cfg, store)
+	require.NoError(t, err)
+	tools, err := c.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+}
+
+func TestAuthorizeRefresh(t *testing.T) {
+	t.Parallel()
+
+	auth := newStubAuthServer(t)
+	cfg := Config{ServerURL: auth.url + "/mcp"}
+	store := NewStore(filepath.Join(t.TempDir(), "server.json"), cfg.ServerURL)
+	require.NoError(t, Authorize(t.Context(), cfg, store, visit(t)))
+
+	token, err := store.GetToken()
+	require.NoError(t, err)
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, store.SaveToken(token))
+
+	_, err = connect(t, cfg, store)
+	require.NoError(t, err)
+	require.Equal(t, 1, auth.refreshes)
+	token, err = store.GetToken()
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token.AccessToken)
+	require.False(t, token.IsExpired())
+}
+
+func TestAuthorizeDenied(t *testing.T) {
+	t.Parallel()
+
+	auth := newStubAuthServer(t)
+	cfg := Config{ServerURL: auth.url + "/mcp"}
+	store := NewStore(filepath.Join(t.TempDir(), "server.json"), cfg.ServerURL)
+
+	err := Authorize(t.Context(), cfg, store, func(authURL string) {
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		redirect := u.Query().Get("redirect_uri") + "?error=access_denied&error_description=nope"
+		resp, err := http.Get(redirect)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	})
+	require.EqualError(t, err, "OAuth error: access_denied - nope")
+	_, err = store.GetToken()
+	require.ErrorIs(t, err, ErrNoToken)
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "oauth", "server.json")
+	store := NewStore(path, "https://example.com/mcp")
+	_, err := store.GetToken()
+	require.ErrorIs(t, err, ErrNoToken)
+
+	require.NoError(t, store.SaveCredentials(Credentials{ClientID: "client"}))
+	require.NoError(t, store.SaveToken(&transport.Token{AccessToken: "token"}))
+	creds, err := store.Credentials()
+	require.NoError(t, err)
+	require.Equal(t, "client", creds.ClientID)
+	require.Equal(t, "token", creds.Token.AccessToken)
+
+	// The credentials of another server are ignored.
+	other := NewStore(path, "https://example.org/mcp")
+	_, err = other.GetToken()
+	require.ErrorIs(t, err, ErrNoToken)
+
+	require.NoError(t, store.Delete())
+	require.NoError(t, store.Delete())
+	_, err = store.GetToken()
+	require.ErrorIs(t, err, ErrNoToken)
+}
@@ -0,0 +1,134 @@
+package mcpoauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// ErrNoToken is returned by [Store.GetToken] when the server hasn't been
+// authorized yet.
+var ErrNoToken = errors.New("no token available")
+
+// Credentials are the client registration and token stored for a server.
+type Credentials struct {
+	// ServerURL is the URL of the server the credentials were obtained for.
+	// They're ignored if the server URL changes.
+	ServerURL string `json:"server_url"`
+	// ClientID, ClientSecret and RedirectURI are the registration of the
+	// client, either dynamic or from the configuration.
+	ClientID     string           `json:"client_id,omitempty"`
+	ClientSecret string           `json:"client_secret,omitempty"`
+	RedirectURI  string           `json:"redirect_uri,omitempty"`
+	Token        *transport.Token `json:"token,omitempty"`
+}
+
+// Store persists the credentials of a server in a file. It implements
+// [transport.TokenStore], so refreshed tokens are saved too.
+type Store struct {
+	path      string
+	serverURL string
+	mu        sync.Mutex
+}
+
+var _ transport.TokenStore = (*Store)(nil)
+
+// NewStore creates a store for the credentials of the server at the given
+// URL, saved in the file at path.
+func NewStore(path, serverURL string) *Store {
+	return &Store{path: path, serverURL: serverURL}
+}
+
+// Credentials returns the stored credentials, which are empty if there are
+// none for the server URL.
+func (s *Store) Credentials() (Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// SaveCredentials replaces the stored credentials.
+func (s *Store) SaveCredentials(creds Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(creds)
+}
+
+// Delete removes the stored credentials.
+func (s *Store) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete oauth credentials: %w", err)
+	}
+	return nil
+}
+
+// GetToken implements [transport.TokenStore].
+func (s *Store) GetToken() (*transport.Token, error) {
+	creds, err := s.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	if creds.Token == nil {
+		return nil, ErrNoToken
+	}
+	return creds.Token, nil
+}
+
+// SaveToken implements [transport.TokenStore], keeping the client
+// registration.
+func (s *Store) SaveToken(token *transport.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	creds, err := s.load()
+	if err != nil {
+		return err
+	}
+	creds.Token = token
+	return s.save(creds)
+}
+
+func (s *Store) load() (Credentials, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{ServerURL: s.serverURL}, nil
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read oauth credentials: %w", err)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("failed to parse oauth credentials: %w", err)
+	}
+	if creds.ServerURL != s.serverURL {
+		return Credentials{ServerURL: s.serverURL}, nil
+	}
+	return creds, nil
+}
+
+func (s *Store) save(creds Credentials) error {
+	creds.ServerURL = s.serverURL
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode oauth credentials: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create oauth credentials directory: %w", err)
+	}
+	// Write to a temporary file first so a crash can't leave a truncated
+	// file behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write oauth credentials: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write oauth credentials: %w", err)
+	}
+	return nil
+}
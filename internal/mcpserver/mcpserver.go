@@ -0,0 +1,245 @@
+// Package mcpserver exposes zero's tools to other agents and editors as a
+// Model Context Protocol server.
+package mcpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+// shutdownTimeout bounds the time spent closing the HTTP server.
+const shutdownTimeout = 5 * time.Second
+
+// Options configures the server.
+type Options struct {
+	Name    string
+	Version string
+	Tools   []tools.BaseTool
+	// NewSession creates the session the tool calls of a client are made in,
+	// which tracks the file history and the task agent sessions.
+	NewSession func(ctx context.Context) (string, error)
+}
+
+// Server serves tools over MCP. Each client gets its own session.
+type Server struct {
+	mcp        *server.MCPServer
+	newSession func(ctx context.Context) (string, error)
+
+	// sessions maps the MCP session IDs to the zero session IDs.
+	sessions   map[string]string
+	sessionsMu sync.Mutex
+}
+
+// New creates a server exposing the given tools.
+func New(opts Options) *Server {
+	s := &Server{
+		mcp:        server.NewMCPServer(opts.Name, opts.Version, server.WithToolCapabilities(false)),
+		newSession: opts.NewSession,
+		sessions:   make(map[string]string),
+	}
+	for _, tool := range opts.Tools {
+		s.mcp.AddTool(mcpTool(tool.Info()), s.handler(tool))
+	}
+	return s
+}
+
+// ServeStdio serves a single client over stdin and stdout until the context
+// is done or the input is closed.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	stdio := server.NewStdioServer(s.mcp)
+	stdio.SetErrorLogger(slog.NewLogLogger(slog.Default().Handler(), slog.LevelError))
+	err := stdio.Listen(ctx, in, out)
+	if errors.Is(err, context.Canceled) || errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// HTTPPath is the path of the MCP endpoint of the HTTP transport.
+const HTTPPath = "/mcp"
+
+// NewToken returns a random bearer token for [Server.ServeHTTP].
+func NewToken() string {
+	return rand.Text()
+}
+
+// ServeHTTP serves clients over streamable HTTP on the given address until
+// the context is done. Clients must send the token as a bearer token.
+func (s *Server) ServeHTTP(ctx context.Context, addr, token string) error {
+	if token == "" {
+		return errors.New("a token is required to serve over HTTP")
+	}
+	srv := &http.Server{Addr: addr}
+	httpServer := server.NewStreamableHTTPServer(s.mcp, server.WithStreamableHTTPServer(srv))
+	mux := http.NewServeMux()
+	mux.Handle(HTTPPath, authorize(httpServer, token))
+	srv.Handler = mux
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- httpServer.Start(addr)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// authorize refuses the requests without the bearer token. It also refuses
+// the requests browsers make for web pages of other sites, which they send
+// the origin of, so that a page can't reach the server through DNS
+// rebinding.
+func authorize(next http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !isLocalOrigin(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLocalOrigin reports whether the origin is a page served from this
+// machine.
+func isLocalOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// mcpTool converts the tool info to the MCP tool definition.
+func mcpTool(info tools.ToolInfo) mcp.Tool {
+	properties := info.Parameters
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	required := info.Required
+	if required == nil {
+		required = []string{}
+	}
+	return mcp.Tool{
+		Name:        info.Name,
+		Description: info.Description,
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: properties,
+			Required:   required,
+		},
+	}
+}
+
+func (s *Server) handler(tool tools.BaseTool) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID, err := s.session(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		input := []byte("{}")
+		if req.Params.Arguments != nil {
+			input, err = json.Marshal(req.Params.Arguments)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("error encoding arguments: %s", err)), nil
+			}
+		}
+
+		ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+		ctx = context.WithValue(ctx, tools.MessageIDContextKey, uuid.New().String())
+		response, err := tool.Run(ctx, tools.ToolCall{
+			ID:    uuid.New().String(),
+			Name:  tool.Name(),
+			Input: string(input),
+		})
+		if errors.Is(err, permission.ErrorPermissionDenied) {
+			return mcp.NewToolResultError("Permission denied by the permission policy of the server"), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return callToolResult(response), nil
+	}
+}
+
+// session returns the zero session of the MCP client making the request,
+// creating it on its first call.
+func (s *Server) session(ctx context.Context) (string, error) {
+	var key string
+	if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+		key = clientSession.SessionID()
+	}
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if sessionID, ok := s.sessions[key]; ok {
+		return sessionID, nil
+	}
+	sessionID, err := s.newSession(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	s.sessions[key] = sessionID
+	return sessionID, nil
+}
+
+// callToolResult converts the tool response to the MCP result. Image
+// responses hold the base64 encoded image.
+func callToolResult(response tools.ToolResponse) *mcp.CallToolResult {
+	var content mcp.Content
+	switch response.Type {
+	case tools.ToolResponseTypeImage:
+		data, err := base64.StdEncoding.DecodeString(response.Content)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid image: %s", err))
+		}
+		content = mcp.NewImageContent(response.Content, http.DetectContentType(data))
+	default:
+		content = mcp.NewTextContent(response.Content)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{content},
+		IsError: response.IsError,
+	}
+}
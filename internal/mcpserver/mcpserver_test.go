@@ -0,0 +1,211 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/llm/tools"
+	"github.com/vikvang/zero/internal/permission"
+)
+
+type fakeTool struct {
+	info tools.ToolInfo
+	run  func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error)
+}
+
+func (t *fakeTool) Name() string         { return t.info.Name }
+func (t *fakeTool) Info() tools.ToolInfo { return t.info }
+
+func (t *fakeTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	return t.run(ctx, call)
+}
+
+var echoTool = &fakeTool{
+	info: tools.ToolInfo{
+		Name:        "echo",
+		Description: "Echoes the text",
+		Parameters: map[string]any{
+			"text": map[string]any{"type": "string"},
+		},
+		Required: []string{"text"},
+	},
+	run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		var params struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+			return tools.NewTextErrorResponse(err.Error()), nil
+		}
+		sessionID, _ := tools.GetContextValues(ctx)
+		return tools.NewTextResponse(sessionID + ": " + params.Text), nil
+	},
+}
+
+const testToken = "test-token"
+
+// newTestClient connects a client to the server over streamable HTTP, which
+// unlike the in-process transport gives each client its own MCP session.
+func newTestClient(t *testing.T, url string) *client.Client {
+	t.Helper()
+
+	c, err := client.NewStreamableHttpClient(url, transport.WithHTTPHeaders(map[string]string{
+		"Authorization": "Bearer " + testToken,
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := t.Context()
+	require.NoError(t, c.Start(ctx))
+	_, err = c.Initialize(ctx, mcp.InitializeRequest{})
+	require.NoError(t, err)
+	return c
+}
+
+// newTestServer serves the tools over HTTP and returns the server URL.
+func newTestServer(t *testing.T, tools ...tools.BaseTool) string {
+	t.Helper()
+
+	var sessions atomic.Int32
+	srv := New(Options{
+		Name:    "zero",
+		Version: "test",
+		Tools:   tools,
+		NewSession: func(context.Context) (string, error) {
+			return fmt.Sprintf("session-%d", sessions.Add(1)), nil
+		},
+	})
+	ts := httptest.NewServer(authorize(server.NewStreamableHTTPServer(srv.mcp), testToken))
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func callTool(t *testing.T, c *client.Client, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := c.CallTool(t.Context(), req)
+	require.NoError(t, err)
+	return result
+}
+
+func TestServerListTools(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, newTestServer(t, echoTool))
+	result, err := c.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	require.Len(t, result.Tools, 1)
+
+	tool := result.Tools[0]
+	require.Equal(t, "echo", tool.Name)
+	require.Equal(t, "Echoes the text", tool.Description)
+	require.Equal(t, "object", tool.InputSchema.Type)
+	require.Equal(t, []string{"text"}, tool.InputSchema.Required)
+	require.Contains(t, tool.InputSchema.Properties, "text")
+}
+
+func TestServerCallTool(t *testing.T) {
+	t.Parallel()
+
+	url := newTestServer(t, echoTool)
+	first := newTestClient(t, url)
+	second := newTestClient(t, url)
+
+	result := callTool(t, first, "echo", map[string]any{"text": "hello"})
+	require.False(t, result.IsError)
+	require.Equal(t, []mcp.Content{mcp.NewTextContent("session-1: hello")}, result.Content)
+
+	// The calls of a client share its session.
+	result = callTool(t, first, "echo", map[string]any{"text": "again"})
+	require.Equal(t, []mcp.Content{mcp.NewTextContent("session-1: again")}, result.Content)
+
+	result = callTool(t, second, "echo", map[string]any{"text": "hi"})
+	require.Equal(t, []mcp.Content{mcp.NewTextContent("session-2: hi")}, result.Content)
+}
+
+func TestServerCallToolErrors(t *testing.T) {
+	t.Parallel()
+
+	denied := &fakeTool{
+		info: tools.ToolInfo{Name: "denied"},
+		run: func(context.Context, tools.ToolCall) (tools.ToolResponse, error) {
+			return tools.ToolResponse{}, permission.ErrorPermissionDenied
+		},
+	}
+	c := newTestClient(t, newTestServer(t, echoTool, denied))
+
+	result := callTool(t, c, "denied", nil)
+	require.True(t, result.IsError)
+	require.Equal(t, []mcp.Content{mcp.NewTextContent("Permission denied by the permission policy of the server")}, result.Content)
+
+	result = callTool(t, c, "echo", map[string]any{"text": 1})
+	require.True(t, result.IsError)
+}
+
+func TestServerHTTPAuthorization(t *testing.T) {
+	t.Parallel()
+
+	url := newTestServer(t, echoTool)
+	const initialize = `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"token", map[string]string{"Authorization": "Bearer " + testToken}, http.StatusOK},
+		{"local origin", map[string]string{"Authorization": "Bearer " + testToken, "Origin": "http://localhost:3000"}, http.StatusOK},
+		{"no token", nil, http.StatusUnauthorized},
+		{"wrong token", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"other origin", map[string]string{"Authorization": "Bearer " + testToken, "Origin": "https://example.com"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, url, strings.NewReader(initialize))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestCallToolResult(t *testing.T) {
+	t.Parallel()
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+		result := callToolResult(tools.NewTextErrorResponse("boom"))
+		require.True(t, result.IsError)
+		require.Equal(t, []mcp.Content{mcp.NewTextContent("boom")}, result.Content)
+	})
+
+	t.Run("image", func(t *testing.T) {
+		t.Parallel()
+		png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+		result := callToolResult(tools.ToolResponse{Type: tools.ToolResponseTypeImage, Content: png})
+		require.False(t, result.IsError)
+		require.Equal(t, []mcp.Content{mcp.NewImageContent(png, "image/png")}, result.Content)
+	})
+}
@@ -0,0 +1,48 @@
+package mcpserver
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vikvang/zero/internal/permission"
+)
+
+// PermissionMode decides the permission requests that would prompt the user,
+// since there's no one to ask. Permission rules, allowed tools and saved
+// grants apply first.
+type PermissionMode string
+
+const (
+	// PermissionModeDeny denies the requests, so only the calls allowed by
+	// the configuration run.
+	PermissionModeDeny PermissionMode = "deny"
+	// PermissionModeAllow grants the requests, except those denied by rules.
+	PermissionModeAllow PermissionMode = "allow"
+)
+
+// PermissionModes lists the supported permission modes.
+var PermissionModes = []PermissionMode{PermissionModeDeny, PermissionModeAllow}
+
+// IsValid reports whether the permission mode is supported.
+func (m PermissionMode) IsValid() bool {
+	switch m {
+	case PermissionModeDeny, PermissionModeAllow:
+		return true
+	default:
+		return false
+	}
+}
+
+// AnswerPermissions answers the permission requests according to the mode
+// until the context is done.
+func AnswerPermissions(ctx context.Context, permissions permission.Service, mode PermissionMode) {
+	for event := range permissions.Subscribe(ctx) {
+		request := event.Payload
+		if mode == PermissionModeAllow {
+			permissions.Grant(request)
+			continue
+		}
+		slog.Info("Permission denied by the mcp server permission mode", "tool", request.ToolName, "action", request.Action, "path", request.Path)
+		permissions.Deny(request)
+	}
+}
@@ -1,8 +1,29 @@
 package message
 
+import "strings"
+
 type Attachment struct {
 	FilePath string
 	FileName string
 	MimeType string
 	Content  []byte
 }
+
+// IsText reports whether the attachment is text, like an MCP resource, which
+// is sent as part of the prompt rather than as an image.
+func (a Attachment) IsText() bool {
+	return isTextMIMEType(a.MimeType)
+}
+
+func isTextMIMEType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.TrimSpace(mimeType)
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/toml", "application/javascript":
+		return true
+	}
+	return strings.HasSuffix(mimeType, "+json") || strings.HasSuffix(mimeType, "+xml")
+}
@@ -26,6 +26,7 @@ const (
 	FinishReasonCanceled         FinishReason = "canceled"
 	FinishReasonError            FinishReason = "error"
 	FinishReasonPermissionDenied FinishReason = "permission_denied"
+	FinishReasonBudgetExceeded   FinishReason = "budget_exceeded"
 
 	// Should never happen
 	FinishReasonUnknown FinishReason = "unknown"
@@ -82,6 +83,11 @@ func (bc BinaryContent) String(p catwalk.InferenceProvider) string {
 	return base64Encoded
 }
 
+// IsText reports whether the content is text rather than an image.
+func (bc BinaryContent) IsText() bool {
+	return isTextMIMEType(bc.MIMEType)
+}
+
 func (BinaryContent) isPart() {}
 
 type ToolCall struct {
@@ -120,8 +126,13 @@ type Message struct {
 	Parts     []ContentPart
 	Model     string
 	Provider  string
-	CreatedAt int64
-	UpdatedAt int64
+	// Cost and token usage of the response of the model, for assistant
+	// messages.
+	Cost             float64
+	PromptTokens     int64
+	CompletionTokens int64
+	CreatedAt        int64
+	UpdatedAt        int64
 }
 
 func (m *Message) Content() TextContent {
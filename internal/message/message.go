@@ -13,10 +13,13 @@ import (
 )
 
 type CreateMessageParams struct {
-	Role     MessageRole
-	Parts    []ContentPart
-	Model    string
-	Provider string
+	Role             MessageRole
+	Parts            []ContentPart
+	Model            string
+	Provider         string
+	Cost             float64
+	PromptTokens     int64
+	CompletionTokens int64
 }
 
 type Service interface {
@@ -27,6 +30,9 @@ type Service interface {
 	List(ctx context.Context, sessionID string) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	// CostSince returns the cost of the messages of all sessions created
+	// since the given time.
+	CostSince(ctx context.Context, since time.Time) (float64, error)
 }
 
 type service struct {
@@ -65,12 +71,15 @@ func (s *service) Create(ctx context.Context, sessionID string, params CreateMes
 		return Message{}, err
 	}
 	dbMessage, err := s.q.CreateMessage(ctx, db.CreateMessageParams{
-		ID:        uuid.New().String(),
-		SessionID: sessionID,
-		Role:      string(params.Role),
-		Parts:     string(partsJSON),
-		Model:     sql.NullString{String: string(params.Model), Valid: true},
-		Provider:  sql.NullString{String: params.Provider, Valid: params.Provider != ""},
+		ID:               uuid.New().String(),
+		SessionID:        sessionID,
+		Role:             string(params.Role),
+		Parts:            string(partsJSON),
+		Model:            sql.NullString{String: string(params.Model), Valid: true},
+		Provider:         sql.NullString{String: params.Provider, Valid: params.Provider != ""},
+		Cost:             params.Cost,
+		PromptTokens:     params.PromptTokens,
+		CompletionTokens: params.CompletionTokens,
 	})
 	if err != nil {
 		return Message{}, err
@@ -110,9 +119,14 @@ func (s *service) Update(ctx context.Context, message Message) error {
 		finishedAt.Valid = true
 	}
 	err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
-		ID:         message.ID,
-		Parts:      string(parts),
-		FinishedAt: finishedAt,
+		ID:               message.ID,
+		Parts:            string(parts),
+		FinishedAt:       finishedAt,
+		Model:            sql.NullString{String: message.Model, Valid: true},
+		Provider:         sql.NullString{String: message.Provider, Valid: message.Provider != ""},
+		Cost:             message.Cost,
+		PromptTokens:     message.PromptTokens,
+		CompletionTokens: message.CompletionTokens,
 	})
 	if err != nil {
 		return err
@@ -145,20 +159,27 @@ func (s *service) List(ctx context.Context, sessionID string) ([]Message, error)
 	return messages, nil
 }
 
+func (s *service) CostSince(ctx context.Context, since time.Time) (float64, error) {
+	return s.q.GetCostSince(ctx, since.Unix())
+}
+
 func (s *service) fromDBItem(item db.Message) (Message, error) {
 	parts, err := unmarshallParts([]byte(item.Parts))
 	if err != nil {
 		return Message{}, err
 	}
 	return Message{
-		ID:        item.ID,
-		SessionID: item.SessionID,
-		Role:      MessageRole(item.Role),
-		Parts:     parts,
-		Model:     item.Model.String,
-		Provider:  item.Provider.String,
-		CreatedAt: item.CreatedAt,
-		UpdatedAt: item.UpdatedAt,
+		ID:               item.ID,
+		SessionID:        item.SessionID,
+		Role:             MessageRole(item.Role),
+		Parts:            parts,
+		Model:            item.Model.String,
+		Provider:         item.Provider.String,
+		Cost:             item.Cost,
+		PromptTokens:     item.PromptTokens,
+		CompletionTokens: item.CompletionTokens,
+		CreatedAt:        item.CreatedAt,
+		UpdatedAt:        item.UpdatedAt,
 	}, nil
 }
 
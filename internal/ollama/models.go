@@ -0,0 +1,115 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+const (
+	// defaultContextWindow is used when the server doesn't report the
+	// context length of a model, it matches the default of Ollama.
+	defaultContextWindow = 4096
+	// maxDefaultMaxTokens caps the default number of tokens to generate.
+	maxDefaultMaxTokens = 8192
+)
+
+type tagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	} `json:"models"`
+}
+
+type showResponse struct {
+	Parameters   string         `json:"parameters"`
+	ModelInfo    map[string]any `json:"model_info"`
+	Capabilities []string       `json:"capabilities"`
+}
+
+// ListModels returns the models installed on the server that can generate
+// text, sorted by ID, with their context window and capabilities filled in
+// from the model information.
+func (c *Client) ListModels(ctx context.Context) ([]catwalk.Model, error) {
+	var tags tagsResponse
+	if err := c.getJSON(ctx, http.MethodGet, "/api/tags", nil, &tags); err != nil {
+		return nil, err
+	}
+
+	models := make([]*catwalk.Model, len(tags.Models))
+	var wg sync.WaitGroup
+	for i, tag := range tags.Models {
+		id := tag.Name
+		if id == "" {
+			id = tag.Model
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var show showResponse
+			if err := c.getJSON(ctx, http.MethodPost, "/api/show", map[string]string{"model": id}, &show); err != nil {
+				slog.Warn("Failed to get Ollama model information", "model", id, "error", err)
+			}
+			models[i] = modelFromShow(id, show)
+		}()
+	}
+	wg.Wait()
+
+	var result []catwalk.Model
+	for _, model := range models {
+		if model != nil {
+			result = append(result, *model)
+		}
+	}
+	slices.SortFunc(result, func(a, b catwalk.Model) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return result, nil
+}
+
+// modelFromShow converts the information of a model, returning nil for
+// models that can't generate text, like embedding models.
+func modelFromShow(id string, show showResponse) *catwalk.Model {
+	if len(show.Capabilities) > 0 && !slices.Contains(show.Capabilities, "completion") {
+		return nil
+	}
+
+	contextWindow := contextLength(show)
+	return &catwalk.Model{
+		ID:               id,
+		Name:             id,
+		ContextWindow:    contextWindow,
+		DefaultMaxTokens: min(contextWindow/4, maxDefaultMaxTokens),
+		CanReason:        slices.Contains(show.Capabilities, "thinking"),
+		SupportsImages:   slices.Contains(show.Capabilities, "vision"),
+	}
+}
+
+// contextLength returns the context length the model is run with, which is
+// the num_ctx parameter of its Modelfile if set, or else the context length
+// the model was trained with.
+func contextLength(show showResponse) int64 {
+	scanner := bufio.NewScanner(strings.NewReader(show.Parameters))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if n, err := strconv.ParseInt(fields[1], 10, 64); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+
+	if arch, ok := show.ModelInfo["general.architecture"].(string); ok {
+		if n, ok := show.ModelInfo[arch+".context_length"].(float64); ok && n > 0 {
+			return int64(n)
+		}
+	}
+	return defaultContextWindow
+}
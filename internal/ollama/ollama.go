@@ -0,0 +1,222 @@
+// Package ollama is a client for the native API of Ollama and compatible local
+// model servers. It discovers the installed models with their context length
+// and capabilities, and streams chat completions.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultBaseURL is the address an Ollama server listens on by default.
+const DefaultBaseURL = "http://localhost:11434"
+
+// maxLineSize bounds a single streamed response line.
+const maxLineSize = 8 * 1024 * 1024
+
+type Client struct {
+	baseURL    string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL. A trailing "/v1", as
+// used for the OpenAI-compatible endpoints, is ignored.
+func NewClient(baseURL string, headers map[string]string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    NormalizeBaseURL(baseURL),
+		headers:    headers,
+		httpClient: httpClient,
+	}
+}
+
+// NormalizeBaseURL returns the root URL of the server, defaulting to
+// DefaultBaseURL.
+func NormalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return baseURL
+}
+
+// StatusError is returned when the server answers with an error status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ollama: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("ollama: %s (status %d)", e.Message, e.StatusCode)
+}
+
+// IsToolsUnsupported reports whether the server rejected a request because
+// the model can't call tools.
+func IsToolsUnsupported(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) &&
+		statusErr.StatusCode == http.StatusBadRequest &&
+		strings.Contains(statusErr.Message, "does not support tools")
+}
+
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"`
+	Images    [][]byte   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Index int    `json:"index,omitempty"`
+	Name  string `json:"name"`
+	// Arguments is usually an object, but some models produce a string
+	// holding the encoded object instead.
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type ChatRequest struct {
+	Model    string         `json:"model"`
+	Messages []Message      `json:"messages"`
+	Tools    []Tool         `json:"tools,omitempty"`
+	Stream   bool           `json:"stream"`
+	Think    *bool          `json:"think,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+type ChatResponse struct {
+	Model           string  `json:"model"`
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason,omitempty"`
+	PromptEvalCount int64   `json:"prompt_eval_count,omitempty"`
+	EvalCount       int64   `json:"eval_count,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Chat sends a chat request and calls fn with every response. Streamed
+// requests produce partial responses, the last one having Done set.
+func (c *Client) Chat(ctx context.Context, req ChatRequest, fn func(ChatResponse) error) error {
+	resp, err := c.do(ctx, http.MethodPost, "/api/chat", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("ollama: invalid response: %w", err)
+		}
+		if chunk.Error != "" {
+			return &StatusError{StatusCode: resp.StatusCode, Message: chunk.Error}
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Version returns the version of the server.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := c.getJSON(ctx, http.MethodGet, "/api/version", nil, &version); err != nil {
+		return "", err
+	}
+	return version.Version, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ollama: invalid response from %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("ollama: failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			statusErr.Message = apiErr.Error
+		} else {
+			statusErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, statusErr
+	}
+	return resp, nil
+}
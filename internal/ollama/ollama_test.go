@@ -0,0 +1,119 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	shows := map[string]string{
+		"qwen3:30b": `{
+			"parameters": "stop \"<|im_end|>\"\ntemperature 0.6",
+			"model_info": {"general.architecture": "qwen3moe", "qwen3moe.context_length": 262144},
+			"capabilities": ["completion", "tools", "thinking"]
+		}`,
+		"llava:7b": `{
+			"parameters": "num_ctx                        8192\nstop \"</s>\"",
+			"model_info": {"general.architecture": "llama", "llama.context_length": 32768},
+			"capabilities": ["completion", "vision"]
+		}`,
+		"nomic-embed-text:latest": `{
+			"model_info": {"general.architecture": "nomic-bert", "nomic-bert.context_length": 2048},
+			"capabilities": ["embedding"]
+		}`,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models": [{"name": "qwen3:30b"}, {"name": "nomic-embed-text:latest"}, {"name": "llava:7b"}, {"name": "broken:1b"}]}`)
+	})
+	mux.HandleFunc("POST /api/show", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		show, ok := shows[req.Model]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error": "model '%s' not found"}`, req.Model)
+			return
+		}
+		fmt.Fprint(w, show)
+	})
+	mux.HandleFunc("POST /api/chat", func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Model == "gemma:2b" && len(req.Tools) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "registry.ollama.ai/library/gemma:2b does not support tools"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "", "thinking": "hmm"}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "Hi"}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "view", "arguments": {"file_path": "main.go"}}}]}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 12, "eval_count": 3}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListModels(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	models, err := NewClient(server.URL+"/v1/", nil, nil).ListModels(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []catwalk.Model{
+		{ID: "broken:1b", Name: "broken:1b", ContextWindow: 4096, DefaultMaxTokens: 1024},
+		{ID: "llava:7b", Name: "llava:7b", ContextWindow: 8192, DefaultMaxTokens: 2048, SupportsImages: true},
+		{ID: "qwen3:30b", Name: "qwen3:30b", ContextWindow: 262144, DefaultMaxTokens: 8192, CanReason: true},
+	}, models)
+}
+
+func TestChat(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	client := NewClient(server.URL, nil, nil)
+
+	var responses []ChatResponse
+	err := client.Chat(context.Background(), ChatRequest{Model: "qwen3:30b", Stream: true}, func(resp ChatResponse) error {
+		responses = append(responses, resp)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 4)
+	require.Equal(t, "hmm", responses[0].Message.Thinking)
+	require.Equal(t, "Hi", responses[1].Message.Content)
+	require.Equal(t, "view", responses[2].Message.ToolCalls[0].Function.Name)
+	require.JSONEq(t, `{"file_path": "main.go"}`, string(responses[2].Message.ToolCalls[0].Function.Arguments))
+	require.True(t, responses[3].Done)
+	require.Equal(t, int64(12), responses[3].PromptEvalCount)
+
+	err = client.Chat(context.Background(), ChatRequest{
+		Model: "gemma:2b",
+		Tools: []Tool{{Type: "function", Function: ToolFunction{Name: "view"}}},
+	}, func(ChatResponse) error { return nil })
+	require.Error(t, err)
+	require.True(t, IsToolsUnsupported(err))
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, DefaultBaseURL, NormalizeBaseURL(""))
+	require.Equal(t, "http://localhost:11434", NormalizeBaseURL("http://localhost:11434/v1/"))
+	require.Equal(t, "http://gpu-box:11434", NormalizeBaseURL("gpu-box:11434"))
+	require.Equal(t, "https://ollama.internal/api-proxy", NormalizeBaseURL("https://ollama.internal/api-proxy/"))
+}
@@ -0,0 +1,240 @@
+package permission
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Command is a bash command line parsed into everything it runs and writes.
+type Command struct {
+	// Programs are the simple commands of the command line, including the
+	// ones in pipelines, subshells, functions and command and process
+	// substitutions, in the order they are written.
+	Programs []Program
+	// Writes are the targets of the redirections that write to files.
+	Writes []string
+}
+
+// Program is a single program invocation of a command line.
+type Program struct {
+	// Args are the program name and its arguments. Words that aren't
+	// literal, e.g. because they expand a variable, are kept as written.
+	Args []string
+	// Text is the invocation as written, including its variable
+	// assignments.
+	Text string
+}
+
+// ParseCommand parses a bash command line.
+func ParseCommand(command string) (Command, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return Command{}, fmt.Errorf("could not parse command: %w", err)
+	}
+
+	var cmd Command
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.CallExpr:
+			// Only assigning variables doesn't run a program.
+			if len(node.Args) == 0 {
+				break
+			}
+			args := make([]string, 0, len(node.Args))
+			for _, word := range node.Args {
+				args = append(args, wordValue(word))
+			}
+			cmd.Programs = append(cmd.Programs, Program{Args: args, Text: printNode(node)})
+		case *syntax.DeclClause:
+			// declare, export and friends change the state of the shell.
+			cmd.Programs = append(cmd.Programs, Program{Args: []string{node.Variant.Value}, Text: printNode(node)})
+		case *syntax.Redirect:
+			if writesFile(node) {
+				cmd.Writes = append(cmd.Writes, printNode(node.Word))
+			}
+		}
+		return true
+	})
+	return cmd, nil
+}
+
+// ReadOnly reports whether the command only runs safe commands and doesn't
+// redirect any output to a file.
+func (c Command) ReadOnly() bool {
+	if len(c.Programs) == 0 || len(c.Writes) > 0 {
+		return false
+	}
+	for _, p := range c.Programs {
+		if !isSafeCommand(p.Args) {
+			return false
+		}
+	}
+	return true
+}
+
+// Texts returns the programs as written.
+func (c Command) Texts() []string {
+	texts := make([]string, 0, len(c.Programs))
+	for _, p := range c.Programs {
+		texts = append(texts, p.Text)
+	}
+	return texts
+}
+
+// isReadOnlyCommand reports whether the bash command line is read-only.
+func isReadOnlyCommand(command string) bool {
+	cmd, err := ParseCommand(command)
+	return err == nil && cmd.ReadOnly()
+}
+
+// isSafeCommand reports whether the arguments start with one of the safe
+// commands. The commands run by wrappers, e.g. "timeout 5 rm -rf build",
+// must be safe too.
+func isSafeCommand(args []string) bool {
+	i := slices.IndexFunc(safeCommands, func(cmd string) bool {
+		fields := strings.Fields(cmd)
+		return len(args) >= len(fields) && slices.Equal(args[:len(fields)], fields)
+	})
+	if i < 0 {
+		return false
+	}
+	if check, ok := safeArguments[safeCommands[i]]; ok && !check(args[len(strings.Fields(safeCommands[i])):]) {
+		return false
+	}
+	w, ok := wrapperCommands[args[0]]
+	if !ok {
+		return true
+	}
+	wrapped, ok := w.wrapped(args[1:])
+	if !ok {
+		return false
+	}
+	return len(wrapped) == 0 || isSafeCommand(wrapped)
+}
+
+// wrapper describes the arguments of a command that runs another command.
+type wrapper struct {
+	// options maps the known options to whether they take a value.
+	options map[string]bool
+	// operands is the number of arguments before the wrapped command.
+	operands int
+	// assignments is whether variables can be set for the wrapped command.
+	assignments bool
+}
+
+// wrapperCommands are the safe commands that run the command given in their
+// arguments.
+var wrapperCommands = map[string]wrapper{
+	"env": {options: map[string]bool{
+		"-0": false, "--null": false,
+		"-i": false, "--ignore-environment": false,
+		"-u": true, "--unset": true,
+	}, assignments: true},
+	"nice": {options: map[string]bool{
+		"-n": true, "--adjustment": true,
+	}},
+	"time": {options: map[string]bool{
+		"-p": false,
+	}},
+	"timeout": {options: map[string]bool{
+		"--foreground": false, "--preserve-status": false,
+		"-v": false, "--verbose": false,
+		"-k": true, "--kill-after": true,
+		"-s": true, "--signal": true,
+	}, operands: 1},
+}
+
+// wrapped returns the command run by the wrapper, which is empty if the
+// wrapper is run on its own. It reports false when an argument isn't
+// understood, as it might change what is run.
+func (w wrapper) wrapped(args []string) ([]string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return w.afterOptions(args[i+1:]), true
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			option, _, hasValue := strings.Cut(arg, "=")
+			takesValue, ok := w.options[option]
+			if !ok {
+				return nil, false
+			}
+			if takesValue && !hasValue {
+				i++
+			}
+		default:
+			return w.afterOptions(args[i:]), true
+		}
+	}
+	return nil, true
+}
+
+func (w wrapper) afterOptions(args []string) []string {
+	if len(args) < w.operands {
+		return nil
+	}
+	args = args[w.operands:]
+	for w.assignments && len(args) > 0 && strings.Contains(args[0], "=") {
+		args = args[1:]
+	}
+	return args
+}
+
+// writesFile reports whether the redirection writes to a file. Duplicating
+// file descriptors and discarding output don't.
+func writesFile(r *syntax.Redirect) bool {
+	target := r.Word.Lit()
+	switch r.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll, syntax.RdrInOut:
+		return target != "/dev/null"
+	case syntax.DplOut:
+		return !isFileDescriptor(target)
+	default:
+		return false
+	}
+}
+
+func isFileDescriptor(s string) bool {
+	if s == "-" {
+		return true
+	}
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// wordValue returns the value of a literal word, or the word as written.
+func wordValue(word *syntax.Word) string {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return printNode(word)
+			}
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, p := range part.Parts {
+				lit, ok := p.(*syntax.Lit)
+				if !ok {
+					return printNode(word)
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return printNode(word)
+		}
+	}
+	return sb.String()
+}
+
+func printNode(node syntax.Node) string {
+	var sb strings.Builder
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&sb, node); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,100 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := ParseCommand(`FOO=1 git log --oneline | head -n 5 && (cd "sub dir"; ls $(go env GOPATH)) > out.txt 2>&1`)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"FOO=1 git log --oneline",
+		"head -n 5",
+		`cd "sub dir"`,
+		"ls $(go env GOPATH)",
+		"go env GOPATH",
+	}, cmd.Texts())
+	require.Equal(t, []string{"cd", "sub dir"}, cmd.Programs[2].Args)
+	require.Equal(t, []string{"ls", "$(go env GOPATH)"}, cmd.Programs[3].Args)
+	require.Equal(t, []string{"out.txt"}, cmd.Writes)
+
+	_, err = ParseCommand("echo 'unterminated")
+	require.Error(t, err)
+}
+
+func TestCommand_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"ls -la", true},
+		{"git status && git diff --stat", true},
+		{"git log | git shortlog", true},
+		{"ls 2>/dev/null", true},
+		{"ls 2>&1", true},
+		{"echo $HOME", true},
+		{"timeout 5 git status", true},
+		{"env -i FOO=bar pwd", true},
+		{"env", true},
+		{"lsblk", false},
+		{"ls && rm -rf build", false},
+		{"ls; rm -rf build", false},
+		{"ls | xargs rm", false},
+		{"echo x > important.go", false},
+		{"echo x >> important.go", false},
+		{"ls &> out.txt", false},
+		{"ls >&out.txt", false},
+		{"echo $(rm -rf build)", false},
+		{"(cd /tmp && rm -rf build)", false},
+		{"diff <(ls) <(rm -rf build)", false},
+		{"export FOO=bar", false},
+		{"$EDITOR main.go", false},
+		{"timeout 5 rm -rf build", false},
+		{"env FOO=bar rm -rf build", false},
+		{"env -S 'rm -rf build'", false},
+		{"nice -n 10 rm -rf build", false},
+		{"git config user.name me", false},
+		{"kill -9 1234", false},
+		{"killall node", false},
+		{"nohup rm -rf build", false},
+		{"git branch", true},
+		{"git branch -a -v", true},
+		{"git branch --list 'feature/*'", true},
+		{"git branch --merged main", true},
+		{"git branch new-feature", false},
+		{"git branch -D main", false},
+		{"git branch -m old new", false},
+		{"git branch --set-upstream-to=origin/main", false},
+		{"git tag", true},
+		{"git tag -l 'v1.*'", true},
+		{"git tag --contains HEAD", true},
+		{"git tag v1.0.0", false},
+		{"git tag -d v1.0.0", false},
+		{"git tag -a v1.0.0 -m release", false},
+		{"git remote", true},
+		{"git remote -v", true},
+		{"git remote show origin", true},
+		{"git remote get-url origin", true},
+		{"git remote remove origin", false},
+		{"git remote add fork https://example.com/fork.git", false},
+		{"git remote set-url origin https://example.com/repo.git", false},
+		{"git diff --output-indicator-new=+", true},
+		{"git diff --output=patch.diff", false},
+		{"git log --output patch.diff", false},
+		{"git show --outp=patch.diff HEAD", false},
+		{"FOO=bar", false},
+		{"echo 'unterminated", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, isReadOnlyCommand(tt.command))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GrantScope is how widely a permission grant applies.
+type GrantScope string
+
+const (
+	// GrantScopeSession applies to the session the permission was granted in.
+	GrantScopeSession GrantScope = "session"
+	// GrantScopeProject applies to every session of the project.
+	GrantScopeProject GrantScope = "project"
+	// GrantScopeGlobal applies to every project, regardless of the path.
+	GrantScopeGlobal GrantScope = "global"
+)
+
+// ParseGrantScope parses a scope name.
+func ParseGrantScope(s string) (GrantScope, error) {
+	switch scope := GrantScope(s); scope {
+	case GrantScopeSession, GrantScopeProject, GrantScopeGlobal:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown scope %q, must be one of: session, project, global", s)
+	}
+}
+
+// Grant allows a tool action without asking again.
+type Grant struct {
+	ID        string
+	Scope     GrantScope
+	SessionID string
+	ToolName  string
+	Action    string
+	Path      string
+	// Command is the command of a bash grant, which only allows running that
+	// exact command again.
+	Command   string
+	CreatedAt int64
+}
+
+// GrantStore persists grants so they survive restarts.
+type GrantStore interface {
+	List(ctx context.Context) ([]Grant, error)
+	Create(ctx context.Context, grant Grant) (Grant, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// newGrant creates a grant with the given scope for the request.
+func newGrant(permission PermissionRequest, scope GrantScope) Grant {
+	grant := Grant{
+		Scope:    scope,
+		ToolName: permission.ToolName,
+		Action:   permission.Action,
+		Path:     permission.Path,
+		Command:  grantCommand(permission),
+	}
+	switch scope {
+	case GrantScopeSession:
+		grant.SessionID = permission.SessionID
+	case GrantScopeGlobal:
+		grant.Path = ""
+	}
+	return grant
+}
+
+// Matches reports whether the grant allows the request.
+func (g Grant) Matches(permission PermissionRequest) bool {
+	if g.ToolName != permission.ToolName || g.Action != permission.Action {
+		return false
+	}
+	if g.Command != grantCommand(permission) {
+		return false
+	}
+	switch g.Scope {
+	case GrantScopeSession:
+		return g.SessionID == permission.SessionID && g.Path == permission.Path
+	case GrantScopeProject:
+		return g.Path == permission.Path
+	case GrantScopeGlobal:
+		return true
+	default:
+		return false
+	}
+}
+
+// grantCommand returns the command a grant for the request is limited to.
+// Bash requests all share the same action and path, so without it a single
+// grant would allow every command.
+func grantCommand(permission PermissionRequest) string {
+	if permission.ToolName != "bash" {
+		return ""
+	}
+	return strings.TrimSpace(paramsMap(permission.Params)["command"])
+}
@@ -0,0 +1,139 @@
+package permission
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryGrantStore struct {
+	mu     sync.Mutex
+	grants []Grant
+}
+
+func (m *memoryGrantStore) List(context.Context) ([]Grant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Grant(nil), m.grants...), nil
+}
+
+func (m *memoryGrantStore) Create(_ context.Context, grant Grant) (Grant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant.CreatedAt = 1
+	m.grants = append(m.grants, grant)
+	return grant, nil
+}
+
+func (m *memoryGrantStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, g := range m.grants {
+		if g.ID == id {
+			m.grants = append(m.grants[:i], m.grants[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestGrant_Matches(t *testing.T) {
+	t.Parallel()
+
+	bash := func(sessionID, action, path, command string) PermissionRequest {
+		return PermissionRequest{
+			SessionID: sessionID,
+			ToolName:  "bash",
+			Action:    action,
+			Path:      path,
+			Params:    map[string]string{"command": command},
+		}
+	}
+	granted := bash("s1", "execute", "/project", "make")
+
+	tests := []struct {
+		name  string
+		scope GrantScope
+		req   PermissionRequest
+		want  bool
+	}{
+		{"session scope, same session", GrantScopeSession, granted, true},
+		{"session scope, other session", GrantScopeSession, bash("s2", "execute", "/project", "make"), false},
+		{"session scope, other command", GrantScopeSession, bash("s1", "execute", "/project", "rm -rf ."), false},
+		{"project scope, other session", GrantScopeProject, bash("s2", "execute", "/project", "make"), true},
+		{"project scope, other path", GrantScopeProject, bash("s1", "execute", "/other", "make"), false},
+		{"project scope, other command", GrantScopeProject, bash("s2", "execute", "/project", "make && rm -rf ."), false},
+		{"global scope, other path", GrantScopeGlobal, bash("s2", "execute", "/other", " make "), true},
+		{"global scope, other command", GrantScopeGlobal, bash("s2", "execute", "/other", "curl example.com"), false},
+		{"global scope, other action", GrantScopeGlobal, bash("s1", "write", "/project", "make"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, newGrant(granted, tt.scope).Matches(tt.req))
+		})
+	}
+}
+
+func TestParseGrantScope(t *testing.T) {
+	t.Parallel()
+
+	scope, err := ParseGrantScope("project")
+	require.NoError(t, err)
+	require.Equal(t, GrantScopeProject, scope)
+
+	_, err = ParseGrantScope("forever")
+	require.Error(t, err)
+}
+
+func TestPermissionService_PersistedGrants(t *testing.T) {
+	t.Parallel()
+
+	store := &memoryGrantStore{}
+	req := CreatePermissionRequest{
+		SessionID: "s1",
+		ToolName:  "bash",
+		Action:    "execute",
+		Path:      "/project",
+		Params:    map[string]string{"command": "make"},
+	}
+
+	service := NewPermissionService("/project", false, nil, nil, store, nil)
+	requests := service.Subscribe(t.Context())
+	result := make(chan bool, 1)
+	go func() { result <- service.Request(req) }()
+	service.GrantPersistent((<-requests).Payload, GrantScopeProject)
+	require.True(t, <-result)
+
+	grants, err := store.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, GrantScopeProject, grants[0].Scope)
+	require.Equal(t, "make", grants[0].Command)
+	require.Equal(t, service.ListGrants(), grants)
+
+	// A new service, e.g. after a restart, loads the grant from the store.
+	restarted := NewPermissionService("/project", false, nil, nil, store, nil)
+	req.SessionID = "s2"
+	require.True(t, restarted.Request(req))
+
+	// The grant only allows the command it was granted for.
+	other := req
+	other.Params = map[string]string{"command": "make clean"}
+	requests = restarted.Subscribe(t.Context())
+	go func() { result <- restarted.Request(other) }()
+	restarted.Deny((<-requests).Payload)
+	require.False(t, <-result)
+
+	// Once revoked, the request prompts again.
+	require.NoError(t, restarted.RevokeGrant(t.Context(), grants[0].ID))
+	require.Empty(t, restarted.ListGrants())
+	require.Empty(t, store.grants)
+
+	go func() { result <- restarted.Request(req) }()
+	restarted.Deny((<-requests).Payload)
+	require.False(t, <-result)
+}
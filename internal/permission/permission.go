@@ -3,6 +3,8 @@ package permission
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/vikvang/zero/internal/csync"
 	"github.com/vikvang/zero/internal/pubsub"
+	"github.com/vikvang/zero/internal/sandbox"
 	"github.com/google/uuid"
 )
 
@@ -26,6 +29,7 @@ type CreatePermissionRequest struct {
 }
 
 type PermissionNotification struct {
+	SessionID  string `json:"session_id"`
 	ToolCallID string `json:"tool_call_id"`
 	Granted    bool   `json:"granted"`
 	Denied     bool   `json:"denied"`
@@ -44,7 +48,7 @@ type PermissionRequest struct {
 
 type Service interface {
 	pubsub.Suscriber[PermissionRequest]
-	GrantPersistent(permission PermissionRequest)
+	GrantPersistent(permission PermissionRequest, scope GrantScope)
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
 	Request(opts CreatePermissionRequest) bool
@@ -52,6 +56,9 @@ type Service interface {
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
+	ListGrants() []Grant
+	RevokeGrant(ctx context.Context, id string) error
+	Sandbox() *sandbox.Sandbox
 }
 
 type permissionService struct {
@@ -59,21 +66,28 @@ type permissionService struct {
 
 	notificationBroker    *pubsub.Broker[PermissionNotification]
 	workingDir            string
-	sessionPermissions    []PermissionRequest
-	sessionPermissionsMu  sync.RWMutex
+	grants                []Grant
+	grantsMu              sync.RWMutex
+	grantStore            GrantStore
 	pendingRequests       *csync.Map[string, chan bool]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
 	allowedTools          []string
+	policy                *Policy
+	sandbox               *sandbox.Sandbox
 
 	// used to make sure we only process one request at a time
 	requestMu     sync.Mutex
 	activeRequest *PermissionRequest
 }
 
-func (s *permissionService) GrantPersistent(permission PermissionRequest) {
+// GrantPersistent grants the request and remembers the grant for the given
+// scope. Grants are saved in the grant store, if any, so they survive
+// restarts.
+func (s *permissionService) GrantPersistent(permission PermissionRequest, scope GrantScope) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  permission.SessionID,
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
@@ -82,9 +96,19 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 		respCh <- true
 	}
 
-	s.sessionPermissionsMu.Lock()
-	s.sessionPermissions = append(s.sessionPermissions, permission)
-	s.sessionPermissionsMu.Unlock()
+	grant := newGrant(permission, scope)
+	grant.ID = uuid.New().String()
+	if s.grantStore != nil {
+		saved, err := s.grantStore.Create(context.Background(), grant)
+		if err != nil {
+			slog.Error("Failed to save permission grant", "scope", scope, "error", err)
+		} else {
+			grant = saved
+		}
+	}
+	s.grantsMu.Lock()
+	s.grants = append(s.grants, grant)
+	s.grantsMu.Unlock()
 
 	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
 		s.activeRequest = nil
@@ -93,6 +117,7 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 
 func (s *permissionService) Grant(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  permission.SessionID,
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
@@ -108,6 +133,7 @@ func (s *permissionService) Grant(permission PermissionRequest) {
 
 func (s *permissionService) Deny(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  permission.SessionID,
 		ToolCallID: permission.ToolCallID,
 		Granted:    false,
 		Denied:     true,
@@ -123,20 +149,44 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 }
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
+	// Deny rules win over everything else, including skip mode.
+	decision := s.policy.Evaluate(opts, s.workingDir)
+	// Writes outside the sandbox are denied, or always asked for.
+	sandboxAsk := false
+	if decision != DecisionDeny && s.outsideSandbox(opts) {
+		decision = DecisionDeny
+		if s.sandbox.Ask() {
+			decision, sandboxAsk = DecisionAsk, true
+		}
+	}
+	if decision == DecisionDeny {
+		slog.Info("Permission denied by rule or sandbox", "tool", opts.ToolName, "action", opts.Action, "path", opts.Path)
+		s.notifyDenied(opts.SessionID, opts.ToolCallID)
+		return false
+	}
+
 	if s.skip {
 		return true
 	}
 
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  opts.SessionID,
 		ToolCallID: opts.ToolCallID,
 	})
 	s.requestMu.Lock()
 	defer s.requestMu.Unlock()
 
-	// Check if the tool/action combination is in the allowlist
+	if decision == DecisionAllow {
+		s.notifyGranted(opts.SessionID, opts.ToolCallID)
+		return true
+	}
+
+	// Check if the tool/action combination is in the allowlist, unless a
+	// rule says to always ask.
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	if decision != DecisionAsk && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
+		s.notifyGranted(opts.SessionID, opts.ToolCallID)
 		return true
 	}
 
@@ -144,7 +194,15 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	autoApprove := s.autoApproveSessions[opts.SessionID]
 	s.autoApproveSessionsMu.RUnlock()
 
+	// Auto-approved sessions have no one to ask, so ask rules don't apply,
+	// but writes outside the sandbox are denied.
 	if autoApprove {
+		if sandboxAsk {
+			slog.Info("Permission denied by sandbox in auto-approved session", "tool", opts.ToolName, "action", opts.Action, "path", opts.Path)
+			s.notifyDenied(opts.SessionID, opts.ToolCallID)
+			return false
+		}
+		s.notifyGranted(opts.SessionID, opts.ToolCallID)
 		return true
 	}
 
@@ -172,23 +230,10 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 		Params:      opts.Params,
 	}
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			return true
-		}
-	}
-	s.sessionPermissionsMu.RUnlock()
-
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			return true
-		}
+	if decision != DecisionAsk && s.hasGrant(permission) {
+		s.notifyGranted(opts.SessionID, opts.ToolCallID)
+		return true
 	}
-	s.sessionPermissionsMu.RUnlock()
 
 	s.activeRequest = &permission
 
@@ -202,6 +247,76 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	return <-respCh
 }
 
+// hasGrant reports whether the same request was already granted for the
+// session, the project or globally.
+func (s *permissionService) hasGrant(permission PermissionRequest) bool {
+	s.grantsMu.RLock()
+	defer s.grantsMu.RUnlock()
+	return slices.ContainsFunc(s.grants, func(g Grant) bool {
+		return g.Matches(permission)
+	})
+}
+
+func (s *permissionService) ListGrants() []Grant {
+	s.grantsMu.RLock()
+	defer s.grantsMu.RUnlock()
+	return slices.Clone(s.grants)
+}
+
+func (s *permissionService) RevokeGrant(ctx context.Context, id string) error {
+	if s.grantStore != nil {
+		if err := s.grantStore.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to revoke permission grant: %w", err)
+		}
+	}
+	s.grantsMu.Lock()
+	s.grants = slices.DeleteFunc(s.grants, func(g Grant) bool {
+		return g.ID == id
+	})
+	s.grantsMu.Unlock()
+	return nil
+}
+
+// sandboxedActions are the actions that write the file of the request.
+var sandboxedActions = []string{"write", "download"}
+
+// outsideSandbox reports whether the request writes a file outside the
+// sandbox.
+func (s *permissionService) outsideSandbox(opts CreatePermissionRequest) bool {
+	if opts.Path == "" || !slices.Contains(sandboxedActions, opts.Action) {
+		return false
+	}
+	path := opts.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.workingDir, path)
+	}
+	return s.sandbox.Check(path) != nil
+}
+
+func (s *permissionService) Sandbox() *sandbox.Sandbox {
+	return s.sandbox
+}
+
+// notifyGranted tells subscribers that a request was granted without asking
+// the user, e.g. because of the allowlist or a previous grant.
+func (s *permissionService) notifyGranted(sessionID, toolCallID string) {
+	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  sessionID,
+		ToolCallID: toolCallID,
+		Granted:    true,
+	})
+}
+
+// notifyDenied tells subscribers that a request was denied without asking
+// the user, e.g. because of a deny rule or the sandbox.
+func (s *permissionService) notifyDenied(sessionID, toolCallID string) {
+	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  sessionID,
+		ToolCallID: toolCallID,
+		Denied:     true,
+	})
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Lock()
 	s.autoApproveSessions[sessionID] = true
@@ -220,15 +335,31 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
-func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
+// NewPermissionService creates the permission service. If policy is nil, only
+// the built-in rules apply. If grantStore is nil, grants only last until the
+// service is gone. If sb is nil, files can be written anywhere.
+func NewPermissionService(workingDir string, skip bool, allowedTools []string, policy *Policy, grantStore GrantStore, sb *sandbox.Sandbox) Service {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	var grants []Grant
+	if grantStore != nil {
+		var err error
+		if grants, err = grantStore.List(context.Background()); err != nil {
+			slog.Error("Failed to load permission grants", "error", err)
+		}
+	}
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
 		workingDir:          workingDir,
-		sessionPermissions:  make([]PermissionRequest, 0),
+		grants:              grants,
+		grantStore:          grantStore,
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
+		policy:              policy,
+		sandbox:             sb,
 		pendingRequests:     csync.NewMap[string, chan bool](),
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vikvang/zero/internal/sandbox"
 )
 
 func TestPermissionService_AllowedCommands(t *testing.T) {
@@ -54,7 +55,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewPermissionService("/tmp", false, tt.allowedTools)
+			service := NewPermissionService("/tmp", false, tt.allowedTools, nil, nil, nil)
 
 			// Create a channel to capture the permission request
 			// Since we're testing the allowlist logic, we need to simulate the request
@@ -79,7 +80,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 }
 
 func TestPermissionService_SkipMode(t *testing.T) {
-	service := NewPermissionService("/tmp", true, []string{})
+	service := NewPermissionService("/tmp", true, []string{}, nil, nil, nil)
 
 	result := service.Request(CreatePermissionRequest{
 		SessionID:   "test-session",
@@ -96,7 +97,7 @@ func TestPermissionService_SkipMode(t *testing.T) {
 
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, nil, nil)
 
 		req1 := CreatePermissionRequest{
 			SessionID:   "session1",
@@ -122,7 +123,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		event := <-events
 
 		permissionReq = event.Payload
-		service.GrantPersistent(permissionReq)
+		service.GrantPersistent(permissionReq, GrantScopeSession)
 
 		wg.Wait()
 		assert.True(t, result1, "First request should be granted")
@@ -140,7 +141,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result2, "Second request should be auto-approved")
 	})
 	t.Run("Sequential requests with temporary grants", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, nil, nil)
 
 		req := CreatePermissionRequest{
 			SessionID:   "session2",
@@ -180,7 +181,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.False(t, result2, "Second request should be denied")
 	})
 	t.Run("Concurrent requests with different outcomes", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, nil, nil)
 
 		events := service.Subscribe(t.Context())
 
@@ -225,7 +226,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 			case "tool1":
 				service.Grant(event.Payload)
 			case "tool2":
-				service.GrantPersistent(event.Payload)
+				service.GrantPersistent(event.Payload, GrantScopeSession)
 			case "tool3":
 				service.Deny(event.Payload)
 			}
@@ -245,3 +246,105 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result, "Repeated request should be auto-approved due to persistent permission")
 	})
 }
+
+func TestPermissionService_NotifiesAutomaticGrants(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowedTools []string
+		autoApprove  bool
+	}{
+		{
+			name:         "tool in allowlist",
+			allowedTools: []string{"bash"},
+		},
+		{
+			name:        "auto-approved session",
+			autoApprove: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPermissionService("/tmp", false, tt.allowedTools, nil, nil, nil)
+			if tt.autoApprove {
+				service.AutoApproveSession("session")
+			}
+			notifications := service.SubscribeNotifications(t.Context())
+
+			granted := service.Request(CreatePermissionRequest{
+				SessionID:  "session",
+				ToolCallID: "call",
+				ToolName:   "bash",
+				Action:     "execute",
+				Path:       "/tmp",
+			})
+			assert.True(t, granted)
+
+			requested := <-notifications
+			assert.Equal(t, PermissionNotification{SessionID: "session", ToolCallID: "call"}, requested.Payload)
+			grant := <-notifications
+			assert.Equal(t, PermissionNotification{SessionID: "session", ToolCallID: "call", Granted: true}, grant.Payload)
+		})
+	}
+}
+
+func TestPermissionService_Sandbox(t *testing.T) {
+	t.Parallel()
+
+	project := t.TempDir()
+	outside := CreatePermissionRequest{
+		SessionID: "session",
+		ToolName:  "write",
+		Action:    "write",
+		Path:      "/etc/hosts",
+	}
+
+	t.Run("inside the sandbox", func(t *testing.T) {
+		t.Parallel()
+		sb := sandbox.New(sandbox.Options{Roots: []string{project}})
+		service := NewPermissionService(project, false, []string{"write"}, nil, nil, sb)
+		assert.True(t, service.Request(CreatePermissionRequest{
+			SessionID: "session",
+			ToolName:  "write",
+			Action:    "write",
+			Path:      project,
+		}))
+	})
+
+	t.Run("deny wins over skip mode", func(t *testing.T) {
+		t.Parallel()
+		sb := sandbox.New(sandbox.Options{Roots: []string{project}})
+		service := NewPermissionService(project, true, nil, nil, nil, sb)
+		assert.False(t, service.Request(outside))
+	})
+
+	t.Run("ask ignores the allowlist", func(t *testing.T) {
+		t.Parallel()
+		sb := sandbox.New(sandbox.Options{Roots: []string{project}, Ask: true})
+		service := NewPermissionService(project, false, []string{"write"}, nil, nil, sb)
+		requests := service.Subscribe(t.Context())
+
+		result := make(chan bool, 1)
+		go func() {
+			result <- service.Request(outside)
+		}()
+
+		req := <-requests
+		service.Grant(req.Payload)
+		assert.True(t, <-result)
+	})
+
+	t.Run("ask is denied in auto-approved sessions", func(t *testing.T) {
+		t.Parallel()
+		sb := sandbox.New(sandbox.Options{Roots: []string{project}, Ask: true})
+		service := NewPermissionService(project, false, nil, nil, nil, sb)
+		service.AutoApproveSession("session")
+		assert.False(t, service.Request(outside))
+		assert.True(t, service.Request(CreatePermissionRequest{
+			SessionID: "session",
+			ToolName:  "write",
+			Action:    "write",
+			Path:      project,
+		}))
+	})
+}
@@ -0,0 +1,322 @@
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Decision is the outcome of evaluating the permission rules for a request.
+type Decision string
+
+const (
+	// DecisionNone means no rule matched the request.
+	DecisionNone Decision = ""
+	// DecisionAllow grants the request without asking.
+	DecisionAllow Decision = "allow"
+	// DecisionDeny rejects the request without asking.
+	DecisionDeny Decision = "deny"
+	// DecisionAsk always asks the user, ignoring the allowlist and previous
+	// grants.
+	DecisionAsk Decision = "ask"
+)
+
+// regexPrefix marks a pattern as a regular expression instead of a glob.
+const regexPrefix = "re:"
+
+// Rule matches permission requests and decides what to do with them. Empty
+// patterns match anything. Patterns are globs unless they start with "re:",
+// in which case the rest is a regular expression.
+type Rule struct {
+	Decision Decision
+	// Tool matches the tool name, e.g. "bash" or "mcp_*".
+	Tool string
+	// Action matches the action of the tool, e.g. "write" or "execute".
+	Action string
+	// Path matches the file or directory the request is about. Relative
+	// patterns are matched against the path relative to the working
+	// directory, and "**" matches any number of directories.
+	Path string
+	// Command matches bash commands. "*" matches any character, including
+	// spaces and slashes.
+	Command string
+	// Host matches the host of the URL of fetch and download requests.
+	Host string
+}
+
+type matcher func(string) bool
+
+type compiledRule struct {
+	Rule
+	tool, action, path, command, host matcher
+}
+
+// Policy is an ordered list of permission rules.
+type Policy struct {
+	rules []compiledRule
+}
+
+// NewPolicy compiles the given rules.
+func NewPolicy(rules []Rule) (*Policy, error) {
+	p := &Policy{}
+	for i, rule := range rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid permission rule %d: %w", i+1, err)
+		}
+		p.rules = append(p.rules, compiled)
+	}
+	return p, nil
+}
+
+// DefaultPolicy returns a policy without rules.
+func DefaultPolicy() *Policy {
+	p, err := NewPolicy(nil)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Evaluate returns the decision for the request. Rules are evaluated in
+// order and the first match wins, except that a matching deny rule always
+// wins. Bash commands that only run safe commands are allowed when no rule
+// matches.
+func (p *Policy) Evaluate(req CreatePermissionRequest, workingDir string) Decision {
+	subject := newRuleSubject(req, workingDir)
+	decision := DecisionNone
+	for _, rule := range p.rules {
+		if !rule.matches(subject) {
+			continue
+		}
+		if rule.Decision == DecisionDeny {
+			return DecisionDeny
+		}
+		if decision == DecisionNone {
+			decision = rule.Decision
+		}
+	}
+	if decision == DecisionNone && req.ToolName == "bash" && isReadOnlyCommand(subject.command) {
+		return DecisionAllow
+	}
+	return decision
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	switch rule.Decision {
+	case DecisionAllow, DecisionDeny, DecisionAsk:
+	default:
+		return compiledRule{}, fmt.Errorf("unknown decision %q, must be one of: allow, deny, ask", rule.Decision)
+	}
+
+	c := compiledRule{Rule: rule}
+	var err error
+	if c.tool, err = compileMatcher(rule.Tool, globMatcher); err != nil {
+		return compiledRule{}, fmt.Errorf("tool: %w", err)
+	}
+	if c.action, err = compileMatcher(rule.Action, globMatcher); err != nil {
+		return compiledRule{}, fmt.Errorf("action: %w", err)
+	}
+	if c.path, err = compileMatcher(rule.Path, pathMatcher); err != nil {
+		return compiledRule{}, fmt.Errorf("path: %w", err)
+	}
+	if c.command, err = compileMatcher(rule.Command, commandMatcher); err != nil {
+		return compiledRule{}, fmt.Errorf("command: %w", err)
+	}
+	if c.host, err = compileMatcher(rule.Host, globMatcher); err != nil {
+		return compiledRule{}, fmt.Errorf("host: %w", err)
+	}
+	return c, nil
+}
+
+func compileMatcher(pattern string, glob func(string) (matcher, error)) (matcher, error) {
+	switch {
+	case pattern == "":
+		return nil, nil
+	case strings.HasPrefix(pattern, regexPrefix):
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, regexPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	default:
+		return glob(pattern)
+	}
+}
+
+func globMatcher(pattern string) (matcher, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return func(s string) bool {
+		ok, _ := path.Match(pattern, s)
+		return ok
+	}, nil
+}
+
+func pathMatcher(pattern string) (matcher, error) {
+	pattern = filepath.ToSlash(pattern)
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("invalid glob %q", pattern)
+	}
+	return func(s string) bool {
+		return doublestar.MatchUnvalidated(pattern, s)
+	}, nil
+}
+
+// commandMatcher turns a glob into a regular expression where "*" matches
+// anything and "?" matches a single character.
+func commandMatcher(pattern string) (matcher, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// ruleSubject holds the values of a request that rules are matched against.
+type ruleSubject struct {
+	tool    string
+	action  string
+	paths   []string
+	command string
+	host    string
+}
+
+func newRuleSubject(req CreatePermissionRequest, workingDir string) ruleSubject {
+	params := paramsMap(req.Params)
+	subject := ruleSubject{
+		tool:    req.ToolName,
+		action:  req.Action,
+		command: strings.TrimSpace(params["command"]),
+	}
+	if u, err := url.Parse(params["url"]); err == nil {
+		subject.host = u.Hostname()
+	}
+
+	target := req.Path
+	for _, key := range []string{"file_path", "path"} {
+		if params[key] != "" {
+			target = params[key]
+			break
+		}
+	}
+	if target != "" {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(workingDir, target)
+		}
+		target = filepath.Clean(target)
+		subject.paths = append(subject.paths, filepath.ToSlash(target))
+		if rel, err := filepath.Rel(workingDir, target); err == nil && !strings.HasPrefix(rel, "..") {
+			subject.paths = append(subject.paths, filepath.ToSlash(rel))
+		}
+	}
+	return subject
+}
+
+func (r compiledRule) matches(s ruleSubject) bool {
+	if r.tool != nil && !r.tool(s.tool) {
+		return false
+	}
+	if r.action != nil && !r.action(s.action) {
+		return false
+	}
+	if r.host != nil && (s.host == "" || !r.host(s.host)) {
+		return false
+	}
+	if r.path != nil && !anyMatch(r.path, s.paths) {
+		return false
+	}
+	if r.command != nil && !r.matchesCommand(s.command) {
+		return false
+	}
+	return true
+}
+
+// matchesCommand matches the command of a bash request. Allow rules only
+// match simple commands, so that allowing e.g. "go test *" doesn't allow
+// "go test ./... && rm -rf ~". Deny and ask rules match if any of the
+// chained commands matches.
+func (r compiledRule) matchesCommand(command string) bool {
+	if command == "" {
+		return false
+	}
+	if r.Decision == DecisionAllow {
+		return isSimpleCommand(command) && r.command(command)
+	}
+	return r.command(command) || anyMatch(r.command, splitCommands(command))
+}
+
+func anyMatch(m matcher, values []string) bool {
+	for _, v := range values {
+		if m(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellOperators are the characters that can chain commands, substitute
+// their output or redirect it.
+const shellOperators = ";&|<>`$(){}\n"
+
+func isSimpleCommand(command string) bool {
+	return !strings.ContainsAny(command, shellOperators)
+}
+
+// splitCommands splits a command line on the operators that chain commands.
+func splitCommands(command string) []string {
+	fields := strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune(";&|\n`()", r)
+	})
+	commands := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimLeft(strings.TrimSpace(f), "$")
+		if f != "" {
+			commands = append(commands, f)
+		}
+	}
+	return commands
+}
+
+// paramsMap returns the string values of the request params, which are
+// usually one of the tools' permission params structs.
+func paramsMap(params any) map[string]string {
+	if params == nil {
+		return nil
+	}
+	bts, err := json.Marshal(params)
+	if err != nil {
+		return nil
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(bts, &raw); err != nil {
+		return nil
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			values[k] = s
+		}
+	}
+	return values
+}
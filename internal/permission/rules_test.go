@@ -0,0 +1,196 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testBashParams struct {
+	Command string `json:"command"`
+}
+
+type testFileParams struct {
+	FilePath string `json:"file_path"`
+}
+
+type testURLParams struct {
+	URL string `json:"url"`
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	rules := []Rule{
+		{Decision: DecisionAllow, Tool: "bash", Command: "go test *"},
+		{Decision: DecisionDeny, Tool: "bash", Command: "rm -rf *"},
+		{Decision: DecisionDeny, Tool: "edit", Path: "vendor/**"},
+		{Decision: DecisionAllow, Tool: "edit", Path: "**/*.go"},
+		{Decision: DecisionAllow, Tool: "fetch", Host: "*.github.com"},
+		{Decision: DecisionAsk, Tool: "fetch"},
+		{Decision: DecisionAllow, Tool: "re:^mcp_docs_", Action: "execute"},
+	}
+	policy, err := NewPolicy(rules)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		req  CreatePermissionRequest
+		want Decision
+	}{
+		{
+			name: "allowed command",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "go test ./..."}},
+			want: DecisionAllow,
+		},
+		{
+			name: "allow rules don't match chained commands",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "go test ./... && curl example.com"}},
+			want: DecisionNone,
+		},
+		{
+			name: "deny rules match chained commands",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "ls; rm -rf /"}},
+			want: DecisionDeny,
+		},
+		{
+			name: "built-in safe command",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "git status --short"}},
+			want: DecisionAllow,
+		},
+		{
+			name: "built-in safe command doesn't match longer names",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "lsblk"}},
+			want: DecisionNone,
+		},
+		{
+			name: "built-in safe command with redirection",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "echo hi > main.go"}},
+			want: DecisionNone,
+		},
+		{
+			name: "chained built-in safe commands",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "git status && git diff | git shortlog"}},
+			want: DecisionAllow,
+		},
+		{
+			name: "built-in safe command chained with another command",
+			req:  CreatePermissionRequest{ToolName: "bash", Params: testBashParams{Command: "ls && curl -o main.go example.com"}},
+			want: DecisionNone,
+		},
+		{
+			name: "deny wins over a later allow",
+			req:  CreatePermissionRequest{ToolName: "edit", Params: testFileParams{FilePath: "/project/vendor/lib/lib.go"}},
+			want: DecisionDeny,
+		},
+		{
+			name: "relative path pattern",
+			req:  CreatePermissionRequest{ToolName: "edit", Params: testFileParams{FilePath: "internal/app/app.go"}},
+			want: DecisionAllow,
+		},
+		{
+			name: "path outside the working directory",
+			req:  CreatePermissionRequest{ToolName: "edit", Params: testFileParams{FilePath: "/etc/passwd"}},
+			want: DecisionNone,
+		},
+		{
+			name: "allowed host",
+			req:  CreatePermissionRequest{ToolName: "fetch", Params: testURLParams{URL: "https://api.github.com/repos"}},
+			want: DecisionAllow,
+		},
+		{
+			name: "other hosts always ask",
+			req:  CreatePermissionRequest{ToolName: "fetch", Params: testURLParams{URL: "https://example.com"}},
+			want: DecisionAsk,
+		},
+		{
+			name: "regex tool name",
+			req:  CreatePermissionRequest{ToolName: "mcp_docs_search", Action: "execute"},
+			want: DecisionAllow,
+		},
+		{
+			name: "no matching rule",
+			req:  CreatePermissionRequest{ToolName: "write", Params: testFileParams{FilePath: "README.md"}},
+			want: DecisionNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, policy.Evaluate(tt.req, "/project"))
+		})
+	}
+}
+
+func TestNewPolicy_InvalidRules(t *testing.T) {
+	t.Parallel()
+
+	for name, rule := range map[string]Rule{
+		"unknown decision": {Decision: "maybe", Tool: "bash"},
+		"invalid regex":    {Decision: DecisionDeny, Command: "re:("},
+		"invalid glob":     {Decision: DecisionDeny, Tool: "[bash"},
+		"invalid path":     {Decision: DecisionDeny, Path: "vendor/[**"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewPolicy([]Rule{rule})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestPermissionService_Rules(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy([]Rule{
+		{Decision: DecisionDeny, Tool: "edit", Path: "vendor/**"},
+		{Decision: DecisionAsk, Tool: "bash"},
+	})
+	require.NoError(t, err)
+
+	t.Run("deny wins over skip mode", func(t *testing.T) {
+		t.Parallel()
+		service := NewPermissionService("/project", true, nil, policy, nil, nil)
+		require.False(t, service.Request(CreatePermissionRequest{
+			SessionID: "session",
+			ToolName:  "edit",
+			Action:    "write",
+			Path:      "/project",
+			Params:    testFileParams{FilePath: "/project/vendor/x.go"},
+		}))
+	})
+
+	t.Run("ask ignores the allowlist", func(t *testing.T) {
+		t.Parallel()
+		service := NewPermissionService("/project", false, []string{"bash"}, policy, nil, nil)
+		requests := service.Subscribe(t.Context())
+
+		result := make(chan bool, 1)
+		go func() {
+			result <- service.Request(CreatePermissionRequest{
+				SessionID: "session",
+				ToolName:  "bash",
+				Action:    "execute",
+				Path:      "/project",
+				Params:    testBashParams{Command: "make"},
+			})
+		}()
+
+		req := <-requests
+		service.Deny(req.Payload)
+		require.False(t, <-result)
+	})
+
+	t.Run("built-in safe commands don't ask", func(t *testing.T) {
+		t.Parallel()
+		service := NewPermissionService("/project", false, nil, nil, nil, nil)
+		require.True(t, service.Request(CreatePermissionRequest{
+			SessionID: "session",
+			ToolName:  "bash",
+			Action:    "execute",
+			Path:      "/project",
+			Params:    testBashParams{Command: "git log -n 5"},
+		}))
+	})
+}
@@ -0,0 +1,148 @@
+package permission
+
+import (
+	"runtime"
+	"strings"
+)
+
+// safeCommands are read-only commands that bash can run without asking, as
+// long as every command of the command line is safe. See [Command.ReadOnly].
+var safeCommands = []string{
+	// Bash builtins and core utils
+	"cal",
+	"date",
+	"df",
+	"du",
+	"echo",
+	"env",
+	"free",
+	"groups",
+	"hostname",
+	"id",
+	"ls",
+	"nice",
+	"printenv",
+	"ps",
+	"pwd",
+	"set",
+	"time",
+	"timeout",
+	"top",
+	"type",
+	"uname",
+	"unset",
+	"uptime",
+	"whatis",
+	"whereis",
+	"which",
+	"whoami",
+
+	// Git
+	"git blame",
+	"git branch",
+	"git config --get",
+	"git config --list",
+	"git describe",
+	"git diff",
+	"git grep",
+	"git log",
+	"git ls-files",
+	"git ls-remote",
+	"git remote",
+	"git rev-parse",
+	"git shortlog",
+	"git show",
+	"git status",
+	"git tag",
+}
+
+// safeArguments checks the arguments of safe commands that only read with
+// some of their arguments, e.g. "git branch" lists the branches, but "git
+// branch -D main" deletes one. The arguments don't include the command.
+var safeArguments = map[string]func(args []string) bool{
+	"git branch": listArguments(map[string]bool{
+		"-a": true, "--all": true,
+		"-r": true, "--remotes": true,
+		"-l": true, "--list": true,
+		"-v": true, "-vv": true, "--verbose": true,
+		"--contains": true, "--no-contains": true,
+		"--merged": true, "--no-merged": true,
+		"--points-at": true, "--show-current": false,
+		"--sort": false, "--format": false,
+		"--color": false, "--no-color": false,
+		"--column": false, "--no-column": false,
+		"-i": false, "--ignore-case": false,
+	}),
+	"git tag": listArguments(map[string]bool{
+		"-l": true, "--list": true,
+		"--contains": true, "--no-contains": true,
+		"--merged": true, "--no-merged": true,
+		"--points-at": true, "--sort": false, "--format": false,
+		"--color": false, "--no-color": false,
+		"--column": false, "--no-column": false,
+		"-i": false, "--ignore-case": false,
+	}),
+	"git remote": gitRemoteArguments,
+	"git diff":   noOutputOption,
+	"git log":    noOutputOption,
+	"git show":   noOutputOption,
+}
+
+// listArguments returns a check for commands that create what they list
+// when given operands, like "git branch" or "git tag". Only the given
+// options are allowed, which map to whether they switch to listing, so that
+// the operands are patterns instead of names to create.
+func listArguments(options map[string]bool) func(args []string) bool {
+	return func(args []string) bool {
+		list, operands := false, false
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") || arg == "-" {
+				operands = true
+				continue
+			}
+			option, _, _ := strings.Cut(arg, "=")
+			lists, ok := options[option]
+			if !ok {
+				return false
+			}
+			list = list || lists
+		}
+		return !operands || list
+	}
+}
+
+// gitRemoteArguments allows listing the remotes and showing one of them.
+func gitRemoteArguments(args []string) bool {
+	for len(args) > 0 && (args[0] == "-v" || args[0] == "--verbose") {
+		args = args[1:]
+	}
+	return len(args) == 0 || args[0] == "show" || args[0] == "get-url"
+}
+
+// noOutputOption rejects the --output option of git, which writes to a file
+// instead of the standard output. Git accepts unambiguous prefixes of long
+// options, so those are rejected too.
+func noOutputOption(args []string) bool {
+	for _, arg := range args {
+		option, _, _ := strings.Cut(arg, "=")
+		if len(option) > len("--o") && strings.HasPrefix("--output", option) {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	if runtime.GOOS == "windows" {
+		safeCommands = append(
+			safeCommands,
+			// Windows-specific commands
+			"ipconfig",
+			"nslookup",
+			"ping",
+			"systeminfo",
+			"tasklist",
+			"where",
+		)
+	}
+}
@@ -0,0 +1,125 @@
+// Package sandbox limits the files tools and shell commands can write to a
+// set of writable roots.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// deniedProxy is the proxy shell commands use when network access is denied.
+// Nothing listens on the discard port, so connections through it fail.
+const deniedProxy = "http://127.0.0.1:9"
+
+// Options configures a sandbox.
+type Options struct {
+	// Roots are the directories that can be written, including their
+	// subdirectories.
+	Roots []string
+	// Ask asks the user for writes outside the roots instead of denying
+	// them.
+	Ask bool
+	// DenyNetwork points the proxy environment variables of shell commands
+	// to a proxy that refuses all connections.
+	DenyNetwork bool
+}
+
+// Sandbox decides which files can be written. A nil sandbox allows writing
+// anywhere.
+type Sandbox struct {
+	roots       []string
+	ask         bool
+	denyNetwork bool
+}
+
+// OutsideError is returned for writes outside the writable roots.
+type OutsideError struct {
+	Path  string
+	Roots []string
+}
+
+func (e *OutsideError) Error() string {
+	return fmt.Sprintf("writing %s is not allowed: it is outside the sandbox, which only allows writing in %s",
+		e.Path, strings.Join(e.Roots, ", "))
+}
+
+// IsOutside reports whether the error is about a write outside the sandbox.
+func IsOutside(err error) bool {
+	var outside *OutsideError
+	return errors.As(err, &outside)
+}
+
+// New creates a sandbox. The roots must be absolute.
+func New(opts Options) *Sandbox {
+	s := &Sandbox{
+		ask:         opts.Ask,
+		denyNetwork: opts.DenyNetwork,
+	}
+	for _, root := range opts.Roots {
+		s.roots = append(s.roots, resolve(root))
+	}
+	return s
+}
+
+// Check returns an [OutsideError] if the file at the absolute path can't be
+// written because it is outside the writable roots.
+func (s *Sandbox) Check(path string) error {
+	if s == nil || path == os.DevNull {
+		return nil
+	}
+	resolved := resolve(path)
+	sep := string(filepath.Separator)
+	for _, root := range s.roots {
+		if resolved == root || strings.HasPrefix(resolved, strings.TrimSuffix(root, sep)+sep) {
+			return nil
+		}
+	}
+	return &OutsideError{Path: path, Roots: s.Roots()}
+}
+
+// Ask reports whether writes outside the sandbox are asked for instead of
+// denied.
+func (s *Sandbox) Ask() bool {
+	return s != nil && s.ask
+}
+
+// Roots returns the writable roots.
+func (s *Sandbox) Roots() []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s.roots...)
+}
+
+// Env returns the environment variables shell commands run with, in the
+// KEY=value form.
+func (s *Sandbox) Env() []string {
+	if s == nil || !s.denyNetwork {
+		return nil
+	}
+	var env []string
+	for _, key := range []string{"http_proxy", "https_proxy", "ftp_proxy", "all_proxy"} {
+		env = append(env, key+"="+deniedProxy, strings.ToUpper(key)+"="+deniedProxy)
+	}
+	return append(env, "no_proxy=", "NO_PROXY=")
+}
+
+// resolve cleans the path and resolves the symlinks of the part of it that
+// exists, so that links can't be used to write outside the roots.
+func resolve(path string) string {
+	path = filepath.Clean(path)
+	var rest []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
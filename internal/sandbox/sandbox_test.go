@@ -0,0 +1,54 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox_Check(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	other := t.TempDir()
+	require.NoError(t, os.Symlink(other, filepath.Join(root, "link")))
+	sb := New(Options{Roots: []string{root}})
+
+	require.NoError(t, sb.Check(root))
+	require.NoError(t, sb.Check(filepath.Join(root, "main.go")))
+	require.NoError(t, sb.Check(filepath.Join(root, "new", "dir", "main.go")), "files that don't exist yet")
+	require.NoError(t, sb.Check(os.DevNull))
+
+	for _, path := range []string{
+		filepath.Join(other, "main.go"),
+		filepath.Join(root, "..", filepath.Base(other), "main.go"),
+		filepath.Join(root, "link", "main.go"),
+		root + "-other",
+	} {
+		err := sb.Check(path)
+		require.Error(t, err, path)
+		require.True(t, IsOutside(err))
+		require.Contains(t, err.Error(), path)
+	}
+}
+
+func TestSandbox_Nil(t *testing.T) {
+	t.Parallel()
+
+	var sb *Sandbox
+	require.NoError(t, sb.Check("/etc/hosts"))
+	require.False(t, sb.Ask())
+	require.Empty(t, sb.Env())
+}
+
+func TestSandbox_Env(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, New(Options{}).Env())
+	env := New(Options{DenyNetwork: true}).Env()
+	require.Contains(t, env, "HTTPS_PROXY="+deniedProxy)
+	require.Contains(t, env, "https_proxy="+deniedProxy)
+	require.Contains(t, env, "NO_PROXY=")
+}
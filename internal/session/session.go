@@ -29,7 +29,9 @@ type Service interface {
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
+	ListChildren(ctx context.Context, parentSessionID string) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
+	AddCost(ctx context.Context, id string, cost float64) (Session, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -79,11 +81,22 @@ func (s *service) CreateTitleSession(ctx context.Context, parentSessionID string
 	return session, nil
 }
 
+// Delete deletes the session along with its child sessions, e.g. the ones of
+// sub-agents and title generation.
 func (s *service) Delete(ctx context.Context, id string) error {
 	session, err := s.Get(ctx, id)
 	if err != nil {
 		return err
 	}
+	children, err := s.ListChildren(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := s.Delete(ctx, child.ID); err != nil {
+			return err
+		}
+	}
 	err = s.q.DeleteSession(ctx, session.ID)
 	if err != nil {
 		return err
@@ -100,6 +113,9 @@ func (s *service) Get(ctx context.Context, id string) (Session, error) {
 	return s.fromDBItem(dbSession), nil
 }
 
+// Save saves the title, token counts and summary of the session. The cost is
+// only changed by AddCost, so that concurrent sub-agents don't overwrite each
+// other's cost.
 func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	dbSession, err := s.q.UpdateSession(ctx, db.UpdateSessionParams{
 		ID:               session.ID,
@@ -110,7 +126,6 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
-		Cost: session.Cost,
 	})
 	if err != nil {
 		return Session{}, err
@@ -120,6 +135,20 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	return session, nil
 }
 
+// AddCost adds to the cost of the session in a single update.
+func (s *service) AddCost(ctx context.Context, id string, cost float64) (Session, error) {
+	dbSession, err := s.q.AddSessionCost(ctx, db.AddSessionCostParams{
+		ID:   id,
+		Cost: cost,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
@@ -132,6 +161,20 @@ func (s *service) List(ctx context.Context) ([]Session, error) {
 	return sessions, nil
 }
 
+// ListChildren returns the sessions created by the given session, oldest
+// first.
+func (s *service) ListChildren(ctx context.Context, parentSessionID string) ([]Session, error) {
+	dbSessions, err := s.q.ListChildSessions(ctx, sql.NullString{String: parentSessionID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = s.fromDBItem(dbSession)
+	}
+	return sessions, nil
+}
+
 func (s service) fromDBItem(item db.Session) Session {
 	return Session{
 		ID:               item.ID,
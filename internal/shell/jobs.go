@@ -0,0 +1,280 @@
+package shell
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vikvang/zero/internal/csync"
+	"github.com/vikvang/zero/internal/pubsub"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// JobStatus is the status of a background job.
+type JobStatus string
+
+const (
+	JobRunning JobStatus = "running"
+	JobExited  JobStatus = "exited"
+	JobKilled  JobStatus = "killed"
+)
+
+const (
+	// maxJobOutput is how much unread output is kept for each stream of a
+	// job, older output is dropped.
+	maxJobOutput = 1024 * 1024
+	// jobKillTimeout is how long to wait for a killed job to exit.
+	jobKillTimeout = 5 * time.Second
+	// maxFinishedJobs is how many finished jobs are kept until they are
+	// removed, older ones are removed first.
+	maxFinishedJobs = 50
+)
+
+// Job is a command running in the background, started with
+// [Shell.StartJob].
+type Job struct {
+	ID        string
+	SessionID string
+	Command   string
+	StartedAt time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	stdout jobOutput
+	stderr jobOutput
+
+	mu       sync.Mutex
+	status   JobStatus
+	exitCode int
+}
+
+// JobInfo is a snapshot of a job, published when jobs start and finish.
+type JobInfo struct {
+	ID        string
+	SessionID string
+	Command   string
+	StartedAt time.Time
+	Status    JobStatus
+	ExitCode  int
+}
+
+var (
+	jobs      = csync.NewMap[string, *Job]()
+	jobBroker = pubsub.NewBroker[JobInfo]()
+	lastJobID atomic.Int64
+)
+
+// StartJob runs the command in the background for the session. The job
+// starts in the working directory and environment of the shell, but
+// doesn't change them.
+func (s *Shell) StartJob(sessionID, command string) (*Job, error) {
+	if _, err := syntax.NewParser().Parse(strings.NewReader(command), ""); err != nil {
+		return nil, fmt.Errorf("could not parse command: %w", err)
+	}
+
+	s.mu.Lock()
+	jobShell := &Shell{
+		cwd:        s.cwd,
+		env:        slices.Clone(s.env),
+		logger:     s.logger,
+		blockFuncs: s.blockFuncs,
+		sandbox:    s.sandbox,
+		askWrite:   s.askWrite,
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        strconv.FormatInt(lastJobID.Add(1), 10),
+		SessionID: sessionID,
+		Command:   command,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		status:    JobRunning,
+	}
+	jobs.Set(job.ID, job)
+	slog.Info("Started background job", "id", job.ID, "session_id", sessionID, "command", command)
+	jobBroker.Publish(pubsub.CreatedEvent, job.Info())
+
+	go job.run(ctx, jobShell)
+	return job, nil
+}
+
+func (j *Job) run(ctx context.Context, jobShell *Shell) {
+	defer j.cancel()
+	err := jobShell.execPOSIXTo(ctx, j.Command, &j.stdout, &j.stderr)
+
+	j.mu.Lock()
+	if j.status == JobRunning {
+		j.status = JobExited
+	}
+	j.exitCode = ExitCode(err)
+	j.mu.Unlock()
+	close(j.done)
+
+	slog.Info("Background job finished", "id", j.ID, "session_id", j.SessionID, "err", err)
+	jobBroker.Publish(pubsub.UpdatedEvent, j.Info())
+	pruneFinishedJobs(maxFinishedJobs)
+}
+
+// Info returns a snapshot of the job.
+func (j *Job) Info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return JobInfo{
+		ID:        j.ID,
+		SessionID: j.SessionID,
+		Command:   j.Command,
+		StartedAt: j.StartedAt,
+		Status:    j.status,
+		ExitCode:  j.exitCode,
+	}
+}
+
+// Finished reports whether the job has finished, in which case all its
+// output has been written.
+func (j *Job) Finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait waits for the job to finish, and reports whether it did before the
+// context is done.
+func (j *Job) Wait(ctx context.Context) bool {
+	select {
+	case <-j.done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ReadOutput returns the output of the job since the last read, and how
+// many bytes of it were dropped because it wasn't read in time.
+func (j *Job) ReadOutput() (stdout, stderr string, dropped int) {
+	stdout, droppedStdout := j.stdout.read()
+	stderr, droppedStderr := j.stderr.read()
+	return stdout, stderr, droppedStdout + droppedStderr
+}
+
+// Kill stops the job and waits for it to exit.
+func (j *Job) Kill() {
+	j.mu.Lock()
+	if j.status == JobRunning {
+		j.status = JobKilled
+	}
+	j.mu.Unlock()
+	j.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobKillTimeout)
+	defer cancel()
+	if !j.Wait(ctx) {
+		slog.Warn("Background job didn't exit after being killed", "id", j.ID, "session_id", j.SessionID)
+	}
+}
+
+// GetJob returns the job of the session with the given ID.
+func GetJob(sessionID, id string) (*Job, bool) {
+	job, ok := jobs.Get(id)
+	if !ok || job.SessionID != sessionID {
+		return nil, false
+	}
+	return job, true
+}
+
+// ListJobs returns the jobs of the session, or all jobs if the session ID is
+// empty, oldest first.
+func ListJobs(sessionID string) []*Job {
+	var list []*Job
+	for job := range jobs.Seq() {
+		if sessionID == "" || job.SessionID == sessionID {
+			list = append(list, job)
+		}
+	}
+	slices.SortFunc(list, func(a, b *Job) int {
+		return cmp.Or(a.StartedAt.Compare(b.StartedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return list
+}
+
+// RemoveJob removes the job, e.g. once it finished and its output was
+// reported. Running jobs are not removed.
+func RemoveJob(id string) {
+	if job, ok := jobs.Get(id); ok && job.Finished() {
+		jobs.Del(id)
+	}
+}
+
+// pruneFinishedJobs removes the oldest finished jobs once there are more
+// than limit of them, so that the jobs whose output is never reported don't
+// pile up.
+func pruneFinishedJobs(limit int) {
+	var finished []*Job
+	for _, job := range ListJobs("") {
+		if job.Finished() {
+			finished = append(finished, job)
+		}
+	}
+	for _, job := range finished[:max(len(finished)-limit, 0)] {
+		jobs.Del(job.ID)
+	}
+}
+
+// KillJobs kills all the running jobs.
+func KillJobs() {
+	var wg sync.WaitGroup
+	for job := range jobs.Seq() {
+		if job.Info().Status != JobRunning {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.Kill()
+		}()
+	}
+	wg.Wait()
+}
+
+// SubscribeJobEvents returns a channel for the events of background jobs.
+func SubscribeJobEvents(ctx context.Context) <-chan pubsub.Event[JobInfo] {
+	return jobBroker.Subscribe(ctx)
+}
+
+// jobOutput buffers an output stream of a job until it is read.
+type jobOutput struct {
+	mu      sync.Mutex
+	buf     []byte
+	dropped int
+}
+
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf = append(o.buf, p...)
+	if over := len(o.buf) - maxJobOutput; over > 0 {
+		o.buf = append(o.buf[:0], o.buf[over:]...)
+		o.dropped += over
+	}
+	return len(p), nil
+}
+
+func (o *jobOutput) read() (string, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out, dropped := string(o.buf), o.dropped
+	o.buf, o.dropped = nil, 0
+	return out, dropped
+}
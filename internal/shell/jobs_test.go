@@ -0,0 +1,109 @@
+package shell
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartJob(t *testing.T) {
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+	_, _, err := sh.Exec(t.Context(), "export GREETING=hello")
+	require.NoError(t, err)
+
+	job, err := sh.StartJob("session", "echo $GREETING; echo oops >&2; cd /; exit 3")
+	require.NoError(t, err)
+	require.True(t, job.Wait(t.Context()))
+
+	stdout, stderr, dropped := job.ReadOutput()
+	require.Equal(t, "hello\n", stdout, "the job starts from the environment of the shell")
+	require.Equal(t, "oops\n", stderr)
+	require.Zero(t, dropped)
+	stdout, stderr, _ = job.ReadOutput()
+	require.Empty(t, stdout+stderr, "output is only returned once")
+
+	info := job.Info()
+	require.Equal(t, JobExited, info.Status)
+	require.Equal(t, 3, info.ExitCode)
+	require.NotEqual(t, "/", sh.GetWorkingDir(), "the job doesn't change the shell")
+
+	got, ok := GetJob("session", job.ID)
+	require.True(t, ok)
+	require.Same(t, job, got)
+	_, ok = GetJob("other", job.ID)
+	require.False(t, ok, "jobs are only visible to their session")
+	require.Contains(t, ListJobs("session"), job)
+	require.NotContains(t, ListJobs("other"), job)
+}
+
+func TestJobKill(t *testing.T) {
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+	job, err := sh.StartJob("session", "echo started; sleep 10")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	require.False(t, job.Wait(ctx))
+
+	job.Kill()
+	require.Equal(t, JobKilled, job.Info().Status)
+	stdout, _, _ := job.ReadOutput()
+	require.Equal(t, "started\n", stdout)
+}
+
+func TestRemoveJob(t *testing.T) {
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+	running, err := sh.StartJob("session", "sleep 10")
+	require.NoError(t, err)
+	defer running.Kill()
+	finished, err := sh.StartJob("session", "true")
+	require.NoError(t, err)
+	require.True(t, finished.Wait(t.Context()))
+
+	RemoveJob(running.ID)
+	RemoveJob(finished.ID)
+	_, ok := GetJob("session", running.ID)
+	require.True(t, ok, "running jobs are kept")
+	_, ok = GetJob("session", finished.ID)
+	require.False(t, ok)
+}
+
+func TestPruneFinishedJobs(t *testing.T) {
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+	var started []*Job
+	for range 3 {
+		job, err := sh.StartJob("session", "true")
+		require.NoError(t, err)
+		require.True(t, job.Wait(t.Context()))
+		started = append(started, job)
+	}
+
+	pruneFinishedJobs(1)
+	for _, job := range started[:2] {
+		_, ok := GetJob("session", job.ID)
+		require.False(t, ok, "the oldest finished jobs are removed")
+	}
+	_, ok := GetJob("session", started[2].ID)
+	require.True(t, ok)
+}
+
+func TestStartJob_InvalidCommand(t *testing.T) {
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+	_, err := sh.StartJob("session", "echo 'unterminated")
+	require.Error(t, err)
+}
+
+func TestJobOutput_DropsOldOutput(t *testing.T) {
+	var out jobOutput
+	_, err := out.Write(make([]byte, maxJobOutput))
+	require.NoError(t, err)
+	_, err = out.Write([]byte("tail"))
+	require.NoError(t, err)
+
+	s, dropped := out.read()
+	require.Len(t, s, maxJobOutput)
+	require.Equal(t, "tail", s[len(s)-4:])
+	require.Equal(t, 4, dropped)
+}
@@ -1,34 +1,119 @@
 package shell
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
 )
 
+// initScriptTimeout is how long the init script of the persistent shell can
+// run.
+const initScriptTimeout = time.Minute
+
 // PersistentShell is a singleton shell instance that maintains state across the application
 type PersistentShell struct {
 	*Shell
+
+	initScript string
+	initOnce   sync.Once
+	initErr    error
+}
+
+// PersistentShellOptions configures the persistent shell when it is created.
+type PersistentShellOptions struct {
+	// Env is the environment the shell starts with, the environment of the
+	// process if nil.
+	Env []string
+	// InitScript is run once before the first command, e.g. to activate a
+	// virtual environment. It runs with the block functions and the sandbox
+	// set up by then, like any other command.
+	InitScript string
 }
 
 var (
-	once          sync.Once
-	shellInstance *PersistentShell
+	once              sync.Once
+	shellInstance     *PersistentShell
+	persistentOptions PersistentShellOptions
 )
 
+// ConfigurePersistentShell sets the options of the persistent shell. It has
+// no effect once the shell was created.
+func ConfigurePersistentShell(opts PersistentShellOptions) {
+	persistentOptions = opts
+}
+
 // GetPersistentShell returns the singleton persistent shell instance
 // This maintains backward compatibility with the existing API
 func GetPersistentShell(cwd string) *PersistentShell {
 	once.Do(func() {
-		shellInstance = &PersistentShell{
-			Shell: NewShell(&Options{
-				WorkingDir: cwd,
-				Logger:     &loggingAdapter{},
-			}),
-		}
+		shellInstance = newPersistentShell(cwd, persistentOptions)
 	})
 	return shellInstance
 }
 
+func newPersistentShell(cwd string, opts PersistentShellOptions) *PersistentShell {
+	return &PersistentShell{
+		Shell: NewShell(&Options{
+			WorkingDir: cwd,
+			Env:        opts.Env,
+			Logger:     &loggingAdapter{},
+		}),
+		initScript: opts.InitScript,
+	}
+}
+
+// Exec runs the init script if it hasn't run yet, and then the command.
+func (s *PersistentShell) Exec(ctx context.Context, command string) (string, string, error) {
+	s.init(ctx)
+	return s.Shell.Exec(ctx, command)
+}
+
+// ExecStream runs the init script if it hasn't run yet, and then the command.
+func (s *PersistentShell) ExecStream(ctx context.Context, command string, w io.Writer) (string, string, error) {
+	s.init(ctx)
+	return s.Shell.ExecStream(ctx, command, w)
+}
+
+// StartJob runs the init script if it hasn't run yet, and then starts the
+// job.
+func (s *PersistentShell) StartJob(sessionID, command string) (*Job, error) {
+	s.init(context.Background())
+	return s.Shell.StartJob(sessionID, command)
+}
+
+func (s *PersistentShell) init(ctx context.Context) {
+	s.initOnce.Do(func() {
+		if s.initScript != "" {
+			s.initErr = s.runInitScript(ctx)
+		}
+	})
+}
+
+func (s *PersistentShell) runInitScript(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, initScriptTimeout)
+	defer cancel()
+
+	_, stderr, err := s.Shell.Exec(ctx, s.initScript)
+	if err != nil {
+		slog.Error("Shell init script failed", "error", err, "stderr", stderr)
+		if stderr = strings.TrimSpace(stderr); stderr != "" {
+			return fmt.Errorf("%w: %s", err, stderr)
+		}
+		return err
+	}
+	slog.Info("Shell init script finished")
+	return nil
+}
+
+// InitError returns the error of the init script, if it ran and failed.
+func (s *PersistentShell) InitError() error {
+	return s.initErr
+}
+
 // slog.dapter adapts the internal slog.package to the Logger interface
 type loggingAdapter struct{}
 
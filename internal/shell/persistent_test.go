@@ -0,0 +1,49 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistentShell_InitScript(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "activate"), []byte("export VIRTUAL_ENV=venv\n"), 0o644))
+
+	sh := newPersistentShell(dir, PersistentShellOptions{
+		Env:        []string{"GREETING=hello"},
+		InitScript: "source activate && cd /",
+	})
+	require.NotContains(t, sh.GetEnv(), "VIRTUAL_ENV=venv", "the init script runs before the first command")
+
+	_, _, err := sh.Exec(t.Context(), "true")
+	require.NoError(t, err)
+	require.NoError(t, sh.InitError())
+	require.Contains(t, sh.GetEnv(), "GREETING=hello")
+	require.Contains(t, sh.GetEnv(), "VIRTUAL_ENV=venv")
+	require.Equal(t, "/", sh.GetWorkingDir(), "the init script runs in the shell")
+}
+
+func TestPersistentShell_InitScriptError(t *testing.T) {
+	sh := newPersistentShell(t.TempDir(), PersistentShellOptions{
+		InitScript: "echo broken >&2; exit 2",
+	})
+
+	stdout, _, err := sh.Exec(t.Context(), "echo still works")
+	require.NoError(t, err)
+	require.Equal(t, "still works\n", stdout)
+	require.ErrorContains(t, sh.InitError(), "broken")
+}
+
+func TestPersistentShell_InitScriptBlocked(t *testing.T) {
+	sh := newPersistentShell(t.TempDir(), PersistentShellOptions{
+		InitScript: "curl example.com",
+	})
+	sh.SetBlockFuncs([]BlockFunc{CommandsBlocker([]string{"curl"})})
+
+	_, _, err := sh.Exec(t.Context(), "true")
+	require.NoError(t, err)
+	require.ErrorContains(t, sh.InitError(), "not allowed")
+}
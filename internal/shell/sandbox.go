@@ -0,0 +1,175 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vikvang/zero/internal/sandbox"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// AskWriteFunc asks whether a command may write the file at the path outside
+// the sandbox.
+type AskWriteFunc func(ctx context.Context, path string) bool
+
+// SetSandbox limits the files commands can write. Writes outside the sandbox
+// are asked for with ask if the sandbox asks for them, and denied otherwise.
+// Only redirections and the files given to common commands that write files,
+// like rm, mv, cp or sed -i, are checked; other programs can still write
+// anywhere the user can.
+func (s *Shell) SetSandbox(sb *sandbox.Sandbox, ask AskWriteFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sandbox = sb
+	s.askWrite = ask
+}
+
+// checkWrite returns an error if the command can't write the file at the
+// absolute path.
+func (s *Shell) checkWrite(ctx context.Context, path string) error {
+	err := s.sandbox.Check(path)
+	if err == nil {
+		return nil
+	}
+	if s.sandbox.Ask() && s.askWrite != nil && s.askWrite(ctx, path) {
+		return nil
+	}
+	return err
+}
+
+// sandboxOpenHandler checks the files opened for writing by redirections.
+// Denied redirections fail like files that can't be opened.
+func (s *Shell) sandboxOpenHandler() interp.OpenHandlerFunc {
+	open := interp.DefaultOpenHandler()
+	return func(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+		if s.sandbox != nil && flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+			if err := s.checkWrite(ctx, absPath(interp.HandlerCtx(ctx).Dir, path)); err != nil {
+				// The interpreter only reports path errors, others are fatal.
+				return nil, &os.PathError{Op: "open", Path: path, Err: err}
+			}
+		}
+		return open(ctx, path, flag, perm)
+	}
+}
+
+// sandboxHandler checks the files written by common commands. Denied
+// commands fail with the reason on stderr, like commands the operating
+// system doesn't allow to write.
+func (s *Shell) sandboxHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if s.sandbox == nil || len(args) == 0 {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			for _, file := range writtenFiles(args) {
+				if file == "" {
+					continue
+				}
+				if err := s.checkWrite(ctx, absPath(hc.Dir, file)); err != nil {
+					fmt.Fprintf(hc.Stderr, "%s: %s\n", args[0], err)
+					return interp.ExitStatus(1)
+				}
+			}
+			return next(ctx, args)
+		}
+	}
+}
+
+// valueFlags are the flags of the commands in writtenFiles that are followed
+// by a value.
+var valueFlags = map[string][]string{
+	"cp":       {"-t", "-S"},
+	"install":  {"-m", "-o", "-g", "-t", "-S"},
+	"ln":       {"-t", "-S"},
+	"mkdir":    {"-m"},
+	"mv":       {"-t", "-S"},
+	"sed":      {"-e", "-f", "-l"},
+	"truncate": {"-s", "-r"},
+}
+
+// writtenFiles returns the files the command writes, if it is a common
+// command that writes the files it is given.
+func writtenFiles(args []string) []string {
+	name := filepath.Base(args[0])
+	operands, flags := splitOperands(args[1:], valueFlags[name])
+	switch name {
+	case "rm", "rmdir", "mkdir", "touch", "unlink", "shred", "truncate", "tee":
+		return operands
+	case "chmod", "chown", "chgrp":
+		// The first operand is the mode or the owner.
+		if len(operands) > 0 {
+			return operands[1:]
+		}
+	case "mv":
+		// Both the sources and the destination are changed.
+		if dir, ok := flags["-t"]; ok {
+			return append(operands, dir)
+		}
+		return operands
+	case "cp", "ln", "install":
+		if dir, ok := flags["-t"]; ok {
+			return []string{dir}
+		}
+		if len(operands) > 1 {
+			return operands[len(operands)-1:]
+		}
+	case "sed":
+		inPlace := slices.ContainsFunc(args[1:], func(arg string) bool {
+			return strings.HasPrefix(arg, "--in-place") ||
+				(strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "i"))
+		})
+		if !inPlace {
+			return nil
+		}
+		_, script := flags["-e"]
+		_, scriptFile := flags["-f"]
+		if !script && !scriptFile && len(operands) > 0 {
+			return operands[1:]
+		}
+		return operands
+	case "dd":
+		for _, arg := range args[1:] {
+			if file, ok := strings.CutPrefix(arg, "of="); ok {
+				return []string{file}
+			}
+		}
+	}
+	return nil
+}
+
+// splitOperands splits the arguments of a command into its operands and the
+// values of the given flags.
+func splitOperands(args, valueFlags []string) ([]string, map[string]string) {
+	var operands []string
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(operands, args[i+1:]...), flags
+		case slices.Contains(valueFlags, arg) && i+1 < len(args):
+			flags[arg] = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			flags[arg] = ""
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return operands, flags
+}
+
+// absPath returns the path relative to dir unless it is absolute.
+func absPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
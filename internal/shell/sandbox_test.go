@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/sandbox"
+)
+
+func TestShell_Sandbox(t *testing.T) {
+	project := t.TempDir()
+	outside := t.TempDir()
+	sh := NewShell(&Options{WorkingDir: project})
+	sh.SetSandbox(sandbox.New(sandbox.Options{Roots: []string{project}}), nil)
+
+	_, _, err := sh.Exec(t.Context(), "echo hi > inside.txt && mkdir -p sub && touch sub/file")
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(project, "sub", "file"))
+
+	outsideFile := filepath.Join(outside, "file.txt")
+	_, stderr, err := sh.Exec(t.Context(), "echo hi > "+outsideFile)
+	require.Error(t, err)
+	require.Contains(t, stderr, "outside the sandbox")
+	require.NoFileExists(t, outsideFile)
+
+	_, stderr, err = sh.Exec(t.Context(), "touch "+outsideFile)
+	require.Equal(t, 1, ExitCode(err))
+	require.Contains(t, stderr, "writing "+outsideFile+" is not allowed")
+	require.NoFileExists(t, outsideFile)
+
+	_, _, err = sh.Exec(t.Context(), "cat inside.txt > /dev/null")
+	require.NoError(t, err, "/dev/null can always be written")
+}
+
+func TestShell_SandboxAsk(t *testing.T) {
+	project := t.TempDir()
+	outside := t.TempDir()
+	var asked []string
+	sh := NewShell(&Options{WorkingDir: project})
+	sh.SetSandbox(sandbox.New(sandbox.Options{Roots: []string{project}, Ask: true}), func(_ context.Context, path string) bool {
+		asked = append(asked, path)
+		return strings.HasSuffix(path, "allowed.txt")
+	})
+
+	_, _, err := sh.Exec(t.Context(), "echo hi > "+filepath.Join(outside, "allowed.txt"))
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(outside, "allowed.txt"))
+
+	_, _, err = sh.Exec(t.Context(), "echo hi > "+filepath.Join(outside, "denied.txt"))
+	require.Error(t, err)
+	require.NoFileExists(t, filepath.Join(outside, "denied.txt"))
+	require.Equal(t, []string{filepath.Join(outside, "allowed.txt"), filepath.Join(outside, "denied.txt")}, asked)
+}
+
+func TestShell_SandboxDenyNetwork(t *testing.T) {
+	sh := NewShell(&Options{WorkingDir: t.TempDir(), Env: os.Environ()})
+	sh.SetSandbox(sandbox.New(sandbox.Options{Roots: []string{t.TempDir()}, DenyNetwork: true}), nil)
+
+	stdout, _, err := sh.Exec(t.Context(), "echo $HTTPS_PROXY")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "127.0.0.1")
+}
+
+func TestWrittenFiles(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"ls -la", nil},
+		{"rm -rf build dist", []string{"build", "dist"}},
+		{"mkdir -m 755 -p out", []string{"out"}},
+		{"chmod +x run.sh", []string{"run.sh"}},
+		{"cp -r src dst", []string{"dst"}},
+		{"cp -t dst a b", []string{"dst"}},
+		{"mv a b", []string{"a", "b"}},
+		{"sed s/a/b/ file", nil},
+		{"sed -i s/a/b/ file", []string{"file"}},
+		{"sed -i -e s/a/b/ file", []string{"file"}},
+		{"dd if=/dev/zero of=disk.img", []string{"disk.img"}},
+		{"rm -- -weird", []string{"-weird"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			require.Equal(t, tt.want, writtenFiles(strings.Fields(tt.command)))
+		})
+	}
+}
@@ -15,11 +15,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"slices"
 	"strings"
 	"sync"
 
+	"github.com/vikvang/zero/internal/sandbox"
 	"github.com/vikvang/zero/internal/slicesext"
 	"mvdan.cc/sh/moreinterp/coreutils"
 	"mvdan.cc/sh/v3/expand"
@@ -56,6 +58,8 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	sandbox    *sandbox.Sandbox
+	askWrite   AskWriteFunc
 }
 
 // Options for creating a new shell
@@ -103,6 +107,18 @@ func (s *Shell) Exec(ctx context.Context, command string) (string, string, error
 	return s.execPOSIX(ctx, command)
 }
 
+// ExecStream executes a command in the shell like Exec, and also writes its
+// output to w as it is produced. Both stdout and stderr are written to w, so
+// it must be safe for concurrent use.
+func (s *Shell) ExecStream(ctx context.Context, command string, w io.Writer) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stdout, stderr bytes.Buffer
+	err := s.execPOSIXTo(ctx, command, io.MultiWriter(&stdout, w), io.MultiWriter(&stderr, w))
+	return stdout.String(), stderr.String(), err
+}
+
 // GetWorkingDir returns the current working directory
 func (s *Shell) GetWorkingDir() string {
 	s.mu.Lock()
@@ -230,21 +246,29 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 
 // execPOSIX executes commands using POSIX shell emulation (cross-platform)
 func (s *Shell) execPOSIX(ctx context.Context, command string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := s.execPOSIXTo(ctx, command, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+// execPOSIXTo executes commands like execPOSIX, writing their output as it
+// is produced.
+func (s *Shell) execPOSIXTo(ctx context.Context, command string, stdout, stderr io.Writer) error {
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
-		return "", "", fmt.Errorf("could not parse command: %w", err)
+		return fmt.Errorf("could not parse command: %w", err)
 	}
 
-	var stdout, stderr bytes.Buffer
 	runner, err := interp.New(
-		interp.StdIO(nil, &stdout, &stderr),
+		interp.StdIO(nil, stdout, stderr),
 		interp.Interactive(false),
-		interp.Env(expand.ListEnviron(s.env...)),
+		interp.Env(expand.ListEnviron(slices.Concat(s.env, s.sandbox.Env())...)),
 		interp.Dir(s.cwd),
-		interp.ExecHandlers(s.blockHandler(), coreutils.ExecHandler),
+		interp.OpenHandler(s.sandboxOpenHandler()),
+		interp.ExecHandlers(s.blockHandler(), s.sandboxHandler(), coreutils.ExecHandler),
 	)
 	if err != nil {
-		return "", "", fmt.Errorf("could not run command: %w", err)
+		return fmt.Errorf("could not run command: %w", err)
 	}
 
 	err = runner.Run(ctx, line)
@@ -254,7 +278,7 @@ func (s *Shell) execPOSIX(ctx context.Context, command string) (string, string,
 		s.env = append(s.env, fmt.Sprintf("%s=%s", name, vr.Str))
 	}
 	s.logger.InfoPersist("POSIX command finished", "command", command, "err", err)
-	return stdout.String(), stderr.String(), err
+	return err
 }
 
 // IsInterrupt checks if an error is due to interruption
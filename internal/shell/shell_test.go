@@ -1,10 +1,12 @@
 package shell
 
 import (
+	"bytes"
 	"context"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -98,6 +100,33 @@ func TestRunContinuity(t *testing.T) {
 	}
 }
 
+// lockedBuffer is a buffer that is safe for concurrent writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func TestExecStream(t *testing.T) {
+	shell := NewShell(&Options{WorkingDir: t.TempDir()})
+	var stream lockedBuffer
+	stdout, stderr, err := shell.ExecStream(t.Context(), "echo out; echo err >&2", &stream)
+	if err != nil {
+		t.Fatalf("failed to exec: %v", err)
+	}
+	if stdout != "out\n" || stderr != "err\n" {
+		t.Fatalf("expected separate stdout and stderr, got %q and %q", stdout, stderr)
+	}
+	if got := stream.buf.String(); got != "out\nerr\n" {
+		t.Fatalf("expected both streams to be written as produced, got %q", got)
+	}
+}
+
 func TestCrossPlatformExecution(t *testing.T) {
 	shell := NewShell(&Options{WorkingDir: "."})
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -10,6 +10,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/vikvang/zero/internal/app"
 	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/llm/tools"
 	"github.com/vikvang/zero/internal/message"
 	"github.com/vikvang/zero/internal/permission"
 	"github.com/vikvang/zero/internal/pubsub"
@@ -30,6 +31,12 @@ type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
 
+// SessionRewoundMsg is sent when the conversation of a session was rewound,
+// so its messages are loaded again.
+type SessionRewoundMsg struct {
+	Session session.Session
+}
+
 type SelectionCopyMsg struct {
 	clickCount   int
 	endSelection bool
@@ -192,6 +199,9 @@ func (m *messageListCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case pubsub.Event[permission.PermissionNotification]:
 		cmds = append(cmds, m.handlePermissionRequest(msg.Payload))
 		return m, tea.Batch(cmds...)
+	case pubsub.Event[tools.BashOutput]:
+		m.handleBashOutput(msg.Payload)
+		return m, tea.Batch(cmds...)
 	case SessionSelectedMsg:
 		if msg.ID != m.session.ID {
 			cmds = append(cmds, m.SetSession(msg))
@@ -201,6 +211,12 @@ func (m *messageListCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.session = session.Session{}
 		cmds = append(cmds, m.listCmp.SetItems([]list.Item{}))
 		return m, tea.Batch(cmds...)
+	case SessionRewoundMsg:
+		if msg.Session.ID == m.session.ID {
+			m.session = session.Session{}
+			cmds = append(cmds, m.SetSession(msg.Session))
+		}
+		return m, tea.Batch(cmds...)
 
 	case pubsub.Event[message.Message]:
 		cmds = append(cmds, m.handleMessageEvent(msg))
@@ -255,6 +271,19 @@ func (m *messageListCmp) handlePermissionRequest(permission permission.Permissio
 	return nil
 }
 
+// handleBashOutput adds the output of a running command to its tool call.
+func (m *messageListCmp) handleBashOutput(output tools.BashOutput) {
+	if output.SessionID != m.session.ID {
+		return
+	}
+	items := m.listCmp.Items()
+	if toolCallIndex := m.findToolCallByID(items, output.ToolCallID); toolCallIndex != NotFound {
+		toolCall := items[toolCallIndex].(messages.ToolCallCmp)
+		toolCall.AppendOutput(output.Output)
+		m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+	}
+}
+
 // handleChildSession handles messages from child sessions (agent tools).
 func (m *messageListCmp) handleChildSession(event pubsub.Event[message.Message]) tea.Cmd {
 	var cmds []tea.Cmd
@@ -611,8 +640,8 @@ func (m *messageListCmp) convertAssistantMessage(msg message.Message, toolResult
 	for _, tc := range msg.ToolCalls() {
 		options := m.buildToolCallOptions(tc, msg, toolResultMap)
 		uiMessages = append(uiMessages, messages.NewToolCallCmp(msg.ID, tc, m.app.Permissions, options...))
-		// If this tool call is the agent tool, fetch nested tool calls
-		if tc.Name == agent.AgentToolName {
+		// If this tool call is an agent tool, fetch nested tool calls
+		if agent.IsAgentToolName(tc.Name) {
 			nestedMessages, _ := m.app.Messages.List(context.Background(), tc.ID)
 			nestedToolResultMap := m.buildToolResultMap(nestedMessages)
 			nestedUIMessages := m.convertMessagesToUI(nestedMessages, nestedToolResultMap)
@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/v2/key"
@@ -18,6 +19,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/vikvang/zero/internal/app"
 	"github.com/vikvang/zero/internal/fsext"
+	"github.com/vikvang/zero/internal/llm/agent"
 	"github.com/vikvang/zero/internal/message"
 	"github.com/vikvang/zero/internal/session"
 	"github.com/vikvang/zero/internal/tui/components/chat"
@@ -49,6 +51,18 @@ type FileCompletionItem struct {
 	Path string // The file path
 }
 
+type MCPResourceCompletionItem struct {
+	Resource agent.MCPResource
+}
+
+// mcpResourcesFailedMsg restores the prompt when the MCP resources it
+// mentions couldn't be read.
+type mcpResourcesFailedMsg struct {
+	text        string
+	attachments []message.Attachment
+	err         error
+}
+
 type editorCmp struct {
 	width              int
 	height             int
@@ -63,9 +77,10 @@ type editorCmp struct {
 
 	keyMap EditorKeyMap
 
-	// File path completions
+	// File path and MCP resource completions
 	currentQuery          string
 	completionsStartIndex int
+	completionsPrefix     string
 	isCompletionsOpen     bool
 }
 
@@ -85,7 +100,8 @@ var DeleteKeyMaps = DeleteAttachmentKeyMaps{
 }
 
 const (
-	maxAttachments = 5
+	maxAttachments     = 5
+	mcpResourceTimeout = 30 * time.Second
 )
 
 type OpenEditorMsg struct {
@@ -158,6 +174,10 @@ func (m *editorCmp) send() tea.Cmd {
 	// Change the placeholder when sending a new message.
 	m.randomizePlaceholders()
 
+	if mentions := agent.ParseMCPResourceMentions(value); len(mentions) > 0 {
+		return m.sendWithMCPResources(value, attachments, mentions)
+	}
+
 	return tea.Batch(
 		util.CmdHandler(chat.SendMsg{
 			Text:        value,
@@ -166,6 +186,27 @@ func (m *editorCmp) send() tea.Cmd {
 	)
 }
 
+// sendWithMCPResources reads the MCP resources mentioned in the prompt and
+// sends them along as attachments.
+func (m *editorCmp) sendWithMCPResources(value string, attachments []message.Attachment, mentions []agent.MCPResource) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpResourceTimeout)
+		defer cancel()
+		all := slices.Clone(attachments)
+		for _, mention := range mentions {
+			resources, err := agent.ReadMCPResource(ctx, mention.MCPName, mention.URI)
+			if err != nil {
+				return mcpResourcesFailedMsg{text: value, attachments: attachments, err: err}
+			}
+			all = append(all, resources...)
+		}
+		return chat.SendMsg{
+			Text:        value,
+			Attachments: all,
+		}
+	}
+}
+
 func (m *editorCmp) repositionCompletions() tea.Msg {
 	x, y := m.completionsPosition()
 	return completions.RepositionCompletionsMsg{X: x, Y: y}
@@ -189,16 +230,28 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.isCompletionsOpen = false
 		m.currentQuery = ""
 		m.completionsStartIndex = 0
+	case mcpResourcesFailedMsg:
+		m.textarea.SetValue(msg.text)
+		m.textarea.MoveToEnd()
+		m.attachments = msg.attachments
+		return m, util.ReportError(msg.err)
 	case completions.SelectCompletionMsg:
 		if !m.isCompletionsOpen {
 			return m, nil
 		}
-		if item, ok := msg.Value.(FileCompletionItem); ok {
+		var completion string
+		switch item := msg.Value.(type) {
+		case FileCompletionItem:
+			completion = item.Path
+		case MCPResourceCompletionItem:
+			completion = item.Resource.Mention()
+		}
+		if completion != "" {
 			word := m.textarea.Word()
-			// If the selected item is a file, insert its path into the textarea
+			// Insert the file path or the resource mention into the textarea
 			value := m.textarea.Value()
 			value = value[:m.completionsStartIndex] + // Remove the current query
-				item.Path + // Insert the file path
+				completion + // Insert the completion
 				value[m.completionsStartIndex+len(word):] // Append the rest of the value
 			// XXX: This will always move the cursor to the end of the textarea.
 			m.textarea.SetValue(value)
@@ -270,7 +323,16 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.isCompletionsOpen = true
 			m.currentQuery = ""
 			m.completionsStartIndex = curIdx
+			m.completionsPrefix = "/"
 			cmds = append(cmds, m.startCompletions)
+		case msg.String() == "@" && !m.isCompletionsOpen &&
+			(len(m.textarea.Value()) == 0 || unicode.IsSpace(rune(m.textarea.Value()[len(m.textarea.Value())-1]))) &&
+			len(agent.GetMCPResources()) > 0:
+			m.isCompletionsOpen = true
+			m.currentQuery = ""
+			m.completionsStartIndex = curIdx
+			m.completionsPrefix = "@"
+			cmds = append(cmds, m.startMCPResourceCompletions)
 		case m.isCompletionsOpen && curIdx <= m.completionsStartIndex:
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 		}
@@ -336,7 +398,7 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 			} else {
 				word := m.textarea.Word()
-				if strings.HasPrefix(word, "/") {
+				if strings.HasPrefix(word, m.completionsPrefix) {
 					// XXX: wont' work if editing in the middle of the field.
 					m.completionsStartIndex = strings.LastIndex(m.textarea.Value(), word)
 					m.currentQuery = word[1:]
@@ -501,6 +563,30 @@ func (m *editorCmp) startCompletions() tea.Msg {
 	}
 }
 
+func (m *editorCmp) startMCPResourceCompletions() tea.Msg {
+	resources := agent.GetMCPResources()
+	completionItems := make([]completions.Completion, 0, len(resources))
+	for _, resource := range resources {
+		title := resource.MCPName + ":" + resource.URI
+		if resource.Name != "" && resource.Name != resource.URI {
+			title += " " + resource.Name
+		}
+		completionItems = append(completionItems, completions.Completion{
+			Title: title,
+			Value: MCPResourceCompletionItem{
+				Resource: resource,
+			},
+		})
+	}
+
+	x, y := m.completionsPosition()
+	return completions.OpenCompletionsMsg{
+		Completions: completionItems,
+		X:           x,
+		Y:           y,
+	}
+}
+
 // Blur implements Container.
 func (c *editorCmp) Blur() tea.Cmd {
 	c.textarea.Blur()
@@ -573,9 +659,10 @@ func New(app *app.App) Editor {
 	ta.Focus()
 	e := &editorCmp{
 		// TODO: remove the app instance from here
-		app:      app,
-		textarea: ta,
-		keyMap:   DefaultEditorKeyMap(),
+		app:               app,
+		textarea:          ta,
+		keyMap:            DefaultEditorKeyMap(),
+		completionsPrefix: "/",
 	}
 	e.setEditorPrompt()
 
@@ -190,8 +190,11 @@ func (m *messageCmp) renderAssistantMessage() string {
 		content = ""
 	} else if finished && content == "" && finishedData.Reason == message.FinishReasonCanceled {
 		content = "*Canceled*"
-	} else if finished && content == "" && finishedData.Reason == message.FinishReasonError {
+	} else if finished && content == "" && (finishedData.Reason == message.FinishReasonError || finishedData.Reason == message.FinishReasonBudgetExceeded) {
 		errTag := t.S().Base.Padding(0, 1).Background(t.Red).Foreground(t.White).Render("ERROR")
+		if finishedData.Reason == message.FinishReasonBudgetExceeded {
+			errTag = t.S().Base.Padding(0, 1).Background(t.Warning).Foreground(t.White).Render("BUDGET")
+		}
 		truncated := ansi.Truncate(finishedData.Message, m.textWidth()-2-lipgloss.Width(errTag), "...")
 		title := fmt.Sprintf("%s %s", errTag, t.S().Base.Foreground(t.FgHalfMuted).Render(truncated))
 		details := t.S().Base.Foreground(t.FgSubtle).Width(m.textWidth() - 2).Render(finishedData.Details)
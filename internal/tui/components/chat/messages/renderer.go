@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/vikvang/zero/internal/ansiext"
+	"github.com/vikvang/zero/internal/config"
 	"github.com/vikvang/zero/internal/fsext"
 	"github.com/vikvang/zero/internal/llm/agent"
 	"github.com/vikvang/zero/internal/llm/tools"
@@ -42,6 +43,9 @@ func (rr renderRegistry) lookup(name string) renderer {
 	if f, ok := rr[name]; ok {
 		return f()
 	}
+	if agent.IsAgentToolName(name) {
+		return agentRenderer{}
+	}
 	return genericRenderer{} // sensible fallback
 }
 
@@ -174,7 +178,16 @@ func init() {
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
+	registry.register(tools.LSPDefinitionToolName, func() renderer { return lspRenderer{} })
+	registry.register(tools.LSPReferencesToolName, func() renderer { return lspRenderer{} })
+	registry.register(tools.LSPSymbolsToolName, func() renderer { return lspRenderer{} })
+	registry.register(tools.LSPHoverToolName, func() renderer { return lspRenderer{} })
+	registry.register(tools.LSPCallHierarchyToolName, func() renderer { return lspRenderer{} })
+	registry.register(tools.LSPRefactorToolName, func() renderer { return lspRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
+	registry.register(agent.ReadMCPResourceToolName, func() renderer { return mcpResourceRenderer{} })
+	registry.register(tools.JobOutputToolName, func() renderer { return jobRenderer{} })
+	registry.register(tools.JobKillToolName, func() renderer { return jobRenderer{} })
 }
 
 // -----------------------------------------------------------------------------
@@ -211,7 +224,10 @@ func (br bashRenderer) Render(v *toolCallCmp) string {
 
 	cmd := strings.ReplaceAll(params.Command, "\n", " ")
 	cmd = strings.ReplaceAll(cmd, "\t", "    ")
-	args := newParamBuilder().addMain(cmd).build()
+	args := newParamBuilder().
+		addMain(cmd).
+		addFlag("background", params.RunInBackground).
+		build()
 
 	return br.renderWithParams(v, "Bash", args, func() string {
 		var meta tools.BashResponseMetadata
@@ -589,6 +605,122 @@ func (dr diagnosticsRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  LSP renderer
+// -----------------------------------------------------------------------------
+
+// lspRenderer handles the LSP code navigation tools
+type lspRenderer struct {
+	baseRenderer
+}
+
+// lspRenderParams holds the parameters shared by the LSP navigation tools
+type lspRenderParams struct {
+	tools.LSPPositionParams
+	Query     string `json:"query"`
+	Kind      string `json:"kind"`
+	Direction string `json:"direction"`
+	NewName   string `json:"new_name"`
+	Action    string `json:"action"`
+}
+
+// Render displays the symbol location and the plain results
+func (lr lspRenderer) Render(v *toolCallCmp) string {
+	var params lspRenderParams
+	var args []string
+	if err := lr.unmarshalParams(v.call.Input, &params); err == nil {
+		location := fsext.PrettyPath(params.FilePath)
+		if location != "" && params.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, params.Line)
+		}
+		subject := params.Symbol
+		if params.Query != "" {
+			subject = params.Query
+		}
+		if subject == "" {
+			subject, location = location, ""
+		}
+		args = newParamBuilder().
+			addMain(subject).
+			addKeyValue("at", location).
+			addKeyValue("kind", params.Kind).
+			addKeyValue("direction", params.Direction).
+			addKeyValue("new name", params.NewName).
+			addKeyValue("action", params.Action).
+			build()
+	}
+
+	return lr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  MCP resource renderer
+// -----------------------------------------------------------------------------
+
+// mcpResourceRenderer handles listing and reading MCP resources
+type mcpResourceRenderer struct {
+	baseRenderer
+}
+
+// Render displays the resource URI, or the listing, with the MCP server name
+func (mr mcpResourceRenderer) Render(v *toolCallCmp) string {
+	var params agent.ReadMCPResourceParams
+	var args []string
+	if err := mr.unmarshalParams(v.call.Input, &params); err == nil {
+		main := params.URI
+		if main == "" {
+			main = "list"
+		}
+		args = newParamBuilder().
+			addMain(main).
+			addKeyValue("mcp", params.MCPName).
+			build()
+	}
+
+	return mr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Job renderer
+// -----------------------------------------------------------------------------
+
+// jobRenderer handles reading the output of background jobs and killing them
+type jobRenderer struct {
+	baseRenderer
+}
+
+// Render displays the job ID, or the listing, and the output of the job
+func (jr jobRenderer) Render(v *toolCallCmp) string {
+	var params tools.JobOutputParams
+	var args []string
+	if err := jr.unmarshalParams(v.call.Input, &params); err == nil {
+		main := "list"
+		if params.JobID != "" {
+			main = "job " + params.JobID
+		}
+		wait := ""
+		if params.Wait > 0 {
+			wait = (time.Duration(params.Wait) * time.Millisecond).String()
+		}
+		args = newParamBuilder().
+			addMain(main).
+			addKeyValue("wait", wait).
+			build()
+	}
+
+	return jr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		var meta tools.JobResponseMetadata
+		if err := jr.unmarshalParams(v.result.Metadata, &meta); err != nil || meta.Output == "" {
+			return renderPlainContent(v, v.result.Content)
+		}
+		return renderPlainContent(v, meta.Output)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Task renderer
 // -----------------------------------------------------------------------------
@@ -614,7 +746,7 @@ func (tr agentRenderer) Render(v *toolCallCmp) string {
 	prompt := params.Prompt
 	prompt = strings.ReplaceAll(prompt, "\n", " ")
 
-	header := tr.makeHeader(v, "Agent", v.textWidth())
+	header := tr.makeHeader(v, prettifyToolName(v.call.Name), v.textWidth())
 	if res, done := earlyState(header, v); v.cancelled && done {
 		return res
 	}
@@ -726,6 +858,8 @@ func earlyState(header string, v *toolCallCmp) (string, bool) {
 	case v.result.ToolCallID == "":
 		if v.permissionRequested && !v.permissionGranted {
 			message = t.S().Base.Foreground(t.FgSubtle).Render("Requesting for permission...")
+		} else if strings.TrimSpace(v.liveOutput) != "" {
+			message = renderOutputTail(v, v.liveOutput)
 		} else {
 			message = t.S().Base.Foreground(t.FgSubtle).Render("Waiting for tool response...")
 		}
@@ -780,6 +914,35 @@ func renderPlainContent(v *toolCallCmp, content string) string {
 	return strings.Join(out, "\n")
 }
 
+// renderOutputTail renders the last lines of the output of a running tool
+func renderOutputTail(v *toolCallCmp, content string) string {
+	t := styles.CurrentTheme()
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\t", "    ")
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > responseContextHeight {
+		lines = lines[len(lines)-responseContextHeight:]
+	}
+
+	width := v.textWidth() - 2 // -2 for left padding
+	out := make([]string, 0, len(lines))
+	for _, ln := range lines {
+		// Progress bars redraw the line with carriage returns.
+		if i := strings.LastIndexByte(ln, '\r'); i >= 0 {
+			ln = ln[i+1:]
+		}
+		ln = " " + ansiext.Escape(ln)
+		if len(ln) > width {
+			ln = v.fit(ln, width)
+		}
+		out = append(out, t.S().Muted.
+			Width(width).
+			Background(t.BgBaseLighter).
+			Render(ln))
+	}
+	return strings.Join(out, "\n")
+}
+
 func getDigits(n int) int {
 	if n == 0 {
 		return 1
@@ -864,8 +1027,14 @@ func prettifyToolName(name string) string {
 	switch name {
 	case agent.AgentToolName:
 		return "Agent"
+	case agent.ReadMCPResourceToolName:
+		return "MCP Resource"
 	case tools.BashToolName:
 		return "Bash"
+	case tools.JobOutputToolName:
+		return "Job Output"
+	case tools.JobKillToolName:
+		return "Kill Job"
 	case tools.DownloadToolName:
 		return "Download"
 	case tools.EditToolName:
@@ -886,7 +1055,30 @@ func prettifyToolName(name string) string {
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.LSPDefinitionToolName:
+		return "Definition"
+	case tools.LSPReferencesToolName:
+		return "References"
+	case tools.LSPSymbolsToolName:
+		return "Symbols"
+	case tools.LSPHoverToolName:
+		return "Hover"
+	case tools.LSPCallHierarchyToolName:
+		return "Call Hierarchy"
+	case tools.LSPRefactorToolName:
+		return "Refactor"
 	default:
+		if id, ok := agent.SubAgentID(name); ok {
+			return subAgentName(id)
+		}
 		return name
 	}
 }
+
+// subAgentName returns the display name of the agent a tool delegates to.
+func subAgentName(id string) string {
+	if agentCfg, ok := config.Get().Agents[id]; ok {
+		return agentCfg.Name + " Agent"
+	}
+	return id + " Agent"
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/v2/key"
@@ -43,6 +44,7 @@ type ToolCallCmp interface {
 	ID() string
 	SetPermissionRequested() // Mark permission request
 	SetPermissionGranted()   // Mark permission granted
+	AppendOutput(string)     // Add output of the running tool
 }
 
 // toolCallCmp implements the ToolCallCmp interface for displaying tool calls.
@@ -59,6 +61,7 @@ type toolCallCmp struct {
 	cancelled           bool               // Whether the tool call was cancelled
 	permissionRequested bool
 	permissionGranted   bool
+	liveOutput          string // Tail of the output of the running tool
 
 	// Animation state for pending tool calls
 	spinning bool       // Whether to show loading animation
@@ -245,6 +248,12 @@ func (m *toolCallCmp) formatToolForCopy() string {
 }
 
 func (m *toolCallCmp) formatParametersForCopy() string {
+	if agent.IsAgentToolName(m.call.Name) {
+		var params agent.AgentParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			return fmt.Sprintf("**Task:**\n%s", params.Prompt)
+		}
+	}
 	switch m.call.Name {
 	case tools.BashToolName:
 		var params tools.BashParams
@@ -358,10 +367,13 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 		}
 	case tools.DiagnosticsToolName:
 		return "**Project:** diagnostics"
-	case agent.AgentToolName:
-		var params agent.AgentParams
+	case agent.ReadMCPResourceToolName:
+		var params agent.ReadMCPResourceParams
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
-			return fmt.Sprintf("**Task:**\n%s", params.Prompt)
+			if params.URI == "" {
+				return "**Resources:** list"
+			}
+			return fmt.Sprintf("**MCP:** %s\n**URI:** %s", params.MCPName, params.URI)
 		}
 	}
 
@@ -382,6 +394,9 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 }
 
 func (m *toolCallCmp) formatResultForCopy() string {
+	if agent.IsAgentToolName(m.call.Name) {
+		return m.formatAgentResultForCopy()
+	}
 	switch m.call.Name {
 	case tools.BashToolName:
 		return m.formatBashResultForCopy()
@@ -395,9 +410,9 @@ func (m *toolCallCmp) formatResultForCopy() string {
 		return m.formatWriteResultForCopy()
 	case tools.FetchToolName:
 		return m.formatFetchResultForCopy()
-	case agent.AgentToolName:
-		return m.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName,
+		tools.LSPDefinitionToolName, tools.LSPReferencesToolName, tools.LSPSymbolsToolName, tools.LSPCallHierarchyToolName, tools.LSPRefactorToolName,
+		agent.ReadMCPResourceToolName:
 		return fmt.Sprintf("```\n%s\n```", m.result.Content)
 	default:
 		return m.result.Content
@@ -666,6 +681,7 @@ func (m *toolCallCmp) ParentMessageID() string {
 func (m *toolCallCmp) SetToolResult(result message.ToolResult) {
 	m.result = result
 	m.spinning = false
+	m.liveOutput = ""
 }
 
 // GetToolCall returns the current tool call data
@@ -810,3 +826,26 @@ func (m *toolCallCmp) SetPermissionRequested() {
 func (m *toolCallCmp) SetPermissionGranted() {
 	m.permissionGranted = true
 }
+
+// maxLiveOutput is how much of the output of a running tool is kept to show
+// its tail.
+const maxLiveOutput = 16 * 1024
+
+// AppendOutput adds output of the running tool, only its tail is kept
+func (m *toolCallCmp) AppendOutput(output string) {
+	if m.result.ToolCallID != "" {
+		return
+	}
+	m.liveOutput += output
+	if over := len(m.liveOutput) - maxLiveOutput; over > 0 {
+		// Don't cut a character in half.
+		for over < len(m.liveOutput) && !utf8.RuneStart(m.liveOutput[over]) {
+			over++
+		}
+		m.liveOutput = m.liveOutput[over:]
+		// Drop the partial first line.
+		if i := strings.IndexByte(m.liveOutput, '\n'); i >= 0 {
+			m.liveOutput = m.liveOutput[i+1:]
+		}
+	}
+}
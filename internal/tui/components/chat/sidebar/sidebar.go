@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
@@ -17,6 +18,7 @@ import (
 	"github.com/vikvang/zero/internal/lsp"
 	"github.com/vikvang/zero/internal/pubsub"
 	"github.com/vikvang/zero/internal/session"
+	"github.com/vikvang/zero/internal/shell"
 	"github.com/vikvang/zero/internal/tui/components/chat"
 	"github.com/vikvang/zero/internal/tui/components/core"
 	"github.com/vikvang/zero/internal/tui/components/core/layout"
@@ -161,6 +163,9 @@ func (m *sidebarCmp) View() string {
 		// Vertical layout (default)
 		if m.session.ID != "" {
 			parts = append(parts, "", m.filesBlock())
+			if jobs := m.jobsBlock(); jobs != "" {
+				parts = append(parts, "", jobs)
+			}
 		}
 		parts = append(parts,
 			"",
@@ -504,6 +509,35 @@ func (m *sidebarCmp) mcpBlock() string {
 	}, true)
 }
 
+// jobsBlock lists the running background jobs of the session, it is empty
+// when there are none.
+func (m *sidebarCmp) jobsBlock() string {
+	t := styles.CurrentTheme()
+	maxWidth := m.getMaxWidth()
+	jobList := []string{t.S().Subtle.Render(core.Section("Jobs", maxWidth)), ""}
+	for _, job := range shell.ListJobs(m.session.ID) {
+		info := job.Info()
+		if info.Status != shell.JobRunning {
+			continue
+		}
+		jobList = append(jobList,
+			core.Status(
+				core.StatusOpts{
+					Icon:         t.ItemBusyIcon.String(),
+					Title:        "Job " + info.ID,
+					Description:  strings.Join(strings.Fields(info.Command), " "),
+					ExtraContent: t.S().Subtle.Render(time.Since(info.StartedAt).Round(time.Second).String()),
+				},
+				maxWidth,
+			),
+		)
+	}
+	if len(jobList) == 2 {
+		return ""
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, jobList...)
+}
+
 func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	t := styles.CurrentTheme()
 	// Format tokens in human-readable format (e.g., 110K, 1.2M)
@@ -544,7 +578,7 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 
 func (s *sidebarCmp) currentModelBlock() string {
 	cfg := config.Get()
-	agentCfg := cfg.Agents["coder"]
+	agentCfg := cfg.PrimaryAgent()
 
 	selectedModel := cfg.Models[agentCfg.Model]
 
@@ -559,6 +593,9 @@ func (s *sidebarCmp) currentModelBlock() string {
 	parts := []string{
 		modelInfo,
 	}
+	if agentCfg.ID != "coder" {
+		parts = append(parts, t.S().Subtle.PaddingLeft(2).Render(agentCfg.Name+" agent"))
+	}
 	if model.CanReason {
 		reasoningInfoStyle := t.S().Subtle.PaddingLeft(2)
 		switch modelProvider.Type {
@@ -569,7 +606,7 @@ func (s *sidebarCmp) currentModelBlock() string {
 			}
 			formatter := cases.Title(language.English, cases.NoLower)
 			parts = append(parts, reasoningInfoStyle.Render(formatter.String(fmt.Sprintf("Reasoning %s", reasoningEffort))))
-		case catwalk.TypeAnthropic:
+		case catwalk.TypeAnthropic, config.TypeOllama:
 			formatter := cases.Title(language.English, cases.NoLower)
 			if selectedModel.Think {
 				parts = append(parts, reasoningInfoStyle.Render(formatter.String("Thinking on")))
@@ -23,6 +23,10 @@ type ShowArgumentsDialogMsg struct {
 	CommandID string
 	Content   string
 	ArgNames  []string
+	Edit      bool
+	// Submit, when set, is called with the argument values instead of
+	// replacing them in Content.
+	Submit func(args map[string]string) tea.Cmd
 }
 
 // CloseArgumentsDialogMsg is a message that is sent when the arguments dialog is closed.
@@ -49,11 +53,14 @@ type commandArgumentsDialogCmp struct {
 	commandID  string
 	content    string
 	argNames   []string
+	edit       bool
+	submit     func(args map[string]string) tea.Cmd
 	help       help.Model
 }
 
-func NewCommandArgumentsDialog(commandID, content string, argNames []string) CommandArgumentsDialog {
+func NewCommandArgumentsDialog(msg ShowArgumentsDialogMsg) CommandArgumentsDialog {
 	t := styles.CurrentTheme()
+	argNames := msg.ArgNames
 	inputs := make([]textinput.Model, len(argNames))
 
 	for i, name := range argNames {
@@ -77,9 +84,11 @@ func NewCommandArgumentsDialog(commandID, content string, argNames []string) Com
 	return &commandArgumentsDialogCmp{
 		inputs:     inputs,
 		keys:       DefaultArgumentsDialogKeyMap(),
-		commandID:  commandID,
-		content:    content,
+		commandID:  msg.CommandID,
+		content:    msg.Content,
 		argNames:   argNames,
+		edit:       msg.Edit,
+		submit:     msg.Submit,
 		focusIndex: 0,
 		width:      60,
 		help:       help.New(),
@@ -101,6 +110,18 @@ func (c *commandArgumentsDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch {
 		case key.Matches(msg, c.keys.Confirm):
 			if c.focusIndex == len(c.inputs)-1 {
+				if c.submit != nil {
+					args := make(map[string]string, len(c.argNames))
+					for i, name := range c.argNames {
+						if value := c.inputs[i].Value(); value != "" {
+							args[name] = value
+						}
+					}
+					return c, tea.Sequence(
+						util.CmdHandler(dialogs.CloseDialogMsg{}),
+						c.submit(args),
+					)
+				}
 				content := c.content
 				for i, name := range c.argNames {
 					value := c.inputs[i].Value()
@@ -111,6 +132,7 @@ func (c *commandArgumentsDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					util.CmdHandler(dialogs.CloseDialogMsg{}),
 					util.CmdHandler(CommandRunCustomMsg{
 						Content: content,
+						Edit:    c.edit,
 					}),
 				)
 			}
@@ -38,6 +38,7 @@ type Command struct {
 	Title       string
 	Description string
 	Shortcut    string // Optional shortcut for the command
+	Edit        bool   // Insert the command content into the editor instead of sending it
 	Handler     func(cmd Command) tea.Cmd
 }
 
@@ -70,9 +71,17 @@ type (
 	ToggleThinkingMsg     struct{}
 	OpenExternalEditorMsg struct{}
 	ToggleYoloModeMsg     struct{}
-	CompactMsg            struct {
+	OpenGrantsMsg         struct{}
+	OpenShellEnvMsg       struct{}
+	OpenRewindMsg         struct {
 		SessionID string
 	}
+	CompactMsg struct {
+		SessionID string
+	}
+	SwitchAgentMsg struct {
+		AgentID string
+	}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
@@ -111,7 +120,7 @@ func (c *commandDialogCmp) Init() tea.Cmd {
 	if err != nil {
 		return util.ReportError(err)
 	}
-	c.userCommands = commands
+	c.userCommands = append(commands, LoadMCPPrompts()...)
 	return c.SetCommandType(c.commandType)
 }
 
@@ -126,12 +135,18 @@ func (c *commandDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 	case tea.KeyPressMsg:
 		switch {
-		case key.Matches(msg, c.keyMap.Select):
+		case key.Matches(msg, c.keyMap.Select, c.keyMap.Edit):
 			selectedItem := c.commandList.SelectedItem()
 			if selectedItem == nil {
 				return c, nil // No item selected, do nothing
 			}
 			command := (*selectedItem).Value()
+			if key.Matches(msg, c.keyMap.Edit) {
+				if c.commandType != UserCommands {
+					return c, nil
+				}
+				command.Edit = true
+			}
 			return c, tea.Sequence(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				command.Handler(command),
@@ -300,13 +315,46 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 
-	// Only show thinking toggle for Anthropic models that can reason
+	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "rewind",
+			Title:       "Rewind",
+			Description: "Restore the files, and optionally the conversation, to before a prompt",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenRewindMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+	}
+
+	// Offer switching to the other agents of the configuration
 	cfg := config.Get()
-	if agentCfg, ok := cfg.Agents["coder"]; ok {
+	primaryAgent := cfg.PrimaryAgent()
+	for _, agentCfg := range cfg.PrimaryAgents() {
+		if agentCfg.ID == primaryAgent.ID {
+			continue
+		}
+		description := agentCfg.Description
+		if description == "" {
+			description = "Switch to the " + agentCfg.Name + " agent"
+		}
+		commands = append(commands, Command{
+			ID:          "switch_agent_" + agentCfg.ID,
+			Title:       "Switch to " + agentCfg.Name + " Agent",
+			Description: description,
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchAgentMsg{AgentID: agentCfg.ID})
+			},
+		})
+	}
+
+	// Only show thinking toggle for Anthropic and Ollama models that can reason
+	if agentCfg := cfg.PrimaryAgent(); agentCfg.ID != "" {
 		providerCfg := cfg.GetProviderForModel(agentCfg.Model)
 		model := cfg.GetModelByType(agentCfg.Model)
 		if providerCfg != nil && model != nil &&
-			providerCfg.Type == catwalk.TypeAnthropic && model.CanReason {
+			(providerCfg.Type == catwalk.TypeAnthropic || providerCfg.Type == config.TypeOllama) && model.CanReason {
 			selectedModel := cfg.Models[agentCfg.Model]
 			status := "Enable"
 			if selectedModel.Think {
@@ -334,7 +382,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 	if c.sessionID != "" {
-		agentCfg := config.Get().Agents["coder"]
+		agentCfg := config.Get().PrimaryAgent()
 		model := config.Get().GetModelByType(agentCfg.Model)
 		if model.SupportsImages {
 			commands = append(commands, Command{
@@ -371,6 +419,22 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "permission_grants",
+			Title:       "Permission Grants",
+			Description: "Review and revoke saved permission grants",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenGrantsMsg{})
+			},
+		},
+		{
+			ID:          "shell_environment",
+			Title:       "Shell Environment",
+			Description: "Show the environment of the shell used by the bash tool",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenShellEnvMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
@@ -6,6 +6,7 @@ import (
 
 type CommandsDialogKeyMap struct {
 	Select,
+	Edit,
 	Next,
 	Previous,
 	Tab,
@@ -18,6 +19,10 @@ func DefaultCommandsDialogKeyMap() CommandsDialogKeyMap {
 			key.WithKeys("enter", "ctrl+y"),
 			key.WithHelp("enter", "confirm"),
 		),
+		Edit: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("ctrl+e", "edit"),
+		),
 		Next: key.NewBinding(
 			key.WithKeys("down", "ctrl+n"),
 			key.WithHelp("↓", "next item"),
@@ -41,6 +46,7 @@ func DefaultCommandsDialogKeyMap() CommandsDialogKeyMap {
 func (k CommandsDialogKeyMap) KeyBindings() []key.Binding {
 	return []key.Binding{
 		k.Select,
+		k.Edit,
 		k.Next,
 		k.Previous,
 		k.Tab,
@@ -68,6 +74,7 @@ func (k CommandsDialogKeyMap) ShortHelp() []key.Binding {
 			key.WithHelp("↑↓", "choose"),
 		),
 		k.Select,
+		k.Edit,
 		k.Close,
 	}
 }
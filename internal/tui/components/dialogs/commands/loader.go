@@ -158,11 +158,13 @@ func createCommandHandler(id string, content string) func(Command) tea.Cmd {
 				CommandID: id,
 				Content:   content,
 				ArgNames:  args,
+				Edit:      cmd.Edit,
 			})
 		}
 
 		return util.CmdHandler(CommandRunCustomMsg{
 			Content: content,
+			Edit:    cmd.Edit,
 		})
 	}
 }
@@ -200,4 +202,5 @@ func isMarkdownFile(name string) bool {
 
 type CommandRunCustomMsg struct {
 	Content string
+	Edit    bool // Insert the content into the editor instead of sending it
 }
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/vikvang/zero/internal/llm/agent"
+	"github.com/vikvang/zero/internal/tui/util"
+)
+
+const MCPCommandPrefix = "mcp:"
+
+// mcpPromptTimeout bounds the time spent getting a prompt from an MCP server.
+const mcpPromptTimeout = 30 * time.Second
+
+// LoadMCPPrompts returns the prompts of the connected MCP servers as
+// commands.
+func LoadMCPPrompts() []Command {
+	prompts := agent.GetMCPPrompts()
+	commands := make([]Command, 0, len(prompts))
+	for _, prompt := range prompts {
+		id := MCPCommandPrefix + prompt.MCPName + ":" + prompt.Name
+		description := prompt.Description
+		if description == "" {
+			description = fmt.Sprintf("Prompt from %s", prompt.MCPName)
+		}
+		commands = append(commands, Command{
+			ID:          id,
+			Title:       id,
+			Description: description,
+			Handler:     createMCPPromptHandler(id, prompt),
+		})
+	}
+	return commands
+}
+
+func createMCPPromptHandler(id string, prompt agent.MCPPrompt) func(Command) tea.Cmd {
+	return func(cmd Command) tea.Cmd {
+		if len(prompt.Arguments) > 0 {
+			argNames := make([]string, len(prompt.Arguments))
+			for i, arg := range prompt.Arguments {
+				argNames[i] = arg.Name
+			}
+			return util.CmdHandler(ShowArgumentsDialogMsg{
+				CommandID: id,
+				ArgNames:  argNames,
+				Edit:      cmd.Edit,
+				Submit: func(args map[string]string) tea.Cmd {
+					return getMCPPrompt(prompt, args, cmd.Edit)
+				},
+			})
+		}
+		return getMCPPrompt(prompt, nil, cmd.Edit)
+	}
+}
+
+// getMCPPrompt gets the prompt from its MCP server and runs it, or inserts it
+// into the editor.
+func getMCPPrompt(prompt agent.MCPPrompt, args map[string]string, edit bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpPromptTimeout)
+		defer cancel()
+		content, err := agent.GetMCPPrompt(ctx, prompt.MCPName, prompt.Name, args)
+		if err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return CommandRunCustomMsg{
+			Content: content,
+			Edit:    edit,
+		}
+	}
+}
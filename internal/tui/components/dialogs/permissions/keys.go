@@ -11,6 +11,8 @@ type KeyMap struct {
 	Select,
 	Allow,
 	AllowSession,
+	AllowProject,
+	AllowGlobally,
 	Deny,
 	ToggleDiffMode,
 	ScrollDown,
@@ -41,6 +43,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("s", "S", "ctrl+s"),
 			key.WithHelp("s", "allow session"),
 		),
+		AllowProject: key.NewBinding(
+			key.WithKeys("p", "P"),
+			key.WithHelp("p", "allow project"),
+		),
+		AllowGlobally: key.NewBinding(
+			key.WithKeys("g", "G"),
+			key.WithHelp("g", "allow globally"),
+		),
 		Deny: key.NewBinding(
 			key.WithKeys("d", "D", "ctrl+d", "esc"),
 			key.WithHelp("d", "deny"),
@@ -81,6 +91,8 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.Select,
 		k.Allow,
 		k.AllowSession,
+		k.AllowProject,
+		k.AllowGlobally,
 		k.Deny,
 		k.ToggleDiffMode,
 		k.ScrollDown,
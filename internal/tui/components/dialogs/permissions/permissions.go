@@ -25,11 +25,16 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
+	PermissionAllowForProject PermissionAction = "allow_project"
+	PermissionAllowGlobally   PermissionAction = "allow_global"
 	PermissionDeny            PermissionAction = "deny"
 
 	PermissionsDialogID dialogs.DialogID = "permissions"
 )
 
+// permissionOptions is the number of buttons in the dialog.
+const permissionOptions = 5
+
 // PermissionResponseMsg represents the user's response to a permission request
 type PermissionResponseMsg struct {
 	Permission permission.PermissionRequest
@@ -49,7 +54,7 @@ type permissionDialogCmp struct {
 	height          int
 	permission      permission.PermissionRequest
 	contentViewPort viewport.Model
-	selectedOption  int // 0: Allow, 1: Allow for session, 2: Deny
+	selectedOption  int // 0: Allow, 1: Allow for session, 2: Allow for project, 3: Allow globally, 4: Deny
 
 	// Diff view state
 	defaultDiffSplitMode bool  // true for split, false for unified
@@ -91,7 +96,7 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName || p.permission.ToolName == tools.LSPRefactorToolName
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -107,10 +112,10 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.selectedOption = (p.selectedOption + 1) % permissionOptions
 			return p, nil
 		case key.Matches(msg, p.keyMap.Left):
-			p.selectedOption = (p.selectedOption + 2) % 3
+			p.selectedOption = (p.selectedOption + permissionOptions - 1) % permissionOptions
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
@@ -123,6 +128,16 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission}),
 			)
+		case key.Matches(msg, p.keyMap.AllowProject):
+			return p, tea.Batch(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForProject, Permission: p.permission}),
+			)
+		case key.Matches(msg, p.keyMap.AllowGlobally):
+			return p, tea.Batch(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowGlobally, Permission: p.permission}),
+			)
 		case key.Matches(msg, p.keyMap.Deny):
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
@@ -227,6 +242,10 @@ func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
 	case 1:
 		action = PermissionAllowForSession
 	case 2:
+		action = PermissionAllowForProject
+	case 3:
+		action = PermissionAllowGlobally
+	case 4:
 		action = PermissionDeny
 	}
 
@@ -251,10 +270,20 @@ func (p *permissionDialogCmp) renderButtons() string {
 			UnderlineIndex: 10, // "S" in "Session"
 			Selected:       p.selectedOption == 1,
 		},
+		{
+			Text:           "Allow for Project",
+			UnderlineIndex: 10, // "P" in "Project"
+			Selected:       p.selectedOption == 2,
+		},
+		{
+			Text:           "Allow Globally",
+			UnderlineIndex: 6, // "G" in "Globally"
+			Selected:       p.selectedOption == 3,
+		},
 		{
 			Text:           "Deny",
 			UnderlineIndex: 0, // "D"
-			Selected:       p.selectedOption == 2,
+			Selected:       p.selectedOption == 4,
 		},
 	}
 
@@ -370,6 +399,20 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
+	case tools.LSPRefactorToolName:
+		params := p.permission.Params.(tools.LSPRefactorPermissionsParams)
+		changeKey := t.S().Muted.Render("Change")
+		changeValue := t.S().Text.
+			Width(p.width - lipgloss.Width(changeKey)).
+			Render(fmt.Sprintf(" %s in %d file(s)", params.Description, len(params.Files)))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				changeKey,
+				changeValue,
+			),
+			baseStyle.Render(strings.Repeat(" ", p.width)),
+		)
 	case tools.FetchToolName:
 		headerParts = append(headerParts, t.S().Muted.Width(p.width).Bold(true).Render("URL"))
 	case tools.ViewToolName:
@@ -424,6 +467,8 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateWriteContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
+	case tools.LSPRefactorToolName:
+		content = p.generateLSPRefactorContent()
 	case tools.FetchToolName:
 		content = p.generateFetchContent()
 	case tools.ViewToolName:
@@ -450,6 +495,21 @@ func (p *permissionDialogCmp) generateBashContent() string {
 		content = strings.TrimSpace(content)
 		lines := strings.Split(content, "\n")
 
+		// List every program of chained commands and the files written to,
+		// as they are easy to miss in a long command.
+		if len(pr.Programs) > 1 || len(pr.Writes) > 0 {
+			lines = append(lines, "", "Runs:")
+			for _, program := range pr.Programs {
+				lines = append(lines, "  • "+program)
+			}
+			if len(pr.Writes) > 0 {
+				lines = append(lines, "", "Writes:")
+				for _, target := range pr.Writes {
+					lines = append(lines, "  • "+target)
+				}
+			}
+		}
+
 		width := p.width - 4
 		var out []string
 		for _, ln := range lines {
@@ -556,6 +616,32 @@ func (p *permissionDialogCmp) generateMultiEditContent() string {
 	return ""
 }
 
+// generateLSPRefactorContent renders the diffs of all the files changed by
+// the refactoring one after the other, scrolled as a whole.
+func (p *permissionDialogCmp) generateLSPRefactorContent() string {
+	if pr, ok := p.permission.Params.(tools.LSPRefactorPermissionsParams); ok {
+		var lines []string
+		for _, file := range pr.Files {
+			formatter := core.DiffFormatter().
+				Before(fsext.PrettyPath(file.FilePath), file.OldContent).
+				After(fsext.PrettyPath(file.FilePath), file.NewContent).
+				Width(p.contentViewPort.Width()).
+				XOffset(p.diffXOffset)
+			if p.useDiffSplitMode() {
+				formatter = formatter.Split()
+			} else {
+				formatter = formatter.Unified()
+			}
+			lines = append(lines, strings.Split(formatter.String(), "\n")...)
+		}
+
+		height := max(9, p.height-9) // same as the content height in render
+		p.diffYOffset = max(0, min(p.diffYOffset, len(lines)-height))
+		return strings.Join(lines[p.diffYOffset:min(len(lines), p.diffYOffset+height)], "\n")
+	}
+	return ""
+}
+
 func (p *permissionDialogCmp) generateFetchContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -743,6 +829,9 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.MultiEditToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
+	case tools.LSPRefactorToolName:
+		p.width = int(float64(p.wWidth) * 0.8)
+		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.FetchToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.3)
@@ -0,0 +1,292 @@
+package rewind
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/viewport"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/vikvang/zero/internal/fsext"
+	"github.com/vikvang/zero/internal/history"
+	"github.com/vikvang/zero/internal/tui/components/core"
+	"github.com/vikvang/zero/internal/tui/components/dialogs"
+	"github.com/vikvang/zero/internal/tui/exp/list"
+	"github.com/vikvang/zero/internal/tui/styles"
+	"github.com/vikvang/zero/internal/tui/util"
+)
+
+const RewindDialogID dialogs.DialogID = "rewind"
+
+// RewindDialog interface for the checkpoint rewind dialog
+type RewindDialog interface {
+	dialogs.DialogModel
+}
+
+// Checkpoint is a checkpoint along with the prompt of its turn.
+type Checkpoint struct {
+	history.Checkpoint
+	Prompt string
+}
+
+// RewindMsg asks to restore a checkpoint.
+type RewindMsg struct {
+	Checkpoint history.Checkpoint
+	// Conversation also rewinds the conversation to before the turn.
+	Conversation bool
+}
+
+type previewMsg struct {
+	changes []history.FileChange
+	err     error
+}
+
+type CheckpointsList = list.FilterableList[list.CompletionItem[Checkpoint]]
+
+type rewindDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	files           history.Service
+	keyMap          KeyMap
+	checkpointsList CheckpointsList
+	help            help.Model
+
+	// Set while previewing a checkpoint.
+	selected *Checkpoint
+	changes  []history.FileChange
+	preview  viewport.Model
+}
+
+// NewRewindDialogCmp creates a dialog to pick a checkpoint of the session,
+// preview the changes restoring it makes and restore it.
+func NewRewindDialogCmp(files history.Service, checkpoints []Checkpoint) RewindDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	// Most recent turns first.
+	items := make([]list.CompletionItem[Checkpoint], 0, len(checkpoints))
+	for i, checkpoint := range slices.Backward(checkpoints) {
+		title := fmt.Sprintf("#%d %s", i+1, firstLine(checkpoint.Prompt))
+		items = append(items, list.NewCompletionItem(title, checkpoint, list.WithCompletionID(checkpoint.ID)))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	checkpointsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Rewind to before a prompt"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &rewindDialogCmp{
+		files:           files,
+		keyMap:          keyMap,
+		checkpointsList: checkpointsList,
+		help:            help,
+		preview:         viewport.New(),
+	}
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
+
+func (r *rewindDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(r.checkpointsList.Init(), r.checkpointsList.Focus())
+}
+
+func (r *rewindDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		r.width = min(160, r.wWidth-8)
+		r.checkpointsList.SetInputWidth(r.listWidth() - 2)
+		r.renderPreview()
+		return r, r.checkpointsList.SetSize(r.listWidth(), r.listHeight())
+	case previewMsg:
+		if msg.err != nil {
+			r.selected = nil
+			return r, util.ReportError(msg.err)
+		}
+		r.changes = msg.changes
+		r.renderPreview()
+		return r, nil
+	case tea.KeyPressMsg:
+		if r.selected != nil {
+			return r, r.updatePreview(msg)
+		}
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.checkpointsList.SelectedItem()
+			if selectedItem == nil {
+				return r, nil
+			}
+			checkpoint := (*selectedItem).Value()
+			r.selected = &checkpoint
+			r.changes = nil
+			return r, func() tea.Msg {
+				changes, err := r.files.PreviewCheckpoint(context.Background(), checkpoint.ID)
+				return previewMsg{changes: changes, err: err}
+			}
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := r.checkpointsList.Update(msg)
+			r.checkpointsList = u.(CheckpointsList)
+			return r, cmd
+		}
+	}
+	return r, nil
+}
+
+func (r *rewindDialogCmp) updatePreview(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, r.keyMap.RestoreFiles), key.Matches(msg, r.keyMap.RestoreAll):
+		return tea.Sequence(
+			util.CmdHandler(dialogs.CloseDialogMsg{}),
+			util.CmdHandler(RewindMsg{
+				Checkpoint:   r.selected.Checkpoint,
+				Conversation: key.Matches(msg, r.keyMap.RestoreAll),
+			}),
+		)
+	case key.Matches(msg, r.keyMap.ScrollDown):
+		r.preview.ScrollDown(1)
+	case key.Matches(msg, r.keyMap.ScrollUp):
+		r.preview.ScrollUp(1)
+	case key.Matches(msg, r.keyMap.Close):
+		r.selected = nil
+		r.changes = nil
+	}
+	return nil
+}
+
+func (r *rewindDialogCmp) renderPreview() {
+	if r.selected == nil {
+		return
+	}
+	t := styles.CurrentTheme()
+	width := r.width - 4
+	r.preview.SetWidth(width)
+	r.preview.SetHeight(r.previewHeight())
+
+	if len(r.changes) == 0 {
+		r.preview.SetContent(t.S().Muted.Render("The files already match the checkpoint."))
+		return
+	}
+	parts := make([]string, 0, len(r.changes))
+	for _, change := range r.changes {
+		path := fsext.PrettyPath(change.Path)
+		if change.Deleted {
+			parts = append(parts, t.S().Muted.Render(path+" will be deleted"))
+			continue
+		}
+		parts = append(parts, core.DiffFormatter().
+			Before(path, change.Before).
+			After(path, change.After).
+			Width(width).
+			Unified().
+			String())
+	}
+	r.preview.SetContent(strings.Join(parts, "\n\n"))
+	r.preview.GotoTop()
+}
+
+func (r *rewindDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	if r.selected != nil {
+		summary := fmt.Sprintf("Rewinding to before %q changes %d file(s).", firstLine(r.selected.Prompt), len(r.changes))
+		content := lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Rewind Preview", r.width-4)),
+			t.S().Text.PaddingLeft(1).Width(r.width-2).Render(summary),
+			"",
+			t.S().Base.PaddingLeft(1).Render(r.preview.View()),
+			"",
+			t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(previewKeyMap(r.keyMap))),
+		)
+		return r.style().Render(content)
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Rewind", r.width-4)),
+		r.checkpointsList.View(),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(content)
+}
+
+func (r *rewindDialogCmp) Cursor() *tea.Cursor {
+	if r.selected != nil {
+		return nil
+	}
+	if cursor, ok := r.checkpointsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = r.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (r *rewindDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *rewindDialogCmp) listHeight() int {
+	return r.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (r *rewindDialogCmp) listWidth() int {
+	return r.width - 2 // 2 for the border
+}
+
+func (r *rewindDialogCmp) previewHeight() int {
+	return int(float64(r.wHeight)*0.7) - 8 // border, title, summary and help
+}
+
+func (r *rewindDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	if r.selected != nil {
+		row = r.wHeight / 8
+	}
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+func (r *rewindDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := r.Position()
+	offset := row + 3 // Border + title
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+// ID implements RewindDialog.
+func (r *rewindDialogCmp) ID() dialogs.DialogID {
+	return RewindDialogID
+}
@@ -0,0 +1,172 @@
+package shellenv
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/vikvang/zero/internal/tui/components/core"
+	"github.com/vikvang/zero/internal/tui/components/dialogs"
+	"github.com/vikvang/zero/internal/tui/exp/list"
+	"github.com/vikvang/zero/internal/tui/styles"
+	"github.com/vikvang/zero/internal/tui/util"
+)
+
+const ShellEnvDialogID dialogs.DialogID = "shell_env"
+
+// ShellEnvDialog interface for the shell environment dialog
+type ShellEnvDialog interface {
+	dialogs.DialogModel
+}
+
+type EnvList = list.FilterableList[list.CompletionItem[string]]
+
+type shellEnvDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	initErr error
+	keyMap  KeyMap
+	envList EnvList
+	help    help.Model
+}
+
+// NewShellEnvDialogCmp creates a dialog showing the environment of the
+// persistent shell, and the error of its init script if it failed.
+func NewShellEnvDialogCmp(env []string, initErr error) ShellEnvDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	env = slices.Sorted(slices.Values(env))
+	items := make([]list.CompletionItem[string], len(env))
+	for i, kv := range env {
+		items[i] = list.NewCompletionItem(kv, kv, list.WithCompletionID(kv))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	envList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Filter variables"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &shellEnvDialogCmp{
+		initErr: initErr,
+		keyMap:  keyMap,
+		envList: envList,
+		help:    help,
+	}
+}
+
+func (s *shellEnvDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(s.envList.Init(), s.envList.Focus())
+}
+
+func (s *shellEnvDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.wWidth = msg.Width
+		s.wHeight = msg.Height
+		s.width = min(120, s.wWidth-8)
+		s.envList.SetInputWidth(s.listWidth() - 2)
+		return s, s.envList.SetSize(s.listWidth(), s.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return s, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := s.envList.Update(msg)
+			s.envList = u.(EnvList)
+			return s, cmd
+		}
+	}
+	return s, nil
+}
+
+func (s *shellEnvDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Shell Environment", s.width-4)),
+	}
+	if s.initErr != nil {
+		parts = append(parts, s.initErrorView())
+	}
+	parts = append(parts,
+		s.envList.View(),
+		"",
+		t.S().Base.Width(s.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(s.help.View(s.keyMap)),
+	)
+
+	return s.style().Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (s *shellEnvDialogCmp) initErrorView() string {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Foreground(t.Error).
+		Width(s.width-2).
+		Padding(0, 1, 1, 1).
+		Render("Init script failed: " + s.initErr.Error())
+}
+
+func (s *shellEnvDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := s.envList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = s.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (s *shellEnvDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(s.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (s *shellEnvDialogCmp) listHeight() int {
+	return s.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (s *shellEnvDialogCmp) listWidth() int {
+	return s.width - 2 // 2 for the border
+}
+
+func (s *shellEnvDialogCmp) Position() (int, int) {
+	row := s.wHeight/4 - 2 // just a bit above the center
+	col := s.wWidth / 2
+	col -= s.width / 2
+	return row, col
+}
+
+func (s *shellEnvDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := s.Position()
+	offset := row + 3 // Border + title
+	if s.initErr != nil {
+		offset += lipgloss.Height(s.initErrorView())
+	}
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+// ID implements ShellEnvDialog.
+func (s *shellEnvDialogCmp) ID() dialogs.DialogID {
+	return ShellEnvDialogID
+}
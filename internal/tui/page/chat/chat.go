@@ -12,6 +12,7 @@ import (
 	"github.com/vikvang/zero/internal/app"
 	"github.com/vikvang/zero/internal/config"
 	"github.com/vikvang/zero/internal/history"
+	"github.com/vikvang/zero/internal/llm/tools"
 	"github.com/vikvang/zero/internal/message"
 	"github.com/vikvang/zero/internal/permission"
 	"github.com/vikvang/zero/internal/pubsub"
@@ -236,6 +237,14 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case chat.SendMsg:
 		return p, p.sendMessage(msg.Text, msg.Attachments)
 	case chat.SessionSelectedMsg:
+		if p.isProjectInit && p.splashFullScreen {
+			// Opening an existing session, e.g. with --session, skips the
+			// project initialization prompt for now.
+			p.splash.SetProjectInit(false)
+			p.isProjectInit = false
+			p.splashFullScreen = false
+			p.focusedPane = PanelTypeEditor
+		}
 		return p, p.setSession(msg)
 	case splash.SubmitAPIKeyMsg:
 		u, cmd := p.splash.Update(msg)
@@ -255,6 +264,11 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return p, tea.Batch(p.SetSize(p.width, p.height), cmd)
 	case commands.ToggleThinkingMsg:
 		return p, p.toggleThinking()
+	case commands.SwitchAgentMsg:
+		if err := p.app.SwitchAgent(msg.AgentID); err != nil {
+			return p, util.ReportError(err)
+		}
+		return p, util.ReportInfo("Switched to the " + config.Get().PrimaryAgent().Name + " agent")
 	case commands.OpenExternalEditorMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
@@ -267,6 +281,10 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
+	case chat.SessionRewoundMsg:
+		u, cmd := p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		return p, cmd
 	case chat.SessionClearedMsg:
 		u, cmd := p.header.Update(msg)
 		p.header = u.(header.Header)
@@ -317,13 +335,18 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
-	case pubsub.Event[permission.PermissionNotification]:
+	case pubsub.Event[permission.PermissionNotification], pubsub.Event[tools.BashOutput]:
 		u, cmd := p.chat.Update(msg)
 		p.chat = u.(chat.MessageListCmp)
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
 
 	case commands.CommandRunCustomMsg:
+		if msg.Edit {
+			u, cmd := p.editor.Update(editor.OpenEditorMsg{Text: msg.Content})
+			p.editor = u.(editor.Editor)
+			return p, cmd
+		}
 		if p.app.CoderAgent.IsBusy() {
 			return p, util.ReportWarn("Agent is busy, please wait before executing a command...")
 		}
@@ -364,7 +387,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return p, p.newSession()
 		case key.Matches(msg, p.keyMap.AddAttachment):
-			agentCfg := config.Get().Agents["coder"]
+			agentCfg := config.Get().PrimaryAgent()
 			model := config.Get().GetModelByType(agentCfg.Model)
 			if model.SupportsImages {
 				return p, util.CmdHandler(commands.OpenFilePickerMsg{})
@@ -523,7 +546,7 @@ func (p *chatPage) updateCompactConfig(compact bool) tea.Cmd {
 func (p *chatPage) toggleThinking() tea.Cmd {
 	return func() tea.Msg {
 		cfg := config.Get()
-		agentCfg := cfg.Agents["coder"]
+		agentCfg := cfg.PrimaryAgent()
 		currentModel := cfg.Models[agentCfg.Model]
 
 		// Toggle the thinking mode
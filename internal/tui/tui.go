@@ -13,6 +13,8 @@ import (
 	"github.com/vikvang/zero/internal/llm/agent"
 	"github.com/vikvang/zero/internal/permission"
 	"github.com/vikvang/zero/internal/pubsub"
+	"github.com/vikvang/zero/internal/session"
+	"github.com/vikvang/zero/internal/shell"
 	cmpChat "github.com/vikvang/zero/internal/tui/components/chat"
 	"github.com/vikvang/zero/internal/tui/components/chat/splash"
 	"github.com/vikvang/zero/internal/tui/components/completions"
@@ -23,10 +25,13 @@ import (
 	"github.com/vikvang/zero/internal/tui/components/dialogs/commands"
 	"github.com/vikvang/zero/internal/tui/components/dialogs/compact"
 	"github.com/vikvang/zero/internal/tui/components/dialogs/filepicker"
+	"github.com/vikvang/zero/internal/tui/components/dialogs/grants"
 	"github.com/vikvang/zero/internal/tui/components/dialogs/models"
 	"github.com/vikvang/zero/internal/tui/components/dialogs/permissions"
 	"github.com/vikvang/zero/internal/tui/components/dialogs/quit"
+	"github.com/vikvang/zero/internal/tui/components/dialogs/rewind"
 	"github.com/vikvang/zero/internal/tui/components/dialogs/sessions"
+	"github.com/vikvang/zero/internal/tui/components/dialogs/shellenv"
 	"github.com/vikvang/zero/internal/tui/page"
 	"github.com/vikvang/zero/internal/tui/page/chat"
 	"github.com/vikvang/zero/internal/tui/styles"
@@ -72,6 +77,18 @@ type appModel struct {
 
 	// Chat Page Specific
 	selectedSessionID string // The ID of the currently selected session
+
+	initialSession session.Session // The session to open on start, if any
+}
+
+// Option configures the TUI.
+type Option func(*appModel)
+
+// WithSession opens the given session when the TUI starts.
+func WithSession(sess session.Session) Option {
+	return func(a *appModel) {
+		a.initialSession = sess
+	}
 }
 
 // Init initializes the application model and returns initial commands.
@@ -91,6 +108,10 @@ func (a appModel) Init() tea.Cmd {
 
 	cmds = append(cmds, tea.EnableMouseAllMotion)
 
+	if a.initialSession.ID != "" {
+		cmds = append(cmds, util.CmdHandler(cmpChat.SessionSelectedMsg(a.initialSession)))
+	}
+
 	return tea.Batch(cmds...)
 }
 
@@ -138,11 +159,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case commands.ShowArgumentsDialogMsg:
 		return a, util.CmdHandler(
 			dialogs.OpenDialogMsg{
-				Model: commands.NewCommandArgumentsDialog(
-					msg.CommandID,
-					msg.Content,
-					msg.ArgNames,
-				),
+				Model: commands.NewCommandArgumentsDialog(msg),
 			},
 		)
 	// Page change messages
@@ -170,6 +187,59 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case commands.OpenGrantsMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: grants.NewGrantsDialogCmp(a.app.Permissions),
+		})
+
+	case commands.OpenShellEnvMsg:
+		sh := shell.GetPersistentShell(a.app.Config().WorkingDir())
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: shellenv.NewShellEnvDialogCmp(sh.GetEnv(), sh.InitError()),
+		})
+
+	case commands.OpenRewindMsg:
+		return a, func() tea.Msg {
+			ctx := context.Background()
+			checkpoints, err := a.app.History.ListCheckpoints(ctx, msg.SessionID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: fmt.Sprintf("Failed to list checkpoints: %v", err)}
+			}
+			if len(checkpoints) == 0 {
+				return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "No checkpoints to rewind to yet"}
+			}
+			items := make([]rewind.Checkpoint, len(checkpoints))
+			for i, checkpoint := range checkpoints {
+				items[i] = rewind.Checkpoint{Checkpoint: checkpoint}
+				if userMsg, err := a.app.Messages.Get(ctx, checkpoint.MessageID); err == nil {
+					items[i].Prompt = userMsg.Content().Text
+				}
+			}
+			return dialogs.OpenDialogMsg{
+				Model: rewind.NewRewindDialogCmp(a.app.History, items),
+			}
+		}
+	case rewind.RewindMsg:
+		return a, func() tea.Msg {
+			ctx := context.Background()
+			changes, err := a.app.Rewind(ctx, msg.Checkpoint, msg.Conversation)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			info := util.InfoMsg{Type: util.InfoTypeInfo, Msg: fmt.Sprintf("Restored %d file(s)", len(changes))}
+			if !msg.Conversation {
+				return info
+			}
+			sess, err := a.app.Sessions.Get(ctx, msg.Checkpoint.SessionID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return tea.BatchMsg{
+				util.CmdHandler(cmpChat.SessionRewoundMsg{Session: sess}),
+				util.CmdHandler(info),
+			}
+		}
+
 	case commands.SwitchModelMsg:
 		return a, util.CmdHandler(
 			dialogs.OpenDialogMsg{
@@ -243,7 +313,11 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case permissions.PermissionAllow:
 			a.app.Permissions.Grant(msg.Permission)
 		case permissions.PermissionAllowForSession:
-			a.app.Permissions.GrantPersistent(msg.Permission)
+			a.app.Permissions.GrantPersistent(msg.Permission, permission.GrantScopeSession)
+		case permissions.PermissionAllowForProject:
+			a.app.Permissions.GrantPersistent(msg.Permission, permission.GrantScopeProject)
+		case permissions.PermissionAllowGlobally:
+			a.app.Permissions.GrantPersistent(msg.Permission, permission.GrantScopeGlobal)
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
 		}
@@ -262,6 +336,10 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, dialogCmd)
 		}
 
+		if payload.Type == agent.AgentEventTypeWarning {
+			cmds = append(cmds, util.ReportWarn(payload.Warning))
+		}
+
 		// Handle auto-compact logic
 		if payload.Done && payload.Type == agent.AgentEventTypeResponse && a.selectedSessionID != "" {
 			// Get current session to check token usage
@@ -599,7 +677,7 @@ func (a *appModel) View() tea.View {
 }
 
 // New creates and initializes a new TUI application model.
-func New(app *app.App) tea.Model {
+func New(app *app.App, opts ...Option) tea.Model {
 	chatPage := chat.New(app)
 	keyMap := DefaultKeyMap()
 	keyMap.pageBindings = chatPage.Bindings()
@@ -618,6 +696,9 @@ func New(app *app.App) tea.Model {
 		dialog:      dialogs.NewDialogCmp(),
 		completions: completions.New(),
 	}
+	for _, opt := range opts {
+		opt(model)
+	}
 
 	return model
 }
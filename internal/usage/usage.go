@@ -0,0 +1,158 @@
+// Package usage reports the token usage and cost recorded on the messages of
+// the sessions of a project.
+package usage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/vikvang/zero/internal/db"
+)
+
+// GroupBy is how the usage is grouped in a report.
+type GroupBy string
+
+const (
+	GroupByDay      GroupBy = "day"
+	GroupByModel    GroupBy = "model"
+	GroupByProvider GroupBy = "provider"
+	// GroupBySession groups the usage by top-level session, the sessions of
+	// sub-agents are included in the session that started them.
+	GroupBySession GroupBy = "session"
+	// GroupByTurn reports each response of the model on its own.
+	GroupByTurn GroupBy = "turn"
+)
+
+// GroupBys lists all the supported groupings.
+var GroupBys = []GroupBy{
+	GroupByDay,
+	GroupByModel,
+	GroupByProvider,
+	GroupBySession,
+	GroupByTurn,
+}
+
+// IsValid reports whether the grouping is supported.
+func (g GroupBy) IsValid() bool {
+	return slices.Contains(GroupBys, g)
+}
+
+// Record is the usage of a single response of the model.
+type Record struct {
+	MessageID string
+	SessionID string
+	// RootSessionID is the top-level session of the response, the session
+	// that started the sub-agent for responses of sub-agents.
+	RootSessionID    string
+	RootSessionTitle string
+	Model            string
+	Provider         string
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+	CreatedAt        int64
+}
+
+// Row is the total usage of a group of responses.
+type Row struct {
+	Key              string  `json:"key"`
+	Label            string  `json:"label,omitempty"`
+	Responses        int     `json:"responses"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (r *Row) add(record Record) {
+	r.Responses++
+	r.PromptTokens += record.PromptTokens
+	r.CompletionTokens += record.CompletionTokens
+	r.Cost += record.Cost
+}
+
+// Report is the usage of a project, grouped by GroupBy.
+type Report struct {
+	GroupBy GroupBy   `json:"group_by"`
+	Since   time.Time `json:"since,omitzero"`
+	Rows    []Row     `json:"rows"`
+	Total   Row       `json:"total"`
+}
+
+// List returns the usage of the responses created since the given time,
+// oldest first.
+func List(ctx context.Context, q db.Querier, since time.Time) ([]Record, error) {
+	rows, err := q.ListMessageUsage(ctx, since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message usage: %w", err)
+	}
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		record := Record{
+			MessageID:        row.ID,
+			SessionID:        row.SessionID,
+			RootSessionID:    row.SessionID,
+			RootSessionTitle: row.Title,
+			Model:            row.Model.String,
+			Provider:         row.Provider.String,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			Cost:             row.Cost,
+			CreatedAt:        row.CreatedAt,
+		}
+		if row.ParentSessionID.Valid {
+			record.RootSessionID = row.ParentSessionID.String
+			record.RootSessionTitle = row.ParentTitle.String
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// NewReport groups the records. Days and turns are listed in order, the
+// other groups by decreasing cost.
+func NewReport(records []Record, groupBy GroupBy, since time.Time) Report {
+	report := Report{
+		GroupBy: groupBy,
+		Since:   since,
+		Rows:    []Row{},
+		Total:   Row{Key: "total"},
+	}
+	index := make(map[string]int)
+	for _, record := range records {
+		key, label := groupKey(record, groupBy)
+		// The same model can be used through several providers.
+		id := key + "\x00" + label
+		i, ok := index[id]
+		if !ok {
+			i = len(report.Rows)
+			index[id] = i
+			report.Rows = append(report.Rows, Row{Key: key, Label: label})
+		}
+		report.Rows[i].add(record)
+		report.Total.add(record)
+	}
+	if groupBy != GroupByDay && groupBy != GroupByTurn {
+		slices.SortStableFunc(report.Rows, func(a, b Row) int {
+			return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.Key, b.Key))
+		})
+	}
+	return report
+}
+
+func groupKey(record Record, groupBy GroupBy) (key, label string) {
+	switch groupBy {
+	case GroupByModel:
+		return record.Model, record.Provider
+	case GroupByProvider:
+		return record.Provider, ""
+	case GroupBySession:
+		return record.RootSessionID, record.RootSessionTitle
+	case GroupByTurn:
+		return record.MessageID, time.Unix(record.CreatedAt, 0).Format("2006-01-02 15:04:05") + " " + record.Model
+	default:
+		return time.Unix(record.CreatedAt, 0).Format("2006-01-02"), ""
+	}
+}
@@ -0,0 +1,94 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vikvang/zero/internal/db"
+)
+
+type fakeQuerier struct {
+	db.Querier
+	rows []db.ListMessageUsageRow
+}
+
+func (f fakeQuerier) ListMessageUsage(_ context.Context, createdAt int64) ([]db.ListMessageUsageRow, error) {
+	var rows []db.ListMessageUsageRow
+	for _, row := range f.rows {
+		if row.CreatedAt >= createdAt {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, 8, 25, 10, 0, 0, 0, time.Local)
+	q := fakeQuerier{rows: []db.ListMessageUsageRow{
+		{ID: "old", SessionID: "parent", Title: "Fix the tests", CreatedAt: day.Add(-48 * time.Hour).Unix()},
+		{ID: "m1", SessionID: "parent", Title: "Fix the tests", Model: sql.NullString{String: "claude", Valid: true}, Cost: 1, CreatedAt: day.Unix()},
+		{
+			ID:              "m2",
+			SessionID:       "child",
+			ParentSessionID: sql.NullString{String: "parent", Valid: true},
+			Title:           "New Task Agent Session",
+			ParentTitle:     sql.NullString{String: "Fix the tests", Valid: true},
+			CreatedAt:       day.Unix(),
+		},
+	}}
+
+	records, err := List(context.Background(), q, day.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "claude", records[0].Model)
+	require.Equal(t, "child", records[1].SessionID)
+	require.Equal(t, "parent", records[1].RootSessionID, "sub-agent usage belongs to the session that started it")
+	require.Equal(t, "Fix the tests", records[1].RootSessionTitle)
+}
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, 8, 25, 10, 0, 0, 0, time.Local)
+	records := []Record{
+		{MessageID: "m1", RootSessionID: "s1", RootSessionTitle: "First", Model: "claude", Provider: "anthropic", PromptTokens: 100, CompletionTokens: 10, Cost: 1, CreatedAt: day.Unix()},
+		{MessageID: "m2", RootSessionID: "s1", RootSessionTitle: "First", Model: "claude", Provider: "bedrock", PromptTokens: 200, CompletionTokens: 20, Cost: 2, CreatedAt: day.Unix()},
+		{MessageID: "m3", RootSessionID: "s2", RootSessionTitle: "Second", Model: "gpt", Provider: "openai", PromptTokens: 50, CompletionTokens: 5, Cost: 0.5, CreatedAt: day.Add(24 * time.Hour).Unix()},
+	}
+
+	keys := func(report Report) []string {
+		var keys []string
+		for _, row := range report.Rows {
+			keys = append(keys, row.Key+"/"+row.Label)
+		}
+		return keys
+	}
+
+	report := NewReport(records, GroupByDay, time.Time{})
+	require.Equal(t, []string{"2025-08-25/", "2025-08-26/"}, keys(report))
+	require.Equal(t, 2, report.Rows[0].Responses)
+	require.Equal(t, int64(300), report.Rows[0].PromptTokens)
+	require.Equal(t, Row{Key: "total", Responses: 3, PromptTokens: 350, CompletionTokens: 35, Cost: 3.5}, report.Total)
+
+	report = NewReport(records, GroupByModel, time.Time{})
+	require.Equal(t, []string{"claude/bedrock", "claude/anthropic", "gpt/openai"}, keys(report))
+
+	report = NewReport(records, GroupByProvider, time.Time{})
+	require.Equal(t, []string{"bedrock/", "anthropic/", "openai/"}, keys(report))
+
+	report = NewReport(records, GroupBySession, time.Time{})
+	require.Equal(t, []string{"s1/First", "s2/Second"}, keys(report))
+	require.InDelta(t, 3, report.Rows[0].Cost, 1e-9)
+
+	report = NewReport(records, GroupByTurn, time.Time{})
+	require.Equal(t, []string{"m1/2025-08-25 10:00:00 claude", "m2/2025-08-25 10:00:00 claude", "m3/2025-08-26 10:00:00 gpt"}, keys(report))
+
+	report = NewReport(nil, GroupByDay, time.Time{})
+	require.Empty(t, report.Rows)
+	require.NotNil(t, report.Rows, "rows are an empty list in JSON")
+}